@@ -0,0 +1,507 @@
+package GoTrees
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+)
+
+// node kinds of the adaptive radix tree. Inner nodes grow from 4 to 16 to 48 to 256 children as they fill up, and shrink back as children are deleted.
+const (
+	artLeaf = iota
+	artNode4
+	artNode16
+	artNode48
+	artNode256
+)
+
+// AdaptiveRadixTree is an adaptive radix tree (ART) with byte slice keys, iterated in lexicographic order.
+// Leaves hold the whole key, so an inner node is only created where two keys differ (lazy expansion), and runs of bytes shared by every key below an inner node are stored once in its prefix (path compression).
+// Like the RadixTree its keys are unique, so there is no Insert that keeps duplicates: Put stores a key or replaces its value.
+type AdaptiveRadixTree struct {
+	root *artNode
+	size uint64
+}
+
+// artNode is either a leaf or an inner node, depending on kind.
+type artNode struct {
+	kind uint8
+	// key and Val are only used by leaves
+	key []byte
+	Val interface{}
+	// prefix is the compressed path that every key below this inner node shares
+	prefix []byte
+	// value is the leaf of the key that ends exactly at this inner node, if there is one
+	value *artNode
+	// num is the number of children
+	num int
+	// keys holds the sorted key bytes of a Node4 or Node16, or the child slot (plus 1) for each byte of a Node48. It is unused by a Node256, which is indexed by byte directly.
+	keys     []byte
+	children []*artNode
+}
+
+// NewAdaptiveRadixTree returns an empty adaptive radix tree. The values will be initialized the same way when doing AdaptiveRadixTree{}
+func NewAdaptiveRadixTree() AdaptiveRadixTree {
+	return AdaptiveRadixTree{root: nil, size: 0}
+}
+
+// Put will set the value of key, inserting it if it is not in the tree. The key is copied.
+func (art *AdaptiveRadixTree) Put(key []byte, value interface{}) {
+	leaf := &artNode{kind: artLeaf, key: append([]byte{}, key...), Val: value}
+	ref := &art.root
+	depth := 0
+	for {
+		n := *ref
+		if n == nil {
+			*ref = leaf
+			art.size++
+			return
+		}
+		if n.kind == artLeaf {
+			if bytes.Equal(n.key, key) {
+				n.Val = value
+				return
+			}
+			// lazy expansion: the two keys share a path up to where they differ, which becomes a new inner node
+			common := commonBytes(n.key[depth:], key[depth:])
+			inner := newARTNode(artNode4, key[depth:depth+common])
+			inner.addLeaf(n, depth+common)
+			inner.addLeaf(leaf, depth+common)
+			*ref = inner
+			art.size++
+			return
+		}
+		common := commonBytes(n.prefix, key[depth:])
+		if common < len(n.prefix) {
+			// the key leaves the compressed path part way through, split the path where they differ
+			inner := newARTNode(artNode4, n.prefix[:common])
+			inner.addChild(n.prefix[common], n)
+			n.prefix = n.prefix[common+1:]
+			inner.addLeaf(leaf, depth+common)
+			*ref = inner
+			art.size++
+			return
+		}
+		depth += len(n.prefix)
+		if depth == len(key) {
+			if n.value == nil {
+				art.size++
+			}
+			n.value = leaf
+			return
+		}
+		child := n.findChild(key[depth])
+		if child == nil {
+			*ref = n.grow()
+			(*ref).addChild(key[depth], leaf)
+			art.size++
+			return
+		}
+		ref = child
+		depth++
+	}
+}
+
+// Find will find key in the tree and return its value, or nil if it is not there.
+func (art *AdaptiveRadixTree) Find(key []byte) *interface{} {
+	n := art.root
+	depth := 0
+	for n != nil {
+		if n.kind == artLeaf {
+			if bytes.Equal(n.key, key) {
+				return &n.Val
+			}
+			return nil
+		}
+		if len(key)-depth < len(n.prefix) || !bytes.Equal(n.prefix, key[depth:depth+len(n.prefix)]) {
+			return nil
+		}
+		depth += len(n.prefix)
+		if depth == len(key) {
+			if n.value == nil {
+				return nil
+			}
+			return &n.value.Val
+		}
+		child := n.findChild(key[depth])
+		if child == nil {
+			return nil
+		}
+		n = *child
+		depth++
+	}
+	return nil
+}
+
+// Contains determines if key exists in the tree and returns the result.
+func (art *AdaptiveRadixTree) Contains(key []byte) bool {
+	return art.Find(key) != nil
+}
+
+// Delete will delete key from the tree. It will return whether or not the tree was changed.
+func (art *AdaptiveRadixTree) Delete(key []byte) bool {
+	if deleteART(&art.root, key, 0) {
+		art.size--
+		return true
+	}
+	return false
+}
+
+// deleteART deletes key from the subtree at ref, shrinking or collapsing inner nodes that become too empty
+func deleteART(ref **artNode, key []byte, depth int) bool {
+	n := *ref
+	if n == nil {
+		return false
+	}
+	if n.kind == artLeaf {
+		if !bytes.Equal(n.key, key) {
+			return false
+		}
+		*ref = nil
+		return true
+	}
+	if len(key)-depth < len(n.prefix) || !bytes.Equal(n.prefix, key[depth:depth+len(n.prefix)]) {
+		return false
+	}
+	depth += len(n.prefix)
+	if depth == len(key) {
+		if n.value == nil {
+			return false
+		}
+		n.value = nil
+	} else {
+		child := n.findChild(key[depth])
+		if child == nil || !deleteART(child, key, depth+1) {
+			return false
+		}
+		if *child == nil {
+			n.removeChild(key[depth])
+		}
+	}
+	*ref = n.shrink()
+	return true
+}
+
+// Walk calls fn on every key in lexicographic order, until fn returns false. Each key is a copy, so fn may keep or change it.
+func (art *AdaptiveRadixTree) Walk(fn func(key []byte, value interface{}) bool) {
+	walkART(art.root, nil, nil, fn)
+}
+
+// Range calls fn on every key with lo <= key <= hi in lexicographic order, until fn returns false. A nil bound is unbounded.
+// Subtrees whose path is outside the range are skipped without visiting them.
+func (art *AdaptiveRadixTree) Range(lo, hi []byte, fn func(key []byte, value interface{}) bool) {
+	walkART(art.root, lo, hi, fn)
+}
+
+// Keys returns a copy of every key in lexicographic order.
+func (art *AdaptiveRadixTree) Keys() [][]byte {
+	keys := make([][]byte, 0, art.size)
+	art.Walk(func(key []byte, value interface{}) bool {
+		keys = append(keys, key)
+		return true
+	})
+	return keys
+}
+
+// Values returns every value in lexicographic order of the keys.
+func (art *AdaptiveRadixTree) Values() []interface{} {
+	vals := make([]interface{}, 0, art.size)
+	art.Walk(func(key []byte, value interface{}) bool {
+		vals = append(vals, value)
+		return true
+	})
+	return vals
+}
+
+// Clear clears the tree of all nodes.
+func (art *AdaptiveRadixTree) Clear() {
+	art.root = nil
+	art.size = 0
+}
+
+func (art *AdaptiveRadixTree) Size() uint64 {
+	return art.size
+}
+
+// Verify walks the whole tree and checks the node kinds, child order and that every leaf is on the path of its key. It returns nil if the tree is valid, otherwise an error describing the first problem found.
+func (art *AdaptiveRadixTree) Verify() error {
+	count := uint64(0)
+	if err := verifyART(art.root, []byte{}, &count); err != nil {
+		return err
+	}
+	if count != art.size {
+		return errors.New("tree holds " + strconv.FormatUint(count, 10) + " keys but size is " + strconv.FormatUint(art.size, 10))
+	}
+	return nil
+}
+
+// verifyART checks n and its subtree. path is the key bytes on the way to n.
+func verifyART(n *artNode, path []byte, count *uint64) error {
+	if n == nil {
+		return nil
+	}
+	if n.kind == artLeaf {
+		if !bytes.HasPrefix(n.key, path) {
+			return errors.New("leaf " + strconv.Quote(string(n.key)) + " is not on the path of its key " + strconv.Quote(string(path)))
+		}
+		*count++
+		return nil
+	}
+	path = append(append([]byte{}, path...), n.prefix...)
+	if n.value != nil {
+		if !bytes.Equal(n.value.key, path) {
+			return errors.New("key " + strconv.Quote(string(n.value.key)) + " is stored at the end of path " + strconv.Quote(string(path)))
+		}
+		*count++
+	}
+	if n.num == 0 || (n.num == 1 && n.value == nil) {
+		return errors.New("inner node at " + strconv.Quote(string(path)) + " should have been collapsed")
+	}
+	if n.num > artCapacity(n.kind) || (n.kind != artNode4 && n.num <= artCapacity(n.kind-1)/2) {
+		return errors.New("inner node at " + strconv.Quote(string(path)) + " has " + strconv.Itoa(n.num) + " children which does not fit its kind")
+	}
+	found := 0
+	last := -1
+	var err error
+	n.eachChild(func(b byte, child *artNode) bool {
+		if int(b) <= last {
+			err = errors.New("children of the inner node at " + strconv.Quote(string(path)) + " are not sorted")
+			return false
+		}
+		last = int(b)
+		found++
+		err = verifyART(child, append(path, b), count)
+		return err == nil
+	})
+	if err != nil {
+		return err
+	}
+	if found != n.num {
+		return errors.New("inner node at " + strconv.Quote(string(path)) + " has " + strconv.Itoa(found) + " children but num is " + strconv.Itoa(n.num))
+	}
+	return nil
+}
+
+// walkART calls fn on every key in the subtree of n within [lo, hi] in order. It returns false once the walk should stop.
+func walkART(n *artNode, lo, hi []byte, fn func(key []byte, value interface{}) bool) bool {
+	return walkARTNode(n, []byte{}, lo, hi, fn)
+}
+
+// walkARTNode is walkART for a node below the root, path is the key bytes on the way to n
+func walkARTNode(n *artNode, path []byte, lo, hi []byte, fn func(key []byte, value interface{}) bool) bool {
+	if n == nil {
+		return true
+	}
+	if n.kind == artLeaf {
+		if lo != nil && bytes.Compare(n.key, lo) < 0 {
+			return true
+		}
+		if hi != nil && bytes.Compare(n.key, hi) > 0 {
+			return false
+		}
+		// the leaf's key is copied so changing it outside cannot move the leaf off its path
+		return fn(append([]byte(nil), n.key...), n.Val)
+	}
+	path = append(path, n.prefix...)
+	// every key below starts with path, so the subtree is skipped if path is already outside the range
+	if lo != nil && bytes.Compare(path, lo[:min(len(path), len(lo))]) < 0 {
+		return true
+	}
+	if hi != nil && bytes.Compare(path, hi[:min(len(path), len(hi))]) > 0 {
+		return false
+	}
+	// a key ending here comes before every longer key below it
+	if n.value != nil && !walkARTNode(n.value, path, lo, hi, fn) {
+		return false
+	}
+	return n.eachChild(func(b byte, child *artNode) bool {
+		return walkARTNode(child, append(path, b), lo, hi, fn)
+	})
+}
+
+// newARTNode creates an empty inner node of the given kind with a copy of prefix
+func newARTNode(kind uint8, prefix []byte) *artNode {
+	n := &artNode{kind: kind, prefix: append([]byte{}, prefix...)}
+	switch kind {
+	case artNode4, artNode16:
+		n.keys = make([]byte, 0, artCapacity(kind))
+		n.children = make([]*artNode, 0, artCapacity(kind))
+	case artNode48:
+		n.keys = make([]byte, 256)
+		n.children = make([]*artNode, 48)
+	case artNode256:
+		n.children = make([]*artNode, 256)
+	}
+	return n
+}
+
+// artCapacity is the most children a node of the kind can hold
+func artCapacity(kind uint8) int {
+	switch kind {
+	case artNode4:
+		return 4
+	case artNode16:
+		return 16
+	case artNode48:
+		return 48
+	case artNode256:
+		return 256
+	}
+	return 0
+}
+
+// addLeaf adds leaf below the inner node n, whose path ends at depth bytes into the key of leaf
+func (n *artNode) addLeaf(leaf *artNode, depth int) {
+	if len(leaf.key) == depth {
+		n.value = leaf
+	} else {
+		n.addChild(leaf.key[depth], leaf)
+	}
+}
+
+// findChild returns the slot holding the child for b, or nil if there is none
+func (n *artNode) findChild(b byte) **artNode {
+	switch n.kind {
+	case artNode4, artNode16:
+		if i := searchBytes(n.keys, b); i < len(n.keys) && n.keys[i] == b {
+			return &n.children[i]
+		}
+	case artNode48:
+		if slot := n.keys[b]; slot != 0 {
+			return &n.children[slot-1]
+		}
+	case artNode256:
+		if n.children[b] != nil {
+			return &n.children[b]
+		}
+	}
+	return nil
+}
+
+// addChild adds child for b, n must not be full
+func (n *artNode) addChild(b byte, child *artNode) {
+	switch n.kind {
+	case artNode4, artNode16:
+		i := searchBytes(n.keys, b)
+		n.keys = append(n.keys, 0)
+		copy(n.keys[i+1:], n.keys[i:])
+		n.keys[i] = b
+		n.children = append(n.children, nil)
+		copy(n.children[i+1:], n.children[i:])
+		n.children[i] = child
+	case artNode48:
+		slot := 0
+		for n.children[slot] != nil {
+			slot++
+		}
+		n.children[slot] = child
+		n.keys[b] = byte(slot + 1)
+	case artNode256:
+		n.children[b] = child
+	}
+	n.num++
+}
+
+// removeChild removes the child for b
+func (n *artNode) removeChild(b byte) {
+	switch n.kind {
+	case artNode4, artNode16:
+		i := searchBytes(n.keys, b)
+		n.keys = append(n.keys[:i], n.keys[i+1:]...)
+		n.children = append(n.children[:i], n.children[i+1:]...)
+	case artNode48:
+		n.children[n.keys[b]-1] = nil
+		n.keys[b] = 0
+	case artNode256:
+		n.children[b] = nil
+	}
+	n.num--
+}
+
+// eachChild calls fn on every child in key byte order, until fn returns false. It returns false if fn stopped the walk.
+func (n *artNode) eachChild(fn func(b byte, child *artNode) bool) bool {
+	switch n.kind {
+	case artNode4, artNode16:
+		for i, child := range n.children {
+			if !fn(n.keys[i], child) {
+				return false
+			}
+		}
+	case artNode48:
+		for b, slot := range n.keys {
+			if slot != 0 && !fn(byte(b), n.children[slot-1]) {
+				return false
+			}
+		}
+	case artNode256:
+		for b, child := range n.children {
+			if child != nil && !fn(byte(b), child) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// grow returns n if it has room for another child, otherwise a copy of n of the next larger kind
+func (n *artNode) grow() *artNode {
+	if n.num < artCapacity(n.kind) {
+		return n
+	}
+	return n.resize(n.kind + 1)
+}
+
+// shrink returns the node that should replace n after a child or value was removed. Nodes that are at most half full of the next smaller kind are copied down a kind,
+// and a node left with a single child (or only a value) is collapsed into it to keep the path compressed.
+func (n *artNode) shrink() *artNode {
+	if n.num == 0 {
+		// only the key ending here is left, it must be non nil since a node with no children and no value is never left behind
+		return n.value
+	}
+	if n.num == 1 && n.value == nil {
+		var b byte
+		var child *artNode
+		n.eachChild(func(key byte, c *artNode) bool {
+			b, child = key, c
+			return false
+		})
+		if child.kind != artLeaf {
+			child.prefix = append(append(append([]byte{}, n.prefix...), b), child.prefix...)
+		}
+		return child
+	}
+	if n.kind != artNode4 && n.num <= artCapacity(n.kind-1)/2 {
+		return n.resize(n.kind - 1)
+	}
+	return n
+}
+
+// resize copies n into a new inner node of the given kind
+func (n *artNode) resize(kind uint8) *artNode {
+	resized := newARTNode(kind, nil)
+	resized.prefix = n.prefix
+	resized.value = n.value
+	n.eachChild(func(b byte, child *artNode) bool {
+		resized.addChild(b, child)
+		return true
+	})
+	return resized
+}
+
+// searchBytes returns the index of the first byte in sorted keys that is >= b
+func searchBytes(keys []byte, b byte) int {
+	i := 0
+	for i < len(keys) && keys[i] < b {
+		i++
+	}
+	return i
+}
+
+// commonBytes returns the number of leading bytes a and b share
+func commonBytes(a, b []byte) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return i
+}
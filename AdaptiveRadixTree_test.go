@@ -0,0 +1,309 @@
+package GoTrees
+
+import (
+	"encoding/binary"
+	"math/rand"
+	"sort"
+	sc "strconv"
+	"testing"
+)
+
+// randomBytes returns a short key that mostly shares prefixes with other keys, but can branch on any byte
+func randomBytes() []byte {
+	key := make([]byte, rand.Intn(5))
+	for i := range key {
+		if rand.Intn(2) == 0 {
+			key[i] = byte(rand.Intn(256))
+		} else {
+			key[i] = byte(rand.Intn(3))
+		}
+	}
+	return key
+}
+
+// sortedByteKeys returns the keys of the map in lexicographic order
+func sortedByteKeys(keys map[string]int) []string {
+	sorted := []string{}
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+func TestAdaptiveRadixTreePut(t *testing.T) {
+	ART := NewAdaptiveRadixTree()
+	keys := map[string]int{}
+
+	for i := 0; i < nRAND*10; i++ {
+		key := randomBytes()
+		keys[string(key)] = i
+		ART.Put(key, i)
+		if err := ART.Verify(); err != nil {
+			t.Fatal("Adaptive radix tree failed verification after inserting " + sc.Quote(string(key)) + ": " + err.Error())
+		}
+	}
+	if ART.Size() != uint64(len(keys)) {
+		t.Fatal("Adaptive radix tree size incorrect, inserting a key twice should replace it. ")
+	}
+	for key, i := range keys {
+		if v := ART.Find([]byte(key)); v == nil || (*v).(int) != i {
+			t.Fatal("Could not find the latest value of " + sc.Quote(key))
+		}
+	}
+	if ART.Contains([]byte{0, 0, 0, 0, 0, 0}) {
+		t.Fatal("Found a key that was not in the tree. ")
+	}
+
+	expected := sortedByteKeys(keys)
+	actual := ART.Keys()
+	vals := ART.Values()
+	if len(actual) != len(expected) {
+		t.Fatal("Adaptive radix tree returned " + sc.Itoa(len(actual)) + " keys but should have " + sc.Itoa(len(expected)))
+	}
+	for i, k := range actual {
+		if string(k) != expected[i] {
+			t.Fatal("Adaptive radix tree key was incorrect, expected " + sc.Quote(expected[i]) + " but got " + sc.Quote(string(k)))
+		}
+		if vals[i].(int) != keys[string(k)] {
+			t.Fatal("Adaptive radix tree value was incorrect for " + sc.Quote(string(k)))
+		}
+	}
+}
+
+func TestAdaptiveRadixTreePutCopiesKey(t *testing.T) {
+	ART := NewAdaptiveRadixTree()
+	key := []byte("abc")
+	ART.Put(key, 1)
+	key[0] = 'x'
+	if !ART.Contains([]byte("abc")) || ART.Contains(key) {
+		t.Fatal("Changing the inserted slice should not change the key in the tree. ")
+	}
+}
+
+func TestAdaptiveRadixTreeKeysCopiesKey(t *testing.T) {
+	ART := NewAdaptiveRadixTree()
+	ART.Put([]byte("abc"), 1)
+	ART.Put([]byte("abd"), 2)
+	ART.Keys()[0][2] = 'z'
+	ART.Walk(func(key []byte, value interface{}) bool {
+		key[0] = 'x'
+		return true
+	})
+	if !ART.Contains([]byte("abc")) || !ART.Contains([]byte("abd")) || ART.Verify() != nil {
+		t.Fatal("Changing a key returned by Keys or Walk should not change the key in the tree. ")
+	}
+}
+
+func TestAdaptiveRadixTreeDelete(t *testing.T) {
+	ART := NewAdaptiveRadixTree()
+	keys := map[string]int{}
+	for i := 0; i < nRAND*10; i++ {
+		key := randomBytes()
+		keys[string(key)] = i
+		ART.Put(key, i)
+	}
+
+	order := sortedByteKeys(keys)
+	rand.Shuffle(len(order), func(i, j int) { order[i], order[j] = order[j], order[i] })
+	for _, k := range order {
+		if !ART.Delete([]byte(k)) {
+			t.Fatal("Failed to delete " + sc.Quote(k))
+		}
+		delete(keys, k)
+		if ART.Delete([]byte(k)) {
+			t.Fatal("Deleted " + sc.Quote(k) + " twice. ")
+		}
+		if err := ART.Verify(); err != nil {
+			t.Fatal("Adaptive radix tree failed verification after deleting " + sc.Quote(k) + ": " + err.Error())
+		}
+		for other, i := range keys {
+			if v := ART.Find([]byte(other)); v == nil || (*v).(int) != i {
+				t.Fatal("Deleting " + sc.Quote(k) + " lost " + sc.Quote(other))
+			}
+		}
+	}
+	if ART.Size() != 0 || len(ART.Keys()) != 0 {
+		t.Fatal("Adaptive radix tree should be empty after deleting every key. ")
+	}
+}
+
+func TestAdaptiveRadixTreeGrowAndShrink(t *testing.T) {
+	ART := NewAdaptiveRadixTree()
+	// every byte after the shared "k" grows the same inner node through each kind
+	for b := 0; b < 256; b++ {
+		ART.Put([]byte{'k', byte(b)}, b)
+		if err := ART.Verify(); err != nil {
+			t.Fatal("Adaptive radix tree failed verification while growing to " + sc.Itoa(b+1) + " children: " + err.Error())
+		}
+	}
+	if ART.root.kind != artNode256 {
+		t.Fatal("Inner node with 256 children should be a Node256. ")
+	}
+	for b := 255; b > 0; b-- {
+		ART.Delete([]byte{'k', byte(b)})
+		if err := ART.Verify(); err != nil {
+			t.Fatal("Adaptive radix tree failed verification while shrinking to " + sc.Itoa(b) + " children: " + err.Error())
+		}
+	}
+	if ART.root.kind != artLeaf {
+		t.Fatal("A tree with one key left should be a single leaf. ")
+	}
+}
+
+func TestAdaptiveRadixTreeRange(t *testing.T) {
+	ART := NewAdaptiveRadixTree()
+	keys := map[string]int{}
+	for i := 0; i < nRAND*10; i++ {
+		key := randomBytes()
+		keys[string(key)] = i
+		ART.Put(key, i)
+	}
+	sorted := sortedByteKeys(keys)
+
+	for i := 0; i < nRAND; i++ {
+		lo := randomBytes()
+		hi := randomBytes()
+		if rand.Intn(10) == 0 {
+			lo = nil
+		}
+		if rand.Intn(10) == 0 {
+			hi = nil
+		}
+		expected := []string{}
+		for _, k := range sorted {
+			if (lo == nil || k >= string(lo)) && (hi == nil || k <= string(hi)) {
+				expected = append(expected, k)
+			}
+		}
+		actual := []string{}
+		ART.Range(lo, hi, func(key []byte, value interface{}) bool {
+			actual = append(actual, string(key))
+			return true
+		})
+		if len(actual) != len(expected) {
+			t.Fatal("Range " + sc.Quote(string(lo)) + " to " + sc.Quote(string(hi)) + " returned " + sc.Itoa(len(actual)) + " keys but should have " + sc.Itoa(len(expected)))
+		}
+		for j := range actual {
+			if actual[j] != expected[j] {
+				t.Fatal("Range key was incorrect, expected " + sc.Quote(expected[j]) + " but got " + sc.Quote(actual[j]))
+			}
+		}
+	}
+
+	count := 0
+	ART.Walk(func(key []byte, value interface{}) bool {
+		count++
+		return count < 3
+	})
+	if count != 3 {
+		t.Fatal("Walk should stop once fn returns false. ")
+	}
+}
+
+func TestAdaptiveRadixTreeClear(t *testing.T) {
+	ART := NewAdaptiveRadixTree()
+	ART.Put([]byte("a"), 1)
+	ART.Put([]byte("ab"), 2)
+	ART.Clear()
+	if ART.Size() != 0 || ART.Contains([]byte("a")) {
+		t.Fatal("Adaptive radix tree should be empty after Clear. ")
+	}
+	ART.Put([]byte("b"), 3)
+	if !ART.Contains([]byte("b")) {
+		t.Fatal("Adaptive radix tree should be usable after Clear. ")
+	}
+}
+
+// benchmarkKeys returns random non negative ints and the same ints as big endian byte keys, which sort the same way
+func benchmarkKeys() ([]int, [][]byte) {
+	ints := make([]int, nRAND*100)
+	keys := make([][]byte, len(ints))
+	for i := range ints {
+		ints[i] = rand.Intn(1 << 30)
+		keys[i] = make([]byte, 8)
+		binary.BigEndian.PutUint64(keys[i], uint64(ints[i]))
+	}
+	return ints, keys
+}
+
+func BenchmarkAdaptiveRadixTreePut(b *testing.B) {
+	_, keys := benchmarkKeys()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		ART := NewAdaptiveRadixTree()
+		for _, k := range keys {
+			ART.Put(k, nil)
+		}
+	}
+}
+
+func BenchmarkAdaptiveRadixTreeBTreePut(b *testing.B) {
+	ints, _ := benchmarkKeys()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		BT := NewBTree(4, nAlloc)
+		for _, k := range ints {
+			BT.Put(k, nil)
+		}
+	}
+}
+
+func BenchmarkAdaptiveRadixTreeFind(b *testing.B) {
+	_, keys := benchmarkKeys()
+	ART := NewAdaptiveRadixTree()
+	for _, k := range keys {
+		ART.Put(k, nil)
+	}
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		for _, k := range keys {
+			ART.Find(k)
+		}
+	}
+}
+
+func BenchmarkAdaptiveRadixTreeBTreeFind(b *testing.B) {
+	ints, _ := benchmarkKeys()
+	BT := NewBTree(4, nAlloc)
+	for _, k := range ints {
+		BT.Put(k, nil)
+	}
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		for _, k := range ints {
+			BT.Find(k)
+		}
+	}
+}
+
+func BenchmarkAdaptiveRadixTreeRange(b *testing.B) {
+	_, keys := benchmarkKeys()
+	ART := NewAdaptiveRadixTree()
+	for _, k := range keys {
+		ART.Put(k, nil)
+	}
+	lo, hi := make([]byte, 8), make([]byte, 8)
+	binary.BigEndian.PutUint64(lo, 1<<28)
+	binary.BigEndian.PutUint64(hi, 1<<29)
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		ART.Range(lo, hi, func(key []byte, value interface{}) bool {
+			return true
+		})
+	}
+}
+
+func BenchmarkAdaptiveRadixTreeBTreeRange(b *testing.B) {
+	ints, _ := benchmarkKeys()
+	BT := NewBTree(4, nAlloc)
+	for _, k := range ints {
+		BT.Put(k, nil)
+	}
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		BT.ascendRange(1<<28, 1<<29, func(kv *keyValue) bool {
+			return true
+		})
+	}
+}
@@ -1,7 +1,10 @@
 package GoTrees
 
 import (
+	"errors"
+	"sort"
 	"strconv"
+	"unsafe"
 )
 
 // BSTree is a binary search tree using key-value nodes.
@@ -45,6 +48,72 @@ func (bst *BSTree) Insert(key int, value interface{}) {
 	bst.root = node
 }
 
+// Put will set the value of key, inserting it if it is not in the BST. If key has duplicates only the occurrence closest to the root is changed.
+func (bst *BSTree) Put(key int, value interface{}) {
+	bst.upsert(key, func(old interface{}, ok bool) (interface{}, bool) {
+		return value, true
+	})
+}
+
+// PutIfAbsent will insert key only if it is not already in the BST. It will return whether or not the tree was changed.
+func (bst *BSTree) PutIfAbsent(key int, value interface{}) bool {
+	inserted := false
+	bst.upsert(key, func(old interface{}, ok bool) (interface{}, bool) {
+		inserted = !ok
+		return value, !ok
+	})
+	return inserted
+}
+
+// Update calls fn with the current value of key and whether it was found, then stores the value fn returns. If fn returns false the tree is left unchanged.
+func (bst *BSTree) Update(key int, fn func(old interface{}, ok bool) (interface{}, bool)) {
+	bst.upsert(key, fn)
+}
+
+// GetOrInsert returns the value of key if it is in the BST, otherwise it inserts value and returns it. loaded reports whether the value was already there.
+func (bst *BSTree) GetOrInsert(key int, value interface{}) (actual interface{}, loaded bool) {
+	bst.upsert(key, func(old interface{}, ok bool) (interface{}, bool) {
+		actual, loaded = old, ok
+		if !ok {
+			actual = value
+		}
+		return actual, !ok
+	})
+	return actual, loaded
+}
+
+// Swap sets the value of key, inserting it if it is not in the BST, and returns the previous value. loaded reports whether there was a previous value.
+func (bst *BSTree) Swap(key int, value interface{}) (previous interface{}, loaded bool) {
+	bst.upsert(key, func(old interface{}, ok bool) (interface{}, bool) {
+		previous, loaded = old, ok
+		return value, true
+	})
+	return previous, loaded
+}
+
+// upsert finds key with a single descent. fn is given the value of the occurrence of key closest to the root and decides the value to store.
+func (bst *BSTree) upsert(key int, fn func(old interface{}, ok bool) (interface{}, bool)) {
+	// link is the pointer that will hold the new node if key is not found
+	link := &bst.root
+	for *link != nil {
+		n := *link
+		if n.Key == key {
+			if value, store := fn(n.Val, true); store {
+				n.Val = value
+			}
+			return
+		} else if n.Key < key {
+			link = &n.Right
+		} else {
+			link = &n.Left
+		}
+	}
+	if value, store := fn(nil, false); store {
+		bst.size++
+		*link = newNode(key, value)
+	}
+}
+
 // Delete will delete the closest occurance of the key to the root in the BST. It will return whether or not the tree was changed.
 func (bst *BSTree) Delete(key int) bool {
 	// the side of the child in relation to the parent (false is left)
@@ -146,6 +215,59 @@ func (bst *BSTree) Contains(key int) bool {
 	return bst.Find(key) != nil
 }
 
+// FindAll returns the values of every occurrence of key in insertion order. It returns an empty slice if key is not in the BST.
+func (bst *BSTree) FindAll(key int) []interface{} {
+	vals := []interface{}{}
+	bst.ascendRange(key, key, func(n *node) bool {
+		vals = append(vals, n.Val)
+		return true
+	})
+	return vals
+}
+
+// Count returns the number of occurrences of key in the BST.
+func (bst *BSTree) Count(key int) int {
+	count := 0
+	bst.ascendRange(key, key, func(n *node) bool {
+		count++
+		return true
+	})
+	return count
+}
+
+// DeleteAll will delete every occurrence of key in the BST. It will return the number of nodes deleted.
+func (bst *BSTree) DeleteAll(key int) int {
+	count := 0
+	for bst.Delete(key) {
+		count++
+	}
+	return count
+}
+
+// ascendRange calls fn on every node with lo <= key <= hi in order, until fn returns false
+func (bst *BSTree) ascendRange(lo, hi int, fn func(n *node) bool) {
+	nodeStack := []*node{}
+	n := bst.root
+	for n != nil || len(nodeStack) != 0 {
+		if n != nil {
+			if n.Key < lo {
+				// this node and its left subtree are below the range
+				n = n.Right
+			} else {
+				nodeStack = append(nodeStack, n)
+				n = n.Left
+			}
+		} else {
+			n = nodeStack[len(nodeStack)-1]
+			nodeStack = nodeStack[:len(nodeStack)-1]
+			if n.Key > hi || !fn(n) {
+				return
+			}
+			n = n.Right
+		}
+	}
+}
+
 func (bst *BSTree) Keys() []int {
 	keys := make([]int, bst.size)
 	i := 0
@@ -215,6 +337,37 @@ func (bst *BSTree) slice() []*node {
 	return nodes
 }
 
+// keyValues returns the key-values of the BST in order
+func (bst *BSTree) keyValues() []*keyValue {
+	nodes := bst.slice()
+	kvs := make([]*keyValue, len(nodes))
+	for i, n := range nodes {
+		kvs[i] = newKeyValue(n.Key, n.Val)
+	}
+	return kvs
+}
+
+// newBSTreeFromSorted builds a balanced BST from sorted key-values in linear time.
+func newBSTreeFromSorted(kvs []*keyValue) BSTree {
+	return BSTree{root: buildBSTNode(kvs), size: uint64(len(kvs))}
+}
+
+// buildBSTNode makes the middle key-value the root of the subtree and builds each half below it
+func buildBSTNode(kvs []*keyValue) *node {
+	if len(kvs) == 0 {
+		return nil
+	}
+	mid := len(kvs) / 2
+	// duplicates must be in the right subtree, so the root is the first occurrence of the middle key
+	for mid > 0 && kvs[mid-1].key == kvs[mid].key {
+		mid--
+	}
+	n := newNode(kvs[mid].key, kvs[mid].value)
+	n.Left = buildBSTNode(kvs[:mid])
+	n.Right = buildBSTNode(kvs[mid+1:])
+	return n
+}
+
 // Clear clears the BST of all nodes.
 func (bst *BSTree) Clear() {
 	bst.root = nil
@@ -285,6 +438,127 @@ func (bst *BSTree) String() string {
 	}
 }
 
+// BSTreeStats is a report on the shape and memory use of a BST, see (*BSTree).Stats
+type BSTreeStats struct {
+	Size   uint64
+	Height uint64
+	// DepthHistogram is the number of nodes at each depth, starting with the root at depth 0
+	DepthHistogram []uint64
+	// AvgPathLength is the average number of links from the root to a node
+	AvgPathLength float64
+	// BalanceHistogram counts nodes by balance factor, the height of the right subtree minus the height of the left subtree
+	BalanceHistogram map[int]uint64
+	// MemoryBytes is an estimate of the memory used by the tree structure. It does not include the memory pointed to by values.
+	MemoryBytes uint64
+}
+
+// Stats walks the BST and returns a report on its depth, balance and memory use.
+func (bst *BSTree) Stats() BSTreeStats {
+	stats := BSTreeStats{Size: bst.size, BalanceHistogram: map[int]uint64{}}
+	stats.MemoryBytes = uint64(unsafe.Sizeof(*bst)) + bst.size*uint64(unsafe.Sizeof(node{}))
+	pathSum := uint64(0)
+	stats.Height = uint64(bstStats(bst.root, 0, &stats, &pathSum))
+	if bst.size > 0 {
+		stats.AvgPathLength = float64(pathSum) / float64(bst.size)
+	}
+	return stats
+}
+
+// bstStats records n and its subtree in stats and returns the height of the subtree
+func bstStats(n *node, depth int, stats *BSTreeStats, pathSum *uint64) int {
+	if n == nil {
+		return 0
+	}
+	if depth == len(stats.DepthHistogram) {
+		stats.DepthHistogram = append(stats.DepthHistogram, 0)
+	}
+	stats.DepthHistogram[depth]++
+	*pathSum += uint64(depth)
+	left := bstStats(n.Left, depth+1, stats, pathSum)
+	right := bstStats(n.Right, depth+1, stats, pathSum)
+	stats.BalanceHistogram[right-left]++
+	if left > right {
+		return left + 1
+	}
+	return right + 1
+}
+
+// String formats the stats as a multi-line report
+func (s BSTreeStats) String() string {
+	str := "size: " + strconv.FormatUint(s.Size, 10) + "\n"
+	str += "height: " + strconv.FormatUint(s.Height, 10) + "\n"
+	str += "nodes per depth:"
+	for _, n := range s.DepthHistogram {
+		str += " " + strconv.FormatUint(n, 10)
+	}
+	str += "\naverage path length: " + strconv.FormatFloat(s.AvgPathLength, 'f', 2, 64) + "\n"
+	str += "balance factors:\n"
+	factors := []int{}
+	for f := range s.BalanceHistogram {
+		factors = append(factors, f)
+	}
+	sort.Ints(factors)
+	for _, f := range factors {
+		str += "  " + strconv.Itoa(f) + ": " + strconv.FormatUint(s.BalanceHistogram[f], 10) + "\n"
+	}
+	str += "estimated memory: " + strconv.FormatUint(s.MemoryBytes, 10) + " bytes\n"
+	return str
+}
+
 func (bst *BSTree) Size() uint64 {
 	return bst.size
 }
+
+// Dot will return the BST in the graphviz DOT language. Each node is labelled with its key.
+func (bst *BSTree) Dot() string {
+	str := "digraph BSTree {\n"
+	nodeQ := []*node{}
+	idQ := []int{}
+	id := 0
+
+	if bst.root != nil {
+		nodeQ = append(nodeQ, bst.root)
+		idQ = append(idQ, id)
+	}
+	for len(nodeQ) > 0 {
+		curr, currID := nodeQ[0], idQ[0]
+		nodeQ, idQ = nodeQ[1:], idQ[1:]
+		str += "\tn" + strconv.Itoa(currID) + " [label=\"" + strconv.Itoa(curr.Key) + "\"];\n"
+		for _, child := range []*node{curr.Left, curr.Right} {
+			if child != nil {
+				id++
+				str += "\tn" + strconv.Itoa(currID) + " -> n" + strconv.Itoa(id) + ";\n"
+				nodeQ = append(nodeQ, child)
+				idQ = append(idQ, id)
+			}
+		}
+	}
+	return str + "}\n"
+}
+
+// Verify walks the whole BST and checks its invariants. It returns nil if the tree is valid, otherwise an error describing the first problem found.
+func (bst *BSTree) Verify() error {
+	count := uint64(0)
+	if err := verifyBSTNode(bst.root, nil, nil, &count); err != nil {
+		return err
+	}
+	if count != bst.size {
+		return errors.New("tree holds " + strconv.FormatUint(count, 10) + " nodes but size is " + strconv.FormatUint(bst.size, 10))
+	}
+	return nil
+}
+
+// verifyBSTNode checks n and its subtree. Keys must be within [lo, hi) since duplicates are always placed in the right subtree (nil is unbounded)
+func verifyBSTNode(n *node, lo, hi *int, count *uint64) error {
+	if n == nil {
+		return nil
+	}
+	if (lo != nil && n.Key < *lo) || (hi != nil && n.Key >= *hi) {
+		return errors.New("key " + strconv.Itoa(n.Key) + " is on the wrong side of one of its ancestors")
+	}
+	*count++
+	if err := verifyBSTNode(n.Left, lo, &n.Key, count); err != nil {
+		return err
+	}
+	return verifyBSTNode(n.Right, &n.Key, hi, count)
+}
@@ -214,3 +214,228 @@ func TestBSTreeString(t *testing.T) {
 		t.Fatal("Expected output: \n" + expected + "\n but got \n" + BST.String())
 	}
 }
+
+func TestBSTreeVerify(t *testing.T) {
+	BST := NewBSTree()
+	keys := make([]int, nRAND)
+
+	if err := BST.Verify(); err != nil {
+		t.Fatal("Empty tree failed verification: " + err.Error())
+	}
+	for i := 0; i < nRAND; i++ {
+		// nRAND - 1 to ensure at least one duplicate key
+		key := rand.Intn(nRAND - 1)
+		keys[i] = key
+		BST.Insert(key, nil)
+		if err := BST.Verify(); err != nil {
+			t.Fatal("Tree failed verification after inserting " + sc.Itoa(key) + ": " + err.Error())
+		}
+	}
+	for _, key := range keys {
+		BST.Delete(key)
+		if err := BST.Verify(); err != nil {
+			t.Fatal("Tree failed verification after deleting " + sc.Itoa(key) + ": " + err.Error())
+		}
+	}
+
+	BST.Insert(10, nil)
+	BST.Insert(5, nil)
+	BST.root.Left.Key = 15
+	if BST.Verify() == nil {
+		t.Fatal("Tree with a misplaced key passed verification. ")
+	}
+}
+
+func TestBSTreeDot(t *testing.T) {
+	BST := NewBSTree()
+
+	BST.Insert(10, 10)
+	BST.Insert(11, 11)
+	BST.Insert(9, 9)
+
+	expected := "digraph BSTree {\n\tn0 [label=\"10\"];\n\tn0 -> n1;\n\tn0 -> n2;\n\tn1 [label=\"9\"];\n\tn2 [label=\"11\"];\n}\n"
+	actual := BST.Dot()
+	if actual != expected {
+		t.Fatal("Expected output: \n" + expected + "\n but got \n" + actual)
+	}
+}
+
+func TestBSTreeStats(t *testing.T) {
+	BST := NewBSTree()
+
+	stats := BST.Stats()
+	if stats.Height != 0 || stats.Size != 0 || len(stats.DepthHistogram) != 0 {
+		t.Fatal("Empty tree should have empty stats. ")
+	}
+
+	BST.Insert(10, 10)
+	BST.Insert(11, 11)
+	BST.Insert(9, 9)
+	BST.Insert(8, 8)
+	BST.Insert(14, 14)
+	BST.Insert(12, 12)
+	BST.Insert(13, 13)
+
+	stats = BST.Stats()
+	if stats.Height != BST.Height() {
+		t.Fatal("Stats height " + sc.Itoa(int(stats.Height)) + " does not match Height " + sc.Itoa(int(BST.Height())))
+	}
+	expected := []uint64{1, 2, 2, 1, 1}
+	for i, n := range stats.DepthHistogram {
+		if n != expected[i] {
+			t.Fatal("Depth histogram incorrect at depth " + sc.Itoa(i) + ", expected " + sc.Itoa(int(expected[i])) + " but got " + sc.Itoa(int(n)))
+		}
+	}
+	// depths are 0 + 1 + 1 + 2 + 2 + 3 + 4
+	if stats.AvgPathLength != 13.0/7 {
+		t.Fatal("Average path length was incorrect. ")
+	}
+	// leaves 8 and 13 are 0, 9 is -1, 12 is 1, 14 is -2, 10 is 2 and 11 is 3
+	if len(stats.BalanceHistogram) != 6 || stats.BalanceHistogram[0] != 2 || stats.BalanceHistogram[-1] != 1 || stats.BalanceHistogram[1] != 1 || stats.BalanceHistogram[-2] != 1 || stats.BalanceHistogram[2] != 1 || stats.BalanceHistogram[3] != 1 {
+		t.Fatal("Balance histogram was incorrect. ")
+	}
+}
+
+func TestBSTreeFindAll(t *testing.T) {
+	BST := NewBSTree()
+	counts := make([]int, nRAND/10)
+
+	for i := 0; i < nRAND*2; i++ {
+		key := rand.Intn(nRAND / 10)
+		counts[key]++
+		BST.Insert(key, i)
+	}
+	for key, count := range counts {
+		vals := BST.FindAll(key)
+		if len(vals) != count || BST.Count(key) != count {
+			t.Fatal("Expected " + sc.Itoa(count) + " occurrences of " + sc.Itoa(key) + " but found " + sc.Itoa(len(vals)))
+		}
+		for i := 1; i < len(vals); i++ {
+			if vals[i].(int) < vals[i-1].(int) {
+				t.Fatal("FindAll did not return the values of " + sc.Itoa(key) + " in insertion order. ")
+			}
+		}
+	}
+	if len(BST.FindAll(nRAND)) != 0 || BST.Count(nRAND) != 0 {
+		t.Fatal("Found occurrences of a key that was not in the tree. ")
+	}
+}
+
+func TestBSTreeDeleteAll(t *testing.T) {
+	BST := NewBSTree()
+	counts := make([]int, nRAND/10)
+
+	for i := 0; i < nRAND*2; i++ {
+		key := rand.Intn(nRAND / 10)
+		counts[key]++
+		BST.Insert(key, i)
+	}
+	remaining := nRAND * 2
+	for key, count := range counts {
+		if deleted := BST.DeleteAll(key); deleted != count {
+			t.Fatal("Expected to delete " + sc.Itoa(count) + " occurrences of " + sc.Itoa(key) + " but deleted " + sc.Itoa(deleted))
+		}
+		remaining -= count
+		if BST.Contains(key) || BST.Size() != uint64(remaining) {
+			t.Fatal("Tree still holds " + sc.Itoa(key) + " or has the wrong size after DeleteAll. ")
+		}
+	}
+}
+
+func TestBSTreePut(t *testing.T) {
+	BST := NewBSTree()
+	keys := rand.Perm(nRAND)
+
+	for _, key := range keys {
+		BST.Put(key, key)
+	}
+	for _, key := range keys {
+		BST.Put(key, -key)
+	}
+	if BST.Size() != nRAND {
+		t.Fatal("Put added duplicate keys, expected size " + sc.Itoa(nRAND) + " but got " + sc.Itoa(int(BST.Size())))
+	}
+	for _, key := range keys {
+		if v := BST.Find(key); v == nil || (*v).(int) != -key {
+			t.Fatal("Put did not replace the value of " + sc.Itoa(key))
+		}
+	}
+	if err := BST.Verify(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBSTreePutIfAbsent(t *testing.T) {
+	BST := NewBSTree()
+	keys := rand.Perm(nRAND)
+
+	for _, key := range keys {
+		if !BST.PutIfAbsent(key, key) {
+			t.Fatal("PutIfAbsent did not insert missing key " + sc.Itoa(key))
+		}
+	}
+	for _, key := range keys {
+		if BST.PutIfAbsent(key, -key) {
+			t.Fatal("PutIfAbsent inserted existing key " + sc.Itoa(key))
+		}
+		if v := BST.Find(key); v == nil || (*v).(int) != key {
+			t.Fatal("PutIfAbsent changed the value of " + sc.Itoa(key))
+		}
+	}
+	if BST.Size() != nRAND {
+		t.Fatal("Expected size " + sc.Itoa(nRAND) + " but got " + sc.Itoa(int(BST.Size())))
+	}
+}
+
+func TestBSTreeUpdate(t *testing.T) {
+	BST := NewBSTree()
+	counter := func(old interface{}, ok bool) (interface{}, bool) {
+		if !ok {
+			return 1, true
+		}
+		return old.(int) + 1, true
+	}
+
+	for i := 0; i < nRAND; i++ {
+		BST.Update(i%10, counter)
+	}
+	for i := 0; i < 10; i++ {
+		if v := BST.Find(i); v == nil || (*v).(int) != nRAND/10 {
+			t.Fatal("Update did not count key " + sc.Itoa(i) + " correctly. ")
+		}
+	}
+	BST.Update(nRAND, func(old interface{}, ok bool) (interface{}, bool) {
+		return nil, false
+	})
+	if BST.Contains(nRAND) || BST.Size() != 10 {
+		t.Fatal("Update inserted a key when fn returned false. ")
+	}
+}
+
+func TestBSTreeGetOrInsert(t *testing.T) {
+	BST := NewBSTree()
+
+	if v, loaded := BST.GetOrInsert(1, "a"); loaded || v.(string) != "a" {
+		t.Fatal("GetOrInsert did not insert a missing key. ")
+	}
+	if v, loaded := BST.GetOrInsert(1, "b"); !loaded || v.(string) != "a" {
+		t.Fatal("GetOrInsert did not return the existing value. ")
+	}
+	if BST.Size() != 1 {
+		t.Fatal("Expected size 1 but got " + sc.Itoa(int(BST.Size())))
+	}
+}
+
+func TestBSTreeSwap(t *testing.T) {
+	BST := NewBSTree()
+
+	if v, loaded := BST.Swap(1, "a"); loaded || v != nil {
+		t.Fatal("Swap returned a previous value for a missing key. ")
+	}
+	if v, loaded := BST.Swap(1, "b"); !loaded || v.(string) != "a" {
+		t.Fatal("Swap did not return the previous value. ")
+	}
+	if v := BST.Find(1); v == nil || (*v).(string) != "b" || BST.Size() != 1 {
+		t.Fatal("Swap did not replace the value. ")
+	}
+}
@@ -1,7 +1,9 @@
 package GoTrees
 
 import (
+	"errors"
 	"strconv"
+	"unsafe"
 )
 
 // BTree is a b-tree using key-value nodes.
@@ -10,6 +12,10 @@ type BTree struct {
 	size      uint64
 	t         uint
 	initAlloc int
+	// encode turns values into bytes for the merkle hashes, nil uses EncodeValue
+	encode func(value interface{}) []byte
+	// expiry holds the expiry times of entries inserted with a TTL, nil until a TTL or clock is set
+	expiry *bTreeExpiry
 }
 
 // NewBTree returns an empty b-tree. The degree of the b tree is 2*t+2. (This ensures valid max-degree. Since this b-tree splits preemptively the degree must be even so it will split with an odd number of pairs)
@@ -23,25 +29,24 @@ func NewBTree(t uint, alloc float32) BTree {
 	return BTree{root: &root, size: 0, t: 2*t + 2, initAlloc: int(alloc * float32(t))}
 }
 
-// Insert will insert node into the BT. A duplicate tree could be placed in the left or right subtree to maintain balance.
+// Insert will insert node into the BT. A duplicate key is always placed after the existing equal keys, so iteration returns equal keys in insertion order.
 func (bt *BTree) Insert(key int, value interface{}) {
-	// check the root for capacity (a new node will be allocated)
-	if bt.root.length > int(bt.t) {
-		mid, left, right := bt.root.SplitInTwo(bt.initAlloc)
-		newRoot := newbTreeNode(bt.initAlloc)
-		bt.root = &newRoot
-		bt.root.AddToList(mid)
-		bt.root.AddChild(left)
-		bt.root.AddChild(right)
-	}
+	bt.insertKeyValue(newKeyValue(key, value))
+}
+
+// insertKeyValue inserts kv itself, so an entry that is inserted again keeps its identity
+func (bt *BTree) insertKeyValue(kv *keyValue) {
+	key := kv.key
+	bt.splitFullRoot()
 	curr := bt.root
 	for curr.numChildren != 0 {
-		_, indexNext := curr.Search(key)
+		// every node on the way down is an ancestor of the changed leaf, so its cached hash is out of date and it holds one more key-value
+		curr.hash = nil
+		curr.count++
+		// descending after any equal keys keeps duplicates in insertion order
+		indexNext := curr.UpperBound(key)
 		if curr.children[indexNext].length > int(bt.t) {
-			// split the node
-			mid, left, right := curr.children[indexNext].SplitInTwo(bt.initAlloc)
-			curr.AddToList(mid)
-			curr.InsertTwoChildren(left, right, indexNext)
+			mid, left, right := bt.splitChild(curr, indexNext)
 			// determine which new node is the next child
 			if mid.key <= key {
 				curr = right
@@ -55,18 +60,135 @@ func (bt *BTree) Insert(key int, value interface{}) {
 	}
 	bt.size++
 	// since this B tree preemtively splits nodes, this key-value will fit into this node
-	curr.AddToList(newKeyValue(key, value))
+	curr.AddToList(kv)
+	curr.count++
+}
+
+// splitFullRoot checks the root for capacity and splits it under a new root if it is full (a new node will be allocated)
+func (bt *BTree) splitFullRoot() {
+	if bt.root.length > int(bt.t) {
+		mid, left, right := bt.root.SplitInTwo(bt.initAlloc)
+		newRoot := newbTreeNode(bt.initAlloc)
+		bt.root = &newRoot
+		bt.root.AddToList(mid)
+		bt.root.AddChild(left)
+		bt.root.AddChild(right)
+		bt.root.recount()
+	}
+}
+
+// splitChild splits the child of curr at index and moves its middle key-value up into curr
+func (bt *BTree) splitChild(curr *bTreeNode, index int) (*keyValue, *bTreeNode, *bTreeNode) {
+	mid, left, right := curr.children[index].SplitInTwo(bt.initAlloc)
+	// mid must sit between the two new children, even if curr has keys equal to it
+	curr.InsertToListAt(mid, index)
+	curr.InsertTwoChildren(left, right, index)
+	return mid, left, right
+}
+
+// Put will set the value of key, inserting it if it is not in the B-Tree. If key has duplicates only the occurrence closest to the root is changed.
+func (bt *BTree) Put(key int, value interface{}) {
+	bt.upsert(key, func(old interface{}, ok bool) (interface{}, bool) {
+		return value, true
+	})
+}
+
+// PutIfAbsent will insert key only if it is not already in the B-Tree. It will return whether or not the tree was changed.
+func (bt *BTree) PutIfAbsent(key int, value interface{}) bool {
+	inserted := false
+	bt.upsert(key, func(old interface{}, ok bool) (interface{}, bool) {
+		inserted = !ok
+		return value, !ok
+	})
+	return inserted
+}
+
+// Update calls fn with the current value of key and whether it was found, then stores the value fn returns. If fn returns false the tree is left unchanged.
+func (bt *BTree) Update(key int, fn func(old interface{}, ok bool) (interface{}, bool)) {
+	bt.upsert(key, fn)
+}
+
+// GetOrInsert returns the value of key if it is in the B-Tree, otherwise it inserts value and returns it. loaded reports whether the value was already there.
+func (bt *BTree) GetOrInsert(key int, value interface{}) (actual interface{}, loaded bool) {
+	bt.upsert(key, func(old interface{}, ok bool) (interface{}, bool) {
+		actual, loaded = old, ok
+		if !ok {
+			actual = value
+		}
+		return actual, !ok
+	})
+	return actual, loaded
+}
+
+// Swap sets the value of key, inserting it if it is not in the B-Tree, and returns the previous value. loaded reports whether there was a previous value.
+func (bt *BTree) Swap(key int, value interface{}) (previous interface{}, loaded bool) {
+	bt.upsert(key, func(old interface{}, ok bool) (interface{}, bool) {
+		previous, loaded = old, ok
+		return value, true
+	})
+	return previous, loaded
+}
+
+// upsert finds key with a single descent, preemptively splitting full nodes the same way as Insert. fn is given the value of the occurrence of key closest to the root and decides the value to store.
+// Like Find, an expired occurrence is deleted first, so fn is never given an expired value and a value stored for it does not keep the old expiry time.
+func (bt *BTree) upsert(key int, fn func(old interface{}, ok bool) (interface{}, bool)) {
+	bt.expireFound(key)
+	bt.splitFullRoot()
+	curr := bt.root
+	// path holds the ancestors of curr, whose counts grow if a key-value is inserted
+	path := []*bTreeNode{}
+	for {
+		curr.hash = nil
+		res, i := curr.Search(key)
+		if res != nil {
+			if value, store := fn(res.value, true); store {
+				res.value = value
+			}
+			return
+		}
+		if curr.numChildren == 0 {
+			// the key wasn't found, since nodes were split on the way down it will fit into this leaf
+			if value, store := fn(nil, false); store {
+				bt.size++
+				curr.InsertToListAt(newKeyValue(key, value), i)
+				curr.count++
+				for _, n := range path {
+					n.count++
+				}
+			}
+			return
+		}
+		if curr.children[i].length > int(bt.t) {
+			// the middle key-value moves up into curr and could be key, so search curr again
+			bt.splitChild(curr, i)
+		} else {
+			path = append(path, curr)
+			curr = curr.children[i]
+		}
+	}
 }
 
 // Find will find key in the B-Tree and return the node. Find will return the closest occurance of key to the root.
+// If that occurrence has expired every expired occurrence of key is deleted first, so an expired value is never returned.
+// Find can therefore change a tree that holds entries with a TTL, so it must not run under a read lock shared with other readers.
 func (bt *BTree) Find(key int) *interface{} {
+	bt.expireFound(key)
+	res := bt.find(key)
+	if res == nil {
+		return nil
+	}
+	return &res.value
+}
+
+// find returns the occurrence of key closest to the root, or nil if key is not in the B-Tree
+func (bt *BTree) find(key int) *keyValue {
 	curr := bt.root
 
 	for {
 		res, i := curr.Search(key)
 		if res != nil {
 			// the node was found
-			return &res.value
+			return res
 		} else {
 			if curr.numChildren == 0 {
 				// the node wasn't found and there are no more children to check
@@ -79,11 +201,64 @@ func (bt *BTree) Find(key int) *interface{} {
 	}
 }
 
-// Contains determines if key exists in the B-Tree and returns the result.
+// Contains determines if key exists in the B-Tree and returns the result. Like Find it can delete expired occurrences of key.
 func (bt *BTree) Contains(key int) bool {
 	return bt.Find(key) != nil
 }
 
+// FindAll returns the values of every occurrence of key in insertion order. It returns an empty slice if key is not in the B-Tree.
+func (bt *BTree) FindAll(key int) []interface{} {
+	vals := []interface{}{}
+	bt.ascendRange(key, key, func(kv *keyValue) bool {
+		vals = append(vals, kv.value)
+		return true
+	})
+	return vals
+}
+
+// Count returns the number of occurrences of key in the B-Tree.
+func (bt *BTree) Count(key int) int {
+	count := 0
+	bt.ascendRange(key, key, func(kv *keyValue) bool {
+		count++
+		return true
+	})
+	return count
+}
+
+// DeleteAll will delete every occurrence of key in the B-Tree. It will return the number of key-values deleted.
+func (bt *BTree) DeleteAll(key int) int {
+	count := 0
+	for bt.Delete(key) {
+		count++
+	}
+	return count
+}
+
+// ascendRange calls fn on every key-value with lo <= key <= hi in order, until fn returns false
+func (bt *BTree) ascendRange(lo, hi int, fn func(kv *keyValue) bool) {
+	if bt.root != nil {
+		ascendRangeNode(bt.root, lo, hi, fn)
+	}
+}
+
+// ascendRangeNode walks the subtree of btn for ascendRange. It returns false once the walk should stop.
+func ascendRangeNode(btn *bTreeNode, lo, hi int, fn func(kv *keyValue) bool) bool {
+	// children before the lower bound can only hold keys smaller than lo
+	for i := btn.LowerBound(lo); i <= btn.length; i++ {
+		if btn.numChildren > 0 && !ascendRangeNode(btn.children[i], lo, hi, fn) {
+			return false
+		}
+		if i == btn.length {
+			break
+		}
+		if btn.nodes[i].key > hi || !fn(btn.nodes[i]) {
+			return false
+		}
+	}
+	return true
+}
+
 func (bt *BTree) Keys() []int {
 	keys := make([]int, bt.size)
 	if bt.size == 0 {
@@ -237,10 +412,77 @@ func (bt *BTree) slice() []*keyValue {
 	return nodes
 }
 
-// Clear clears the B-Tree of all nodes.
+// bulkLoad builds a new B-Tree with the same parameters as bt from sorted key-values in linear time. Nodes are built bottom up and filled to t keys, one below the split size, so the next insert into a node does not split it.
+func (bt *BTree) bulkLoad(kvs []*keyValue) BTree {
+	result := BTree{size: uint64(len(kvs)), t: bt.t, initAlloc: bt.initAlloc, encode: bt.encode}
+	capacity := int(bt.t)
+	if len(kvs) == 0 {
+		root := newbTreeNode(bt.initAlloc)
+		result.root = &root
+		return result
+	}
+
+	// the leaves hold every key-value except the separators between them
+	numLeaves := (len(kvs) + capacity + 1) / (capacity + 1)
+	level := make([]*bTreeNode, 0, numLeaves)
+	separators := make([]*keyValue, 0, numLeaves-1)
+	perLeaf, extra := (len(kvs)-(numLeaves-1))/numLeaves, (len(kvs)-(numLeaves-1))%numLeaves
+	i := 0
+	for l := 0; l < numLeaves; l++ {
+		count := perLeaf
+		if l < extra {
+			count++
+		}
+		leaf := newbTreeNode(max(bt.initAlloc, count))
+		leaf.nodes = append(leaf.nodes, kvs[i:i+count]...)
+		leaf.length = count
+		leaf.count = uint64(count)
+		level = append(level, &leaf)
+		i += count
+		if l < numLeaves-1 {
+			separators = append(separators, kvs[i])
+			i++
+		}
+	}
+
+	// each parent level takes its children and the separators between them, passing the separators between parents up
+	for len(level) > 1 {
+		numParents := (len(level) + capacity) / (capacity + 1)
+		parents := make([]*bTreeNode, 0, numParents)
+		parentSeparators := make([]*keyValue, 0, numParents-1)
+		perParent, extra := len(level)/numParents, len(level)%numParents
+		c := 0
+		for p := 0; p < numParents; p++ {
+			count := perParent
+			if p < extra {
+				count++
+			}
+			parent := newbTreeNode(max(bt.initAlloc, count-1))
+			parent.nodes = append(parent.nodes, separators[c:c+count-1]...)
+			parent.length = count - 1
+			parent.children = append(parent.children, level[c:c+count]...)
+			parent.numChildren = count
+			parent.recount()
+			parents = append(parents, &parent)
+			c += count
+			if p < numParents-1 {
+				parentSeparators = append(parentSeparators, separators[c-1])
+			}
+		}
+		level, separators = parents, parentSeparators
+	}
+	result.root = level[0]
+	return result
+}
+
+// Clear clears the B-Tree of all nodes. The tree is left with an empty root so it can be used again.
 func (bt *BTree) Clear() {
-	bt.root = nil
+	root := newbTreeNode(bt.initAlloc)
+	bt.root = &root
 	bt.size = 0
+	if bt.expiry != nil {
+		bt.expiry.clear()
+	}
 }
 
 // Height calculates the height of the B tree
@@ -296,6 +538,122 @@ func (bt *BTree) String() string {
 	}
 }
 
+// Dot will return the B-Tree in the graphviz DOT language. Each node is labelled with its keys.
+func (bt *BTree) Dot() string {
+	str := "digraph BTree {\n\tnode [shape=box];\n"
+	nodeQ := []*bTreeNode{}
+	idQ := []int{}
+	id := 0
+
+	if bt.root != nil {
+		nodeQ = append(nodeQ, bt.root)
+		idQ = append(idQ, id)
+	}
+	for len(nodeQ) > 0 {
+		curr, currID := nodeQ[0], idQ[0]
+		nodeQ, idQ = nodeQ[1:], idQ[1:]
+		label := ""
+		for i, kv := range curr.nodes {
+			if i > 0 {
+				label += " "
+			}
+			label += strconv.Itoa(kv.key)
+		}
+		str += "\tn" + strconv.Itoa(currID) + " [label=\"" + label + "\"];\n"
+		for _, child := range curr.children[:curr.numChildren] {
+			id++
+			str += "\tn" + strconv.Itoa(currID) + " -> n" + strconv.Itoa(id) + ";\n"
+			nodeQ = append(nodeQ, child)
+			idQ = append(idQ, id)
+		}
+	}
+	return str + "}\n"
+}
+
+// BTreeStats is a report on the shape and memory use of a B-Tree, see (*BTree).Stats
+type BTreeStats struct {
+	Size   uint64
+	Height uint64
+	// Nodes is the total number of b tree nodes
+	Nodes uint64
+	// NodesPerLevel is the number of nodes at each level, starting with the root
+	NodesPerLevel []uint64
+	// FillHistogram counts nodes by how full they are. Bucket i holds nodes filled between i*10% and (i+1)*10% of MaxKeys, the last bucket includes full (or overfull) nodes
+	FillHistogram [10]uint64
+	// MaxKeys is the most keys a node holds before it is split
+	MaxKeys int
+	// AvgKeys, MinKeys and MaxKeysSeen are the average, smallest and largest number of keys in a node
+	AvgKeys     float64
+	MinKeys     int
+	MaxKeysSeen int
+	// WastedSlots is the number of allocated but unused key-value and child slots, mostly from initAlloc preallocation
+	WastedSlots uint64
+	// WastedBytes is the memory taken by WastedSlots
+	WastedBytes uint64
+	// MemoryBytes is an estimate of the memory used by the tree structure. It does not include the memory pointed to by values.
+	MemoryBytes uint64
+}
+
+// Stats walks the B-Tree and returns a report on node count per level, node occupancy and memory use.
+func (bt *BTree) Stats() BTreeStats {
+	stats := BTreeStats{Size: bt.size, Height: bt.Height(), MaxKeys: int(bt.t) + 1}
+	ptrSize := uint64(unsafe.Sizeof(uintptr(0)))
+	stats.MemoryBytes = uint64(unsafe.Sizeof(*bt)) + bt.size*uint64(unsafe.Sizeof(keyValue{}))
+	if bt.root == nil || bt.root.length == 0 {
+		return stats
+	}
+	stats.MinKeys = bt.root.length
+	nodeQ := []*bTreeNode{bt.root}
+
+	for len(nodeQ) > 0 {
+		nodeCount := len(nodeQ)
+		stats.NodesPerLevel = append(stats.NodesPerLevel, uint64(nodeCount))
+		for _, curr := range nodeQ[:nodeCount] {
+			stats.Nodes++
+			if curr.length < stats.MinKeys {
+				stats.MinKeys = curr.length
+			}
+			if curr.length > stats.MaxKeysSeen {
+				stats.MaxKeysSeen = curr.length
+			}
+			bucket := curr.length * len(stats.FillHistogram) / stats.MaxKeys
+			if bucket >= len(stats.FillHistogram) {
+				bucket = len(stats.FillHistogram) - 1
+			}
+			stats.FillHistogram[bucket]++
+
+			slots := uint64(cap(curr.nodes) + cap(curr.children))
+			wasted := slots - uint64(curr.length+curr.numChildren)
+			stats.WastedSlots += wasted
+			stats.MemoryBytes += uint64(unsafe.Sizeof(*curr)) + slots*ptrSize
+			nodeQ = append(nodeQ, curr.children[:curr.numChildren]...)
+		}
+		nodeQ = nodeQ[nodeCount:]
+	}
+	stats.WastedBytes = stats.WastedSlots * ptrSize
+	stats.AvgKeys = float64(bt.size) / float64(stats.Nodes)
+	return stats
+}
+
+// String formats the stats as a multi-line report
+func (s BTreeStats) String() string {
+	str := "size: " + strconv.FormatUint(s.Size, 10) + "\n"
+	str += "height: " + strconv.FormatUint(s.Height, 10) + "\n"
+	str += "nodes: " + strconv.FormatUint(s.Nodes, 10) + "\n"
+	str += "nodes per level:"
+	for _, n := range s.NodesPerLevel {
+		str += " " + strconv.FormatUint(n, 10)
+	}
+	str += "\nkeys per node: avg " + strconv.FormatFloat(s.AvgKeys, 'f', 2, 64) + " min " + strconv.Itoa(s.MinKeys) + " max " + strconv.Itoa(s.MaxKeysSeen) + " (nodes split at " + strconv.Itoa(s.MaxKeys) + ")\n"
+	str += "fill histogram:\n"
+	for i, n := range s.FillHistogram {
+		str += "  " + strconv.Itoa(i*10) + "-" + strconv.Itoa((i+1)*10) + "%: " + strconv.FormatUint(n, 10) + "\n"
+	}
+	str += "wasted slots: " + strconv.FormatUint(s.WastedSlots, 10) + " (" + strconv.FormatUint(s.WastedBytes, 10) + " bytes)\n"
+	str += "estimated memory: " + strconv.FormatUint(s.MemoryBytes, 10) + " bytes\n"
+	return str
+}
+
 func (bt *BTree) Size() uint64 {
 	return bt.size
 }
@@ -304,8 +662,11 @@ func (bt *BTree) Size() uint64 {
 func (bt *BTree) Delete(key int) bool {
 	curr := bt.root
 	t := int(bt.t)
+	// path holds the ancestors of curr, whose counts shrink once the key-value is deleted
+	path := []*bTreeNode{}
 
 	for {
+		curr.hash = nil
 		res, i := curr.Search(key)
 		leftSibling := i > 0
 		rightSibling := i < curr.length
@@ -333,11 +694,17 @@ func (bt *BTree) Delete(key int) bool {
 					if curr == bt.root && bt.root.length <= 0 {
 						bt.root = curr.children[i]
 					}
+					path = append(path, curr)
 					curr = curr.children[i]
 					// must skip return since more merges may be required
 					continue
 				}
 			}
+			bt.forgetExpiry(res)
+			curr.count--
+			for _, n := range path {
+				n.count--
+			}
 			bt.size--
 			return true
 		} else {
@@ -354,6 +721,7 @@ func (bt *BTree) Delete(key int) bool {
 					// premptive merging is required
 					i = bt.validateNextChildSize(curr, leftSibling, rightSibling, i)
 				}
+				path = append(path, curr)
 				curr = curr.children[i]
 			}
 		}
@@ -404,8 +772,8 @@ func borrowLeft(parent, left, curr *bTreeNode, index int) {
 	if index >= parent.length {
 		node_index = parent.length - 1
 	}
-	// shift the in order predecessor down to this current node
-	curr.AddToList(parent.nodes[node_index])
+	// shift the in order predecessor down to the front of this current node
+	curr.InsertToListAt(parent.nodes[node_index], 0)
 	// replace the shifted parent KV with the in order predecessor (largest key in left)
 	parent.nodes[node_index] = left.nodes[left.length-1]
 	// remove the KV from left
@@ -415,6 +783,8 @@ func borrowLeft(parent, left, curr *bTreeNode, index int) {
 		curr.PrependChild(left.children[left.numChildren-1])
 		left.DeleteChild(left.numChildren - 1)
 	}
+	left.recount()
+	curr.recount()
 }
 
 // borrowRight is called when the current node can borrow a KV from the parent who can then borrow a KV from the right sibling of curr
@@ -424,8 +794,8 @@ func borrowRight(parent, right, curr *bTreeNode, index int) {
 	if index >= parent.length {
 		node_index = parent.length - 1
 	}
-	// shift the in order successor down to this current node
-	curr.AddToList(parent.nodes[node_index])
+	// shift the in order successor down to the end of this current node
+	curr.InsertToListAt(parent.nodes[node_index], curr.length)
 	// replace the shifted parent KV with the in order successor (smallest key in right)
 	parent.nodes[node_index] = right.nodes[0]
 	// remove the KV from right
@@ -435,6 +805,8 @@ func borrowRight(parent, right, curr *bTreeNode, index int) {
 		curr.AddChild(right.children[0])
 		right.DeleteChild(0)
 	}
+	right.recount()
+	curr.recount()
 }
 
 // parentMerge is called when it cannot borrow from both left and right silbings
@@ -445,34 +817,117 @@ func parentMerge(parent, left, curr *bTreeNode, index int) {
 		node_index = parent.length - 1
 	}
 	// add the node from the parent in the merge
-	left.AddToList(parent.nodes[node_index])
+	left.InsertToListAt(parent.nodes[node_index], left.length)
 	parent.RemoveFromListAt(node_index)
 	// merge the silbing to the right (curr)
 	left.MergeRightSilbing(curr)
 	// right (curr) as it has been merged into left (+1 so the right child is deleted)
 	parent.DeleteChild(node_index + 1)
+	left.recount()
 }
 
-// findAndDeleteIOP find and delete in order predecessor
+// findAndDeleteIOP find and delete in order predecessor. Every node on the way down loses one key-value from its subtree
 func (bt *BTree) findAndDeleteIOP(start *bTreeNode) *keyValue {
 	for start.numChildren != 0 {
+		start.hash = nil
+		start.count--
 		// fixed sibling flags since this follows the right side
 		bt.validateNextChildSize(start, true, false, start.numChildren-1)
 		start = start.children[start.numChildren-1]
 	}
 	pred := start.nodes[start.length-1]
 	start.RemoveFromListAt(start.length - 1)
+	start.count--
 	return pred
 }
 
-// findAndDeleteIOS find and delete in order successor
+// findAndDeleteIOS find and delete in order successor. Every node on the way down loses one key-value from its subtree
 func (bt *BTree) findAndDeleteIOS(start *bTreeNode) *keyValue {
 	for start.numChildren != 0 {
+		start.hash = nil
+		start.count--
 		// fixed sibling flags since this follows the left side
 		bt.validateNextChildSize(start, false, true, 0)
 		start = start.children[0]
 	}
 	pred := start.nodes[0]
 	start.RemoveFromListAt(0)
+	start.count--
 	return pred
 }
+
+// Verify walks the whole B-Tree and checks its invariants. It returns nil if the tree is valid, otherwise an error describing the first problem found.
+func (bt *BTree) Verify() error {
+	if bt.root == nil {
+		if bt.size != 0 {
+			return errors.New("tree has no root but size is " + strconv.FormatUint(bt.size, 10))
+		}
+		return nil
+	}
+	count := uint64(0)
+	leafDepth := -1
+	if err := bt.verifyNode(bt.root, 0, nil, nil, &leafDepth, &count); err != nil {
+		return err
+	}
+	if count != bt.size {
+		return errors.New("tree holds " + strconv.FormatUint(count, 10) + " keys but size is " + strconv.FormatUint(bt.size, 10))
+	}
+	return bt.verifyExpiry()
+}
+
+// verifyNode checks a single node and its subtree. lo and hi are the inclusive key bounds given by the parent (nil is unbounded)
+func (bt *BTree) verifyNode(btn *bTreeNode, depth int, lo, hi *int, leafDepth *int, count *uint64) error {
+	if btn.length != len(btn.nodes) {
+		return errors.New("node " + btn.String() + " has length " + strconv.Itoa(btn.length) + " but holds " + strconv.Itoa(len(btn.nodes)) + " key-values")
+	}
+	if btn != bt.root && btn.length == 0 {
+		return errors.New("empty non-root node at depth " + strconv.Itoa(depth))
+	}
+	for i, kv := range btn.nodes {
+		if kv == nil {
+			return errors.New("node " + btn.String() + " has a nil key-value at index " + strconv.Itoa(i))
+		}
+		if i > 0 && btn.nodes[i-1].key > kv.key {
+			return errors.New("node " + btn.String() + " is not sorted")
+		}
+		if (lo != nil && kv.key < *lo) || (hi != nil && kv.key > *hi) {
+			return errors.New("key " + strconv.Itoa(kv.key) + " is outside the range given by its parent")
+		}
+	}
+	before := *count
+	*count += uint64(btn.length)
+
+	if btn.numChildren == 0 {
+		if btn.count != uint64(btn.length) {
+			return errors.New("leaf " + btn.String() + " holds " + strconv.Itoa(btn.length) + " key-values but its count is " + strconv.FormatUint(btn.count, 10))
+		}
+		if *leafDepth == -1 {
+			*leafDepth = depth
+		} else if *leafDepth != depth {
+			return errors.New("leaf " + btn.String() + " is at depth " + strconv.Itoa(depth) + " but other leaves are at depth " + strconv.Itoa(*leafDepth))
+		}
+		return bt.verifyHash(btn)
+	}
+	if btn.numChildren != btn.length+1 || len(btn.children) != btn.numChildren {
+		return errors.New("node " + btn.String() + " has " + strconv.Itoa(btn.length) + " keys but " + strconv.Itoa(btn.numChildren) + " children")
+	}
+	for i, child := range btn.children {
+		if child == nil {
+			return errors.New("node " + btn.String() + " has a nil child at index " + strconv.Itoa(i))
+		}
+		childLo, childHi := lo, hi
+		if i > 0 {
+			childLo = &btn.nodes[i-1].key
+		}
+		if i < btn.length {
+			childHi = &btn.nodes[i].key
+		}
+		if err := bt.verifyNode(child, depth+1, childLo, childHi, leafDepth, count); err != nil {
+			return err
+		}
+	}
+	if *count-before != btn.count {
+		return errors.New("subtree of " + btn.String() + " holds " + strconv.FormatUint(*count-before, 10) + " key-values but its count is " + strconv.FormatUint(btn.count, 10))
+	}
+	return bt.verifyHash(btn)
+}
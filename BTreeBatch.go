@@ -0,0 +1,81 @@
+package GoTrees
+
+// InsertBatch will insert every key-value of kvs, the same as calling Insert for each in order.
+// The batch is sorted first (already sorted batches are detected and left alone, kvs itself is never changed). Keys are then inserted in order while remembering the path to the last leaf,
+// so each key only climbs as far as the subtree that can hold it instead of descending from the root. The counts along the path are added up as the path leaves each node, not once per key.
+func (bt *BTree) InsertBatch(kvs []Entry) {
+	maxKeys := int(bt.t) + 1
+	// path is the finger from the root to the last leaf. indexes[i] is the index of path[i+1] in the children of path[i],
+	// and highs[i] is the first key too large for the subtree of path[i] (nil if the subtree is unbounded above)
+	path := []*bTreeNode{bt.root}
+	indexes := []int{}
+	highs := []*int{nil}
+	// pending[i] is the number of key-values inserted below path[i] that its count does not include yet. A node's count is only brought up to date when it leaves
+	// the path, and the inserts are passed on to its parent then, so an insert only touches the nodes it climbs over and descends through
+	pending := []uint64{0}
+	flush := func(i int) {
+		path[i].count += pending[i]
+		if i > 0 {
+			pending[i-1] += pending[i]
+		}
+		pending[i] = 0
+	}
+	if len(kvs) > 0 {
+		bt.root.hash = nil
+	}
+
+	for _, kv := range sortedEntries(kvs) {
+		key := kv.Key
+
+		// climb until the subtree at the end of the path can hold key
+		for len(path) > 1 && highs[len(path)-1] != nil && key >= *highs[len(path)-1] {
+			flush(len(path) - 1)
+			path = path[:len(path)-1]
+			indexes = indexes[:len(indexes)-1]
+			highs = highs[:len(highs)-1]
+			pending = pending[:len(pending)-1]
+		}
+		// descend to the leaf, equal keys are passed on the right so duplicates stay in insertion order
+		curr := path[len(path)-1]
+		for curr.numChildren > 0 {
+			next := curr.UpperBound(key)
+			high := highs[len(highs)-1]
+			if next < curr.length {
+				high = &curr.nodes[next].key
+			}
+			curr = curr.children[next]
+			// every node the path reaches gets a key-value below it, so its cached hash is out of date
+			curr.hash = nil
+			path = append(path, curr)
+			indexes = append(indexes, next)
+			highs = append(highs, high)
+			pending = append(pending, 0)
+		}
+		curr.AddToList(newKeyValue(key, kv.Value))
+		bt.size++
+		pending[len(pending)-1]++
+
+		// split overfull nodes bottom up. The path below a split node is no longer valid, so it is cut back to the parent
+		for j := len(path) - 1; j >= 0 && path[j].length > maxKeys; j-- {
+			// the halves are recounted from their children, which are already up to date
+			flush(j)
+			mid, left, right := path[j].SplitInTwo(bt.initAlloc)
+			if j == 0 {
+				newRoot := newbTreeNode(bt.initAlloc)
+				bt.root = &newRoot
+				bt.root.AddToList(mid)
+				bt.root.AddChild(left)
+				bt.root.AddChild(right)
+				bt.root.recount()
+				path, indexes, highs, pending = []*bTreeNode{bt.root}, []int{}, []*int{nil}, []uint64{0}
+				break
+			}
+			path[j-1].InsertToListAt(mid, indexes[j-1])
+			path[j-1].InsertTwoChildren(left, right, indexes[j-1])
+			path, indexes, highs, pending = path[:j], indexes[:j-1], highs[:j], pending[:j]
+		}
+	}
+	for i := len(path) - 1; i >= 0; i-- {
+		flush(i)
+	}
+}
@@ -0,0 +1,130 @@
+package GoTrees
+
+import (
+	"math/rand"
+	sc "strconv"
+	"testing"
+)
+
+// checkSameTree fails if the keys or values of the two trees differ
+func checkSameTree(t *testing.T, actual, expected *BTree) {
+	if err := actual.Verify(); err != nil {
+		t.Fatal("Tree failed verification: " + err.Error())
+	}
+	checkKeys(t, "InsertBatch", actual.Keys(), expected.Keys())
+	expectedVals := expected.Values()
+	for i, v := range actual.Values() {
+		if v != expectedVals[i] {
+			t.Fatal("InsertBatch value was incorrect at index " + sc.Itoa(i))
+		}
+	}
+}
+
+// randomBatch returns nRAND key-values in random order. nRAND - 1 keys ensure duplicates within and between batches, and the values are unique across batches.
+func randomBatch(batch int) []Entry {
+	kvs := make([]Entry, nRAND)
+	for i := range kvs {
+		kvs[i] = Entry{Key: rand.Intn(nRAND - 1), Value: batch*nRAND + i}
+	}
+	return kvs
+}
+
+func TestBTreeInsertBatch(t *testing.T) {
+	for _, tParam := range []uint{0, 1, 3} {
+		for _, alloc := range []float32{0, nAlloc, 1} {
+			BT := NewBTree(tParam, alloc)
+			expected := NewBTree(tParam, alloc)
+			// several batches so later batches land in an existing tree
+			for batch := 0; batch < 5; batch++ {
+				kvs := randomBatch(batch)
+				for _, kv := range kvs {
+					expected.Insert(kv.Key, kv.Value)
+				}
+				original := append([]Entry{}, kvs...)
+				BT.InsertBatch(kvs)
+				for i := range kvs {
+					if kvs[i] != original[i] {
+						t.Fatal("InsertBatch reordered the batch it was given. ")
+					}
+				}
+				if BT.Size() != expected.Size() {
+					t.Fatal("BT size incorrect, expected " + sc.Itoa(int(expected.Size())) + " but got " + sc.Itoa(int(BT.Size())))
+				}
+				checkSameTree(t, &BT, &expected)
+			}
+		}
+	}
+}
+
+func TestBTreeInsertBatchSorted(t *testing.T) {
+	BT := NewBTree(T, nAlloc)
+	expected := NewBTree(T, nAlloc)
+	kvs := make([]Entry, nRAND*10)
+	for i := range kvs {
+		kvs[i] = Entry{Key: i / 3, Value: i}
+		expected.Insert(kvs[i].Key, kvs[i].Value)
+	}
+
+	BT.InsertBatch(kvs)
+	checkSameTree(t, &BT, &expected)
+	for _, k := range rand.Perm(nRAND) {
+		BT.Delete(k)
+	}
+	if err := BT.Verify(); err != nil {
+		t.Fatal("Batch inserted tree failed verification after deletes: " + err.Error())
+	}
+}
+
+func BenchmarkBTreeInsert(b *testing.B) {
+	for n := 0; n < b.N; n++ {
+		BT := NewBTree(4, nAlloc)
+		for i := 0; i < nRAND*100; i++ {
+			BT.Insert(i, nil)
+		}
+	}
+}
+
+func BenchmarkBTreeInsertBatch(b *testing.B) {
+	kvs := make([]Entry, nRAND*100)
+	for i := range kvs {
+		kvs[i] = Entry{Key: i}
+	}
+	for n := 0; n < b.N; n++ {
+		BT := NewBTree(4, nAlloc)
+		BT.InsertBatch(kvs)
+	}
+}
+
+// deepTree returns a B-Tree with the smallest nodes, so it is as deep as a B-Tree of its size gets, holding the even keys below nRAND*100
+func deepTree() BTree {
+	BT := NewBTree(1, nAlloc)
+	for i := 0; i < nRAND*100; i += 2 {
+		BT.Insert(i, nil)
+	}
+	return BT
+}
+
+// the odd keys fall between keys already in the tree, so each Insert descends the whole height while the batch only climbs to the next leaf
+func BenchmarkBTreeInsertSortedDeep(b *testing.B) {
+	for n := 0; n < b.N; n++ {
+		b.StopTimer()
+		BT := deepTree()
+		b.StartTimer()
+		for i := 1; i < nRAND*100; i += 2 {
+			BT.Insert(i, nil)
+		}
+	}
+}
+
+func BenchmarkBTreeInsertBatchDeep(b *testing.B) {
+	kvs := make([]Entry, 0, nRAND*50)
+	for i := 1; i < nRAND*100; i += 2 {
+		kvs = append(kvs, Entry{Key: i})
+	}
+	for n := 0; n < b.N; n++ {
+		b.StopTimer()
+		BT := deepTree()
+		b.StartTimer()
+		BT.InsertBatch(kvs)
+	}
+}
@@ -0,0 +1,277 @@
+package GoTrees
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+)
+
+// EncodeValue is the default encoding of values for the merkle hashes of a BTree. Basic types are encoded with their type so 1 and "1" hash differently.
+// Other types fall back to their Go syntax representation, which is only stable between processes for plain data (not pointers), so use SetValueEncoder for them.
+func EncodeValue(value interface{}) []byte {
+	switch v := value.(type) {
+	case nil:
+		return []byte{'n'}
+	case []byte:
+		return append([]byte{'b'}, v...)
+	case string:
+		return append([]byte{'s'}, v...)
+	case bool:
+		return []byte("t" + strconv.FormatBool(v))
+	case int:
+		return []byte("i" + strconv.Itoa(v))
+	case int64:
+		return []byte("i" + strconv.FormatInt(v, 10))
+	case uint64:
+		return []byte("u" + strconv.FormatUint(v, 10))
+	case float64:
+		return []byte("f" + strconv.FormatUint(math.Float64bits(v), 16))
+	}
+	return []byte(fmt.Sprintf("v%T:%#v", value, value))
+}
+
+// SetValueEncoder sets how values are turned into bytes for the merkle hashes, nil restores EncodeValue. Every cached hash is dropped.
+// Trees compared with Diff or checked against each others root hash must use the same encoder.
+func (bt *BTree) SetValueEncoder(encode func(value interface{}) []byte) {
+	bt.encode = encode
+	var clear func(btn *bTreeNode)
+	clear = func(btn *bTreeNode) {
+		btn.hash = nil
+		for _, child := range btn.children[:btn.numChildren] {
+			clear(child)
+		}
+	}
+	clear(bt.root)
+}
+
+func (bt *BTree) encodeValue(value interface{}) []byte {
+	if bt.encode == nil {
+		return EncodeValue(value)
+	}
+	return bt.encode(value)
+}
+
+// RootHash returns the merkle hash of the B-Tree. Each node hashes its keys, values and the hashes of its children, and hashes are cached in the nodes.
+// Insert, Delete and the other changes clear the cached hashes along the path they change, so only those nodes are hashed again.
+// Changing a value through the pointer returned by Find is not seen, use Put instead.
+// Two trees with the same root hash hold the same key-values, but the hash also depends on the shape of the tree.
+func (bt *BTree) RootHash() []byte {
+	return bt.hashNode(bt.root)
+}
+
+// hashNode returns the hash of btn, hashing any children whose cached hash was cleared first
+func (bt *BTree) hashNode(btn *bTreeNode) []byte {
+	if btn.hash != nil {
+		return btn.hash
+	}
+	children := make([][]byte, btn.numChildren)
+	for i, child := range btn.children[:btn.numChildren] {
+		children[i] = bt.hashNode(child)
+	}
+	keys, values := btn.keysAndValues(bt.encodeValue)
+	btn.hash = hashNodeContents(keys, values, children)
+	return btn.hash
+}
+
+// keysAndValues returns the keys of btn and their encoded values
+func (btn *bTreeNode) keysAndValues(encode func(value interface{}) []byte) ([]int, [][]byte) {
+	keys := make([]int, btn.length)
+	values := make([][]byte, btn.length)
+	for i, kv := range btn.nodes {
+		keys[i] = kv.key
+		values[i] = encode(kv.value)
+	}
+	return keys, values
+}
+
+// hashNodeContents hashes the keys and encoded values of a node followed by the hashes of its children. Every part is length prefixed so different nodes can not hash the same bytes.
+func hashNodeContents(keys []int, values [][]byte, children [][]byte) []byte {
+	h := sha256.New()
+	buf := make([]byte, binary.MaxVarintLen64)
+	h.Write(buf[:binary.PutUvarint(buf, uint64(len(keys)))])
+	for i, key := range keys {
+		h.Write(buf[:binary.PutVarint(buf, int64(key))])
+		h.Write(buf[:binary.PutUvarint(buf, uint64(len(values[i])))])
+		h.Write(values[i])
+	}
+	h.Write(buf[:binary.PutUvarint(buf, uint64(len(children)))])
+	for _, child := range children {
+		h.Write(child)
+	}
+	return h.Sum(nil)
+}
+
+// BTreeProof proves that a key-value is in a B-Tree with a given root hash. Path holds the node with the key-value first, then each parent up to the root.
+type BTreeProof struct {
+	Key   int
+	Value []byte
+	Path  []BTreeProofNode
+}
+
+// BTreeProofNode is the content of one node of a BTreeProof: its keys, their encoded values and the hashes of its children.
+type BTreeProofNode struct {
+	Keys     []int
+	Values   [][]byte
+	Children [][]byte
+}
+
+// Proof returns a membership proof for the occurrence of key closest to the root, the same one Find returns, or nil if key is not in the B-Tree.
+func (bt *BTree) Proof(key int) *BTreeProof {
+	bt.RootHash()
+	path := []BTreeProofNode{}
+	curr := bt.root
+	for {
+		keys, values := curr.keysAndValues(bt.encodeValue)
+		children := make([][]byte, curr.numChildren)
+		for i, child := range curr.children[:curr.numChildren] {
+			children[i] = child.hash
+		}
+		path = append([]BTreeProofNode{{Keys: keys, Values: values, Children: children}}, path...)
+		res, i := curr.Search(key)
+		if res != nil {
+			return &BTreeProof{Key: key, Value: bt.encodeValue(res.value), Path: path}
+		}
+		if curr.numChildren == 0 {
+			return nil
+		}
+		curr = curr.children[i]
+	}
+}
+
+// Verify checks the proof against rootHash. It returns true if the first node holds Key with Value and hashing each node up the path ends at rootHash.
+func (p *BTreeProof) Verify(rootHash []byte) bool {
+	if len(p.Path) == 0 {
+		return false
+	}
+	var hash []byte
+	for i, node := range p.Path {
+		if len(node.Keys) != len(node.Values) {
+			return false
+		}
+		found := false
+		if i == 0 {
+			for j, key := range node.Keys {
+				found = found || (key == p.Key && bytes.Equal(node.Values[j], p.Value))
+			}
+		} else {
+			for _, child := range node.Children {
+				found = found || bytes.Equal(child, hash)
+			}
+		}
+		if !found {
+			return false
+		}
+		hash = hashNodeContents(node.Keys, node.Values, node.Children)
+	}
+	return bytes.Equal(hash, rootHash)
+}
+
+// Diff returns the changes that turn bt into other, in key order. Values are compared with reflect.DeepEqual, as in the package Diff, so both return the same changes.
+// Both trees are walked together and any pair of subtrees with the same hash is skipped without visiting it, so trees that share most of their nodes' contents are compared quickly.
+// The skip assumes that values with the same encoding are deeply equal, which holds for EncodeValue on plain data.
+// Duplicate keys are paired up by position, and the position of each change is found from the tree it is in.
+func (bt *BTree) Diff(other *BTree) Patch {
+	bt.RootHash()
+	other.RootHash()
+	changes := Patch{}
+	a := []diffItem{{node: bt.root, height: spineHeight(bt.root)}}
+	b := []diffItem{{node: other.root, height: spineHeight(other.root)}}
+	for len(a) > 0 && len(b) > 0 {
+		x, y := a[len(a)-1], b[len(b)-1]
+		if x.node != nil && y.node != nil && bytes.Equal(x.node.hash, y.node.hash) {
+			a, b = a[:len(a)-1], b[:len(b)-1]
+		} else if x.node != nil && (y.node == nil || x.height >= y.height) {
+			a = expandDiffItem(a)
+		} else if y.node != nil {
+			b = expandDiffItem(b)
+		} else if x.kv.key < y.kv.key {
+			changes = append(changes, Change{Kind: Removed, Key: x.kv.key, Index: bt.occurrence(x.kv), Old: x.kv.value})
+			a = a[:len(a)-1]
+		} else if x.kv.key > y.kv.key {
+			changes = append(changes, Change{Kind: Added, Key: y.kv.key, Index: other.occurrence(y.kv), New: y.kv.value})
+			b = b[:len(b)-1]
+		} else {
+			if !reflect.DeepEqual(x.kv.value, y.kv.value) {
+				changes = append(changes, Change{Kind: Changed, Key: x.kv.key, Index: bt.occurrence(x.kv), Old: x.kv.value, New: y.kv.value})
+			}
+			a, b = a[:len(a)-1], b[:len(b)-1]
+		}
+	}
+	for len(a) > 0 {
+		if a[len(a)-1].node != nil {
+			a = expandDiffItem(a)
+		} else {
+			changes = append(changes, Change{Kind: Removed, Key: a[len(a)-1].kv.key, Index: bt.occurrence(a[len(a)-1].kv), Old: a[len(a)-1].kv.value})
+			a = a[:len(a)-1]
+		}
+	}
+	for len(b) > 0 {
+		if b[len(b)-1].node != nil {
+			b = expandDiffItem(b)
+		} else {
+			changes = append(changes, Change{Kind: Added, Key: b[len(b)-1].kv.key, Index: other.occurrence(b[len(b)-1].kv), New: b[len(b)-1].kv.value})
+			b = b[:len(b)-1]
+		}
+	}
+	return changes
+}
+
+// occurrence returns the position of kv among the occurrences of its key in bt
+func (bt *BTree) occurrence(kv *keyValue) int {
+	i := 0
+	bt.ascendRange(kv.key, kv.key, func(other *keyValue) bool {
+		if other == kv {
+			return false
+		}
+		i++
+		return true
+	})
+	return i
+}
+
+// diffItem is either a whole subtree of the given height or a single key-value, waiting to be compared by Diff
+type diffItem struct {
+	node   *bTreeNode
+	kv     *keyValue
+	height int
+}
+
+// expandDiffItem replaces the subtree on top of the stack with its children and key-values, so the first of them is on top
+func expandDiffItem(stack []diffItem) []diffItem {
+	top := stack[len(stack)-1]
+	stack = stack[:len(stack)-1]
+	btn := top.node
+	for i := btn.length; i >= 0; i-- {
+		if btn.numChildren > 0 {
+			stack = append(stack, diffItem{node: btn.children[i], height: top.height - 1})
+		}
+		if i > 0 {
+			stack = append(stack, diffItem{kv: btn.nodes[i-1]})
+		}
+	}
+	return stack
+}
+
+// verifyHash checks that a cached hash of btn is still correct, which needs every child to have a cached hash too
+func (bt *BTree) verifyHash(btn *bTreeNode) error {
+	if btn.hash == nil {
+		return nil
+	}
+	children := make([][]byte, btn.numChildren)
+	for i, child := range btn.children[:btn.numChildren] {
+		if child.hash == nil {
+			return errors.New("node " + btn.String() + " has a cached hash but its child " + child.String() + " does not")
+		}
+		children[i] = child.hash
+	}
+	keys, values := btn.keysAndValues(bt.encodeValue)
+	if !bytes.Equal(btn.hash, hashNodeContents(keys, values, children)) {
+		return errors.New("node " + btn.String() + " has an out of date cached hash")
+	}
+	return nil
+}
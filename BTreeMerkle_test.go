@@ -0,0 +1,207 @@
+package GoTrees
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"sort"
+	sc "strconv"
+	"testing"
+)
+
+// checkRootHash fails if a cached hash in BT is out of date, by comparing RootHash to a hash computed from scratch
+func checkRootHash(t *testing.T, BT *BTree, name string) {
+	if err := BT.Verify(); err != nil {
+		t.Fatal("B-Tree failed verification after " + name + ": " + err.Error())
+	}
+	cached := BT.RootHash()
+	BT.SetValueEncoder(nil)
+	if !bytes.Equal(cached, BT.RootHash()) {
+		t.Fatal("Cached root hash was out of date after " + name)
+	}
+}
+
+func TestBTreeRootHash(t *testing.T) {
+	BT := NewBTree(T, nAlloc)
+	checkRootHash(t, &BT, "creating the tree")
+	for i := 0; i < nRAND*5; i++ {
+		key := rand.Intn(nRAND)
+		switch rand.Intn(5) {
+		case 0, 1:
+			BT.Insert(key, i)
+		case 2:
+			BT.Put(key, i)
+		case 3:
+			BT.Delete(key)
+		case 4:
+			BT.PutIfAbsent(key, i)
+		}
+		// hash every few changes so both fresh and cached nodes are changed
+		if i%3 == 0 {
+			checkRootHash(t, &BT, "change "+sc.Itoa(i))
+		}
+	}
+
+	BT.InsertBatch([]Entry{{5, nil}, {1, nil}, {99, nil}, {42, nil}, {42, nil}})
+	checkRootHash(t, &BT, "inserting a batch")
+	BT.DeleteRange(10, 20)
+	checkRootHash(t, &BT, "deleting a range")
+	left, right := BT.SplitAt(nRAND / 2)
+	checkRootHash(t, &left, "splitting")
+	checkRootHash(t, &right, "splitting")
+	left.Join(&right)
+	checkRootHash(t, &left, "joining")
+}
+
+func TestBTreeRootHashContent(t *testing.T) {
+	A := NewBTree(T, nAlloc)
+	B := NewBTree(T, nAlloc)
+	for i := 0; i < nRAND; i++ {
+		A.Insert(i, i)
+		B.Insert(i, i)
+	}
+	if !bytes.Equal(A.RootHash(), B.RootHash()) {
+		t.Fatal("Trees built the same way should have the same root hash. ")
+	}
+	B.Put(nRAND/2, "changed")
+	if bytes.Equal(A.RootHash(), B.RootHash()) {
+		t.Fatal("Changing a value should change the root hash. ")
+	}
+	B.Put(nRAND/2, nRAND/2)
+	if !bytes.Equal(A.RootHash(), B.RootHash()) {
+		t.Fatal("Changing the value back should restore the root hash. ")
+	}
+	B.Put(nRAND/2, "1")
+	A.Put(nRAND/2, 1)
+	if bytes.Equal(A.RootHash(), B.RootHash()) {
+		t.Fatal("Values of different types should not hash the same. ")
+	}
+}
+
+func TestBTreeProof(t *testing.T) {
+	BT := NewBTree(T, nAlloc)
+	for i := 0; i < nRAND; i++ {
+		BT.Put(rand.Intn(nRAND*10), i)
+	}
+	root := BT.RootHash()
+	for _, key := range BT.Keys() {
+		proof := BT.Proof(key)
+		if proof == nil {
+			t.Fatal("No proof for key " + sc.Itoa(key) + " which is in the tree. ")
+		}
+		if !proof.Verify(root) {
+			t.Fatal("Proof for key " + sc.Itoa(key) + " did not verify. ")
+		}
+		if !bytes.Equal(proof.Value, EncodeValue(*BT.Find(key))) {
+			t.Fatal("Proof for key " + sc.Itoa(key) + " holds the wrong value. ")
+		}
+		forged := *proof
+		forged.Value = EncodeValue("forged")
+		if forged.Verify(root) {
+			t.Fatal("Proof with a forged value verified for key " + sc.Itoa(key))
+		}
+	}
+	if BT.Proof(-1) != nil {
+		t.Fatal("Got a proof for a key that is not in the tree. ")
+	}
+
+	// a proof made before a change does not verify against the new root
+	key := BT.Keys()[0]
+	proof := BT.Proof(key)
+	BT.Put(key, "new value")
+	if proof.Verify(BT.RootHash()) {
+		t.Fatal("Old proof verified against the root hash of a changed tree. ")
+	}
+	if !BT.Proof(key).Verify(BT.RootHash()) {
+		t.Fatal("New proof did not verify after the change. ")
+	}
+}
+
+func TestBTreeDiff(t *testing.T) {
+	for run := 0; run < 10; run++ {
+		A := NewBTree(T, nAlloc)
+		B := NewBTree(T, nAlloc)
+		a := map[int]int{}
+		b := map[int]int{}
+		for i := 0; i < nRAND*2; i++ {
+			key := rand.Intn(nRAND * 4)
+			A.Put(key, i)
+			B.Put(key, i)
+			a[key], b[key] = i, i
+		}
+		// a few changes to B, so most subtrees stay the same
+		for i := 0; i < run*3; i++ {
+			key := rand.Intn(nRAND * 4)
+			if rand.Intn(2) == 0 {
+				B.Put(key, -i)
+				b[key] = -i
+			} else {
+				B.Delete(key)
+				delete(b, key)
+			}
+		}
+		if run%2 == 1 {
+			// the same contents in a differently shaped tree
+			B = B.Union(&B, nil)
+		}
+
+		expected := []Change{}
+		keys := []int{}
+		for key := range a {
+			keys = append(keys, key)
+		}
+		for key := range b {
+			if _, ok := a[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Ints(keys)
+		for _, key := range keys {
+			old, inA := a[key]
+			new, inB := b[key]
+			if !inB {
+				expected = append(expected, Change{Kind: Removed, Key: key, Old: old})
+			} else if !inA {
+				expected = append(expected, Change{Kind: Added, Key: key, New: new})
+			} else if old != new {
+				expected = append(expected, Change{Kind: Changed, Key: key, Old: old, New: new})
+			}
+		}
+
+		actual := A.Diff(&B)
+		if len(actual) != len(expected) {
+			t.Fatal("Diff returned " + sc.Itoa(len(actual)) + " changes but should have " + sc.Itoa(len(expected)))
+		}
+		for i := range actual {
+			if actual[i] != expected[i] {
+				t.Fatal("Diff change " + sc.Itoa(i) + " was " + actual[i].Kind.String() + " " + sc.Itoa(actual[i].Key) + " but should be " + expected[i].Kind.String() + " " + sc.Itoa(expected[i].Key))
+			}
+		}
+		if len(A.Diff(&A)) != 0 {
+			t.Fatal("A tree should have no changes from itself. ")
+		}
+	}
+}
+
+func TestBTreeDiffEquality(t *testing.T) {
+	// every value encodes differently, so only the value comparison can tell which are equal
+	pointer := func(value interface{}) []byte {
+		return []byte(fmt.Sprintf("%p", value))
+	}
+	A, B := NewBTree(T, nAlloc), NewBTree(T, nAlloc)
+	A.SetValueEncoder(pointer)
+	B.SetValueEncoder(pointer)
+	for i := 0; i < nRAND; i++ {
+		A.Insert(i, []int{i})
+		B.Insert(i, []int{i + i%2})
+	}
+	actual, expected := A.Diff(&B), NewPatch(&A, &B)
+	if len(actual) != nRAND/2 || len(actual) != len(expected) {
+		t.Fatal("B-Tree Diff returned " + sc.Itoa(len(actual)) + " changes but the package Diff returned " + sc.Itoa(len(expected)))
+	}
+	for i := range actual {
+		if actual[i].Kind != expected[i].Kind || actual[i].Key != expected[i].Key {
+			t.Fatal("B-Tree Diff change " + sc.Itoa(i) + " was " + actual[i].String() + " but the package Diff has " + expected[i].String())
+		}
+	}
+}
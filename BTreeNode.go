@@ -8,40 +8,36 @@ type bTreeNode struct {
 	length      int
 	children    []*bTreeNode
 	numChildren int
+	// count is the number of key-values in the subtree, so a tree cut into pieces knows the size of each piece without walking it
+	count uint64
+	// hash is the cached merkle hash of the subtree, nil when it has to be computed again. Every method that changes the node clears it.
+	hash []byte
 }
 
+// newbTreeNode creates an empty node. alloc is only reserved as capacity so the lists stay in step with length and numChildren
 func newbTreeNode(alloc int) bTreeNode {
-	return bTreeNode{nodes: make([]*keyValue, alloc), length: 0, children: make([]*bTreeNode, alloc), numChildren: 0}
+	return bTreeNode{nodes: make([]*keyValue, 0, alloc), length: 0, children: make([]*bTreeNode, 0, alloc+1), numChildren: 0}
 }
 
-// AddToList adds a node to the nodes list, it does not do any b-tree insert logic
+// AddToList adds a node to the nodes list, it does not do any b-tree insert logic. A duplicate key is added after the existing equal keys so equal keys stay in insertion order.
 func (btn *bTreeNode) AddToList(n *keyValue) {
-	min := 0
-	max := btn.length
-	midPoint := (min + max) / 2
-
-	for min < max {
-		if btn.nodes[midPoint].key > n.key {
-			max = midPoint
-		} else if btn.nodes[midPoint].key < n.key {
-			min = midPoint + 1
-		} else {
-			// this means node is duplicate key
-			break
-		}
-		midPoint = (min + max) / 2
-	}
+	btn.InsertToListAt(n, btn.UpperBound(n.key))
+}
 
-	if btn.length <= midPoint || btn.length == 0 {
+// InsertToListAt adds a node to the nodes list at index, shifting the nodes after it to the right. It does not check the key order.
+func (btn *bTreeNode) InsertToListAt(n *keyValue, index int) {
+	btn.hash = nil
+	if btn.length <= index {
 		btn.nodes = append(btn.nodes, n)
 	} else {
-		btn.nodes = append(btn.nodes[:midPoint+1], btn.nodes[midPoint:]...)
-		btn.nodes[midPoint] = n
+		btn.nodes = append(btn.nodes[:index+1], btn.nodes[index:]...)
+		btn.nodes[index] = n
 	}
 	btn.length++
 }
 
 func (btn *bTreeNode) MergeRightSilbing(right *bTreeNode) {
+	btn.hash = nil
 	btn.nodes = append(btn.nodes, right.nodes...)
 	btn.length += right.length
 	btn.children = append(btn.children, right.children...)
@@ -50,6 +46,7 @@ func (btn *bTreeNode) MergeRightSilbing(right *bTreeNode) {
 
 // RemoveFromList removes an element from the nodes list, it does not do any b-tree delete logic
 func (btn *bTreeNode) RemoveFromList(key int) {
+	btn.hash = nil
 	_, i := btn.Search(key)
 	if i >= 0 {
 		if i >= btn.length {
@@ -63,11 +60,13 @@ func (btn *bTreeNode) RemoveFromList(key int) {
 }
 
 func (btn *bTreeNode) RemoveFromListAt(index int) {
+	btn.hash = nil
 	btn.length--
 	btn.nodes = append(btn.nodes[:index], btn.nodes[index+1:]...)
 }
 
 func (btn *bTreeNode) ReplaceFromListAt(new *keyValue, index int) {
+	btn.hash = nil
 	btn.nodes[index] = new
 }
 
@@ -91,29 +90,72 @@ func (btn *bTreeNode) Search(key int) (*keyValue, int) {
 	return nil, midPoint
 }
 
-// SplitInTwo splits a node into two subnodes, and takes the middle out
+// LowerBound returns the index of the first node with a key greater than or equal to key (length if there is none)
+func (btn *bTreeNode) LowerBound(key int) int {
+	min := 0
+	max := btn.length
+
+	for min < max {
+		midPoint := (min + max) / 2
+		if btn.nodes[midPoint].key < key {
+			min = midPoint + 1
+		} else {
+			max = midPoint
+		}
+	}
+	return min
+}
+
+// UpperBound returns the index of the first node with a key greater than key (length if there is none)
+func (btn *bTreeNode) UpperBound(key int) int {
+	min := 0
+	max := btn.length
+
+	for min < max {
+		midPoint := (min + max) / 2
+		if btn.nodes[midPoint].key <= key {
+			min = midPoint + 1
+		} else {
+			max = midPoint
+		}
+	}
+	return min
+}
+
+// SplitInTwo splits a node into two subnodes, and takes the middle out. A node with an even length (possible after merges) gives the extra key-value to the right node.
 func (btn *bTreeNode) SplitInTwo(alloc int) (*keyValue, *bTreeNode, *bTreeNode) {
 	mid := btn.length / 2
+	rightLength := btn.length - mid - 1
 	var left *bTreeNode = nil
 	var right *bTreeNode = nil
 
-	nodeAlloc := max(alloc, btn.length/2)
-	childAlloc := max(alloc, btn.length/2+1)
+	nodeAlloc := max(alloc, rightLength)
+	childAlloc := max(alloc, rightLength+1)
 
 	if btn.numChildren > 0 {
-		left = &bTreeNode{nodes: btn.nodes[:mid], length: btn.length / 2, children: btn.children[:mid+1], numChildren: btn.numChildren / 2}
-		right = &bTreeNode{nodes: make([]*keyValue, nodeAlloc), length: btn.length / 2, children: make([]*bTreeNode, childAlloc), numChildren: btn.numChildren / 2}
-		copy(right.children, btn.children[mid+1:])
+		left = &bTreeNode{nodes: btn.nodes[:mid], length: mid, children: btn.children[:mid+1], numChildren: mid + 1}
+		right = &bTreeNode{nodes: make([]*keyValue, 0, nodeAlloc), length: rightLength, children: make([]*bTreeNode, 0, childAlloc), numChildren: rightLength + 1}
+		right.children = append(right.children, btn.children[mid+1:]...)
 	} else {
 		// splitting a leaf, these nodes will need new child lists allocated
-		left = &bTreeNode{nodes: btn.nodes[:mid], length: btn.length / 2, children: make([]*bTreeNode, btn.length/2+1), numChildren: 0}
-		right = &bTreeNode{nodes: make([]*keyValue, nodeAlloc), length: btn.length / 2, children: make([]*bTreeNode, alloc+1), numChildren: 0}
+		left = &bTreeNode{nodes: btn.nodes[:mid], length: mid, children: make([]*bTreeNode, btn.length/2+1), numChildren: 0}
+		right = &bTreeNode{nodes: make([]*keyValue, 0, nodeAlloc), length: rightLength, children: make([]*bTreeNode, alloc+1), numChildren: 0}
 	}
 	// Only copy the right hand nodes, the left memory can be recycled
-	copy(right.nodes, btn.nodes[mid+1:])
+	right.nodes = append(right.nodes, btn.nodes[mid+1:]...)
+	left.recount()
+	right.recount()
 	return btn.nodes[mid], left, right
 }
 
+// recount sets the count of the node from its own length and the counts of its children
+func (btn *bTreeNode) recount() {
+	btn.count = uint64(btn.length)
+	for _, child := range btn.children[:btn.numChildren] {
+		btn.count += child.count
+	}
+}
+
 func max(a, b int) int {
 	if a > b {
 		return a
@@ -122,21 +164,32 @@ func max(a, b int) int {
 	}
 }
 
+func min(a, b int) int {
+	if a < b {
+		return a
+	} else {
+		return b
+	}
+}
+
 // AddChild adds a child to the end list
 func (btn *bTreeNode) AddChild(other *bTreeNode) {
+	btn.hash = nil
 	btn.children = append(btn.children, other)
 	btn.numChildren++
 }
 
 // PrependChild adds a child to the front list
 func (btn *bTreeNode) PrependChild(other *bTreeNode) {
-	btn.children = append(btn.children[1:], btn.children...)
+	btn.hash = nil
+	btn.children = append(btn.children[:1], btn.children...)
 	btn.children[0] = other
 	btn.numChildren++
 }
 
 // DeleteChild removes a child at index from the child list
 func (btn *bTreeNode) DeleteChild(index int) {
+	btn.hash = nil
 	if index >= 0 {
 		btn.numChildren--
 		btn.children = append(btn.children[:index], btn.children[index+1:]...)
@@ -145,6 +198,7 @@ func (btn *bTreeNode) DeleteChild(index int) {
 
 // InsertTwoChildren adds 2 children to the child list, overwriting the child at index
 func (btn *bTreeNode) InsertTwoChildren(left *bTreeNode, right *bTreeNode, index int) {
+	btn.hash = nil
 	// making room for children nodes
 	btn.children = append(btn.children, nil)
 	// shift over current children
@@ -116,3 +116,24 @@ func TestBTreeNodeSplitInTwo(t *testing.T) {
 		}
 	}
 }
+
+func TestBTreeNodeSplitInTwoEven(t *testing.T) {
+	btn := newbTreeNode(nAlloc * T)
+	keys := []int{1, 2, 3, 4, 5, 6}
+
+	for _, key := range keys {
+		btn.AddToList(newKeyValue(key, key))
+	}
+
+	mid, left, right := btn.SplitInTwo(nAlloc * T)
+
+	if mid.key != 4 {
+		t.Error("Mid key-value is not correct, expected 4 but got " + strconv.Itoa(mid.key))
+	}
+	if left.length != 3 || len(left.nodes) != 3 {
+		t.Error("Left length incorrect, expected 3 but got " + strconv.Itoa(left.length) + " with " + strconv.Itoa(len(left.nodes)) + " nodes")
+	}
+	if right.length != 2 || len(right.nodes) != 2 {
+		t.Error("Right length incorrect, expected 2 but got " + strconv.Itoa(right.length) + " with " + strconv.Itoa(len(right.nodes)) + " nodes")
+	}
+}
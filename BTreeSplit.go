@@ -0,0 +1,246 @@
+package GoTrees
+
+import "math"
+
+// SplitAt cuts the B-Tree in two by key. left will hold every key smaller than key and right will hold the rest. Both trees have the same parameters as bt, and bt is left empty since its nodes are reused.
+// The nodes along the search path are cut and the pieces are stitched back together, so SplitAt takes O(log n). Each node counts the key-values below it, so the sizes of the halves are read from their roots.
+// Expiry times and the clock move with their entries to left and right.
+func (bt *BTree) SplitAt(key int) (left BTree, right BTree) {
+	left, right = bt.cut(key)
+	left.size = left.root.count
+	right.size = bt.size - left.size
+	if bt.expiry != nil {
+		bt.expiry.splitInto(&left, &right, key)
+	}
+	bt.Clear()
+	return left, right
+}
+
+// Join appends every key-value in right to bt in O(log n). Every key in bt must be smaller than or equal to every key in right, otherwise Join returns false and neither tree is changed.
+// right is left empty since its nodes are reused, and its expiry times move to bt.
+func (bt *BTree) Join(right *BTree) bool {
+	if bt.root.length > 0 && right.root.length > 0 && maxKeyValue(bt.root).key > minKeyValue(right.root).key {
+		return false
+	}
+	size := bt.size + right.size
+	bt.root = bt.joinRoots(right)
+	bt.size = size
+	if right.expiry != nil {
+		right.expiry.moveTo(bt)
+	}
+	right.Clear()
+	return true
+}
+
+// DeleteRange will delete every key-value with lo <= key <= hi and return how many were deleted.
+// Instead of deleting one key at a time, the tree is cut at both ends of the range, the subtrees in between are dropped whole and the two outer pieces are joined back together.
+// This rebalances only the nodes along the two edge paths, and the number deleted is read from the counts of the outer pieces, so the dropped subtrees are never visited.
+func (bt *BTree) DeleteRange(lo, hi int) int {
+	if lo > hi || bt.root.length == 0 {
+		return 0
+	}
+	left, middle := bt.cut(lo)
+	right := BTree{t: bt.t, initAlloc: bt.initAlloc, encode: bt.encode}
+	if hi < math.MaxInt {
+		middle, right = middle.cut(hi + 1)
+	} else {
+		right.Clear()
+	}
+	deleted := bt.size - left.root.count - right.root.count
+	if bt.expiry != nil {
+		ascendRangeNode(middle.root, lo, hi, func(kv *keyValue) bool {
+			bt.forgetExpiry(kv)
+			return true
+		})
+	}
+	bt.root = left.joinRoots(&right)
+	bt.size -= deleted
+	return int(deleted)
+}
+
+// cut splits the nodes of bt by key into two new trees without setting their sizes. bt must be cleared afterwards since its nodes are reused.
+func (bt *BTree) cut(key int) (BTree, BTree) {
+	left := BTree{t: bt.t, initAlloc: bt.initAlloc, encode: bt.encode}
+	right := BTree{t: bt.t, initAlloc: bt.initAlloc, encode: bt.encode}
+	if bt.root != nil && bt.root.length > 0 {
+		left.root, _, right.root, _ = bt.splitNode(bt.root, spineHeight(bt.root), key)
+	}
+	if left.root == nil {
+		left.Clear()
+	}
+	if right.root == nil {
+		right.Clear()
+	}
+	return left, right
+}
+
+// joinRoots stitches the nodes of right onto the nodes of bt and returns the new root. The smallest key-value of right becomes the separator between the two trees. Sizes are not changed.
+func (bt *BTree) joinRoots(right *BTree) *bTreeNode {
+	if right.root.length == 0 {
+		return bt.root
+	}
+	if bt.root.length == 0 {
+		return right.root
+	}
+	sep := right.findAndDeleteIOS(right.root)
+	rightHeight := 0
+	if right.root.length > 0 {
+		rightHeight = spineHeight(right.root)
+	}
+	root, _ := bt.join(bt.root, spineHeight(bt.root), sep, right.root, rightHeight)
+	return root
+}
+
+// splitNode splits the subtree of n (which has height h) by key. It returns the roots and heights of the two halves, an empty half is nil with height 0.
+func (bt *BTree) splitNode(n *bTreeNode, h int, key int) (*bTreeNode, int, *bTreeNode, int) {
+	i := n.LowerBound(key)
+	if n.numChildren == 0 {
+		var left, right *bTreeNode = nil, nil
+		leftHeight, rightHeight := 0, 0
+		if i > 0 {
+			left, leftHeight = bt.nodeFrom(n.nodes[:i], nil), 1
+		}
+		if i < n.length {
+			right, rightHeight = bt.nodeFrom(n.nodes[i:], nil), 1
+		}
+		return left, leftHeight, right, rightHeight
+	}
+
+	// only the child at i straddles key, everything else in n goes wholly to one side
+	childLeft, childLeftHeight, childRight, childRightHeight := bt.splitNode(n.children[i], h-1, key)
+	left, leftHeight := childLeft, childLeftHeight
+	if i > 0 {
+		// the keys before the separator at i-1 keep their children and are joined to the left half of the child
+		piece, pieceHeight := n.children[0], h-1
+		if i > 1 {
+			piece, pieceHeight = bt.nodeFrom(n.nodes[:i-1], n.children[:i]), h
+		}
+		left, leftHeight = bt.join(piece, pieceHeight, n.nodes[i-1], childLeft, childLeftHeight)
+	}
+	right, rightHeight := childRight, childRightHeight
+	if i < n.length {
+		// the keys after the separator at i keep their children and are joined to the right half of the child
+		piece, pieceHeight := n.children[n.length], h-1
+		if i < n.length-1 {
+			piece, pieceHeight = bt.nodeFrom(n.nodes[i+1:], n.children[i+1:n.numChildren]), h
+		}
+		right, rightHeight = bt.join(childRight, childRightHeight, n.nodes[i], piece, pieceHeight)
+	}
+	return left, leftHeight, right, rightHeight
+}
+
+// join stitches two subtrees together with sep between them, every key in left must be <= sep.key <= every key in right.
+// The shorter tree is hung off the spine of the taller tree at the level where the heights match, so this takes O(difference in height). It returns the new root and its height.
+func (bt *BTree) join(left *bTreeNode, leftHeight int, sep *keyValue, right *bTreeNode, rightHeight int) (*bTreeNode, int) {
+	if leftHeight == 0 && rightHeight == 0 {
+		return bt.nodeFrom([]*keyValue{sep}, nil), 1
+	}
+	if leftHeight == rightHeight {
+		if left.length+right.length+1 <= int(bt.t)+1 {
+			// the two roots fit into one node
+			nodes := append(append(append([]*keyValue{}, left.nodes...), sep), right.nodes...)
+			return bt.nodeFrom(nodes, append(append([]*bTreeNode{}, left.children[:left.numChildren]...), right.children[:right.numChildren]...)), leftHeight
+		}
+		return bt.nodeFrom([]*keyValue{sep}, []*bTreeNode{left, right}), leftHeight + 1
+	}
+
+	// path and indexes record the spine walked down so overfull nodes can be split on the way back up
+	path := []*bTreeNode{}
+	indexes := []int{}
+	if leftHeight > rightHeight {
+		curr := left
+		path = append(path, curr)
+		// stop at the node whose children are as tall as right (or at the leaf if right is empty)
+		for h := leftHeight; h > rightHeight+1 && curr.numChildren > 0; h-- {
+			indexes = append(indexes, curr.numChildren-1)
+			curr = curr.children[curr.numChildren-1]
+			path = append(path, curr)
+		}
+		for _, n := range path {
+			n.hash = nil
+			n.count += 1 + subtreeCount(right)
+		}
+		curr.InsertToListAt(sep, curr.length)
+		if rightHeight > 0 {
+			curr.AddChild(right)
+		}
+		return bt.splitOverfull(path, indexes, leftHeight)
+	}
+	curr := right
+	path = append(path, curr)
+	for h := rightHeight; h > leftHeight+1 && curr.numChildren > 0; h-- {
+		indexes = append(indexes, 0)
+		curr = curr.children[0]
+		path = append(path, curr)
+	}
+	for _, n := range path {
+		n.hash = nil
+		n.count += 1 + subtreeCount(left)
+	}
+	curr.InsertToListAt(sep, 0)
+	if leftHeight > 0 {
+		curr.PrependChild(left)
+	}
+	return bt.splitOverfull(path, indexes, rightHeight)
+}
+
+// splitOverfull walks back up path splitting any node that holds more than t+1 keys. indexes[i] is the index of path[i+1] in the children of path[i].
+func (bt *BTree) splitOverfull(path []*bTreeNode, indexes []int, height int) (*bTreeNode, int) {
+	for i := len(path) - 1; i >= 0; i-- {
+		if path[i].length <= int(bt.t)+1 {
+			break
+		}
+		mid, left, right := path[i].SplitInTwo(bt.initAlloc)
+		if i == 0 {
+			return bt.nodeFrom([]*keyValue{mid}, []*bTreeNode{left, right}), height + 1
+		}
+		path[i-1].InsertToListAt(mid, indexes[i-1])
+		path[i-1].InsertTwoChildren(left, right, indexes[i-1])
+	}
+	return path[0], height
+}
+
+// nodeFrom creates a node holding copies of the key-value and child lists
+func (bt *BTree) nodeFrom(nodes []*keyValue, children []*bTreeNode) *bTreeNode {
+	btn := newbTreeNode(max(bt.initAlloc, len(nodes)))
+	btn.nodes = append(btn.nodes, nodes...)
+	btn.length = len(nodes)
+	btn.children = append(btn.children, children...)
+	btn.numChildren = len(children)
+	btn.recount()
+	return &btn
+}
+
+// spineHeight counts the levels of the subtree of btn by following its leftmost children
+func spineHeight(btn *bTreeNode) int {
+	height := 1
+	for btn.numChildren > 0 {
+		btn = btn.children[0]
+		height++
+	}
+	return height
+}
+
+// subtreeCount returns the number of key-values in the subtree of btn, 0 for an empty half
+func subtreeCount(btn *bTreeNode) uint64 {
+	if btn == nil {
+		return 0
+	}
+	return btn.count
+}
+
+// minKeyValue returns the leftmost key-value in the subtree of btn
+func minKeyValue(btn *bTreeNode) *keyValue {
+	for btn.numChildren > 0 {
+		btn = btn.children[0]
+	}
+	return btn.nodes[0]
+}
+
+// maxKeyValue returns the rightmost key-value in the subtree of btn
+func maxKeyValue(btn *bTreeNode) *keyValue {
+	for btn.numChildren > 0 {
+		btn = btn.children[btn.numChildren-1]
+	}
+	return btn.nodes[btn.length-1]
+}
@@ -0,0 +1,146 @@
+package GoTrees
+
+import (
+	"math"
+	"math/rand"
+	sc "strconv"
+	"testing"
+)
+
+func TestBTreeSplitAt(t *testing.T) {
+	for _, tParam := range []uint{0, 1, 3} {
+		for trial := 0; trial < nRAND; trial++ {
+			BT := NewBTree(tParam, nAlloc)
+			n := rand.Intn(nRAND * 3)
+			for i := 0; i < n; i++ {
+				// nRAND - 1 to ensure at least one duplicate key
+				BT.Insert(rand.Intn(nRAND-1), i)
+			}
+			keys := BT.Keys()
+			splitKey := rand.Intn(nRAND+2) - 1
+
+			left, right := BT.SplitAt(splitKey)
+			if err := left.Verify(); err != nil {
+				t.Fatal("Left half failed verification after splitting at " + sc.Itoa(splitKey) + ": " + err.Error())
+			}
+			if err := right.Verify(); err != nil {
+				t.Fatal("Right half failed verification after splitting at " + sc.Itoa(splitKey) + ": " + err.Error())
+			}
+			if BT.Size() != 0 || len(BT.Keys()) != 0 {
+				t.Fatal("SplitAt did not empty the receiver. ")
+			}
+			split := 0
+			for split < len(keys) && keys[split] < splitKey {
+				split++
+			}
+			checkKeys(t, "Left half", left.Keys(), keys[:split])
+			checkKeys(t, "Right half", right.Keys(), keys[split:])
+
+			if !left.Join(&right) {
+				t.Fatal("Join of two halves returned false. ")
+			}
+			if err := left.Verify(); err != nil {
+				t.Fatal("Joined tree failed verification: " + err.Error())
+			}
+			checkKeys(t, "Joined tree", left.Keys(), keys)
+			if right.Size() != 0 {
+				t.Fatal("Join did not empty the right tree. ")
+			}
+		}
+	}
+}
+
+func TestBTreeJoin(t *testing.T) {
+	left, right := NewBTree(T, nAlloc), NewBTree(T, nAlloc)
+	// trees of very different heights must still join at the right level
+	for i := 0; i < nRAND; i++ {
+		left.Insert(i, i)
+	}
+	right.Insert(nRAND, nRAND)
+	right.Insert(nRAND+1, nRAND+1)
+
+	if !left.Join(&right) {
+		t.Fatal("Join returned false for ordered trees. ")
+	}
+	if err := left.Verify(); err != nil {
+		t.Fatal("Joined tree failed verification: " + err.Error())
+	}
+	if left.Size() != nRAND+2 || !left.Contains(nRAND+1) {
+		t.Fatal("Joined tree is missing keys. ")
+	}
+
+	small := NewBTree(T, nAlloc)
+	small.Insert(-1, nil)
+	if !small.Join(&left) || small.Size() != nRAND+3 {
+		t.Fatal("Joining a short tree to a tall tree failed. ")
+	}
+	if err := small.Verify(); err != nil {
+		t.Fatal("Joined tree failed verification: " + err.Error())
+	}
+	for _, k := range rand.Perm(nRAND) {
+		small.Delete(k)
+	}
+	if err := small.Verify(); err != nil || small.Size() != 3 {
+		t.Fatal("Joined tree did not support deletes. ")
+	}
+
+	overlap := NewBTree(T, nAlloc)
+	overlap.Insert(0, nil)
+	if small.Join(&overlap) {
+		t.Fatal("Join returned true for overlapping trees. ")
+	}
+	if small.Size() != 3 || overlap.Size() != 1 {
+		t.Fatal("A failed Join changed the trees. ")
+	}
+}
+
+func TestBTreeDeleteRange(t *testing.T) {
+	for _, tParam := range []uint{0, 1, 3} {
+		for trial := 0; trial < nRAND; trial++ {
+			BT := NewBTree(tParam, nAlloc)
+			n := rand.Intn(nRAND * 3)
+			for i := 0; i < n; i++ {
+				// nRAND - 1 to ensure at least one duplicate key
+				BT.Insert(rand.Intn(nRAND-1), i)
+			}
+			lo := rand.Intn(nRAND+2) - 1
+			hi := lo + rand.Intn(nRAND/2)
+			expected := []int{}
+			for _, k := range BT.Keys() {
+				if k < lo || k > hi {
+					expected = append(expected, k)
+				}
+			}
+
+			deleted := BT.DeleteRange(lo, hi)
+			if err := BT.Verify(); err != nil {
+				t.Fatal("Tree failed verification after deleting [" + sc.Itoa(lo) + ", " + sc.Itoa(hi) + "]: " + err.Error())
+			}
+			if deleted != n-len(expected) || BT.Size() != uint64(len(expected)) {
+				t.Fatal("DeleteRange deleted " + sc.Itoa(deleted) + " keys but expected " + sc.Itoa(n-len(expected)))
+			}
+			checkKeys(t, "DeleteRange", BT.Keys(), expected)
+		}
+	}
+}
+
+func TestBTreeDeleteRangeEdges(t *testing.T) {
+	BT := NewBTree(T, nAlloc)
+	for i := 0; i < nRAND; i++ {
+		BT.Insert(i, i)
+	}
+
+	if BT.DeleteRange(10, 5) != 0 || BT.DeleteRange(nRAND, nRAND*2) != 0 || BT.Size() != nRAND {
+		t.Fatal("DeleteRange changed the tree for an empty range. ")
+	}
+	if BT.DeleteRange(nRAND-10, math.MaxInt) != 10 || BT.Size() != nRAND-10 {
+		t.Fatal("DeleteRange up to MaxInt deleted the wrong number of keys. ")
+	}
+	if BT.DeleteRange(math.MinInt, math.MaxInt) != nRAND-10 || BT.Size() != 0 {
+		t.Fatal("DeleteRange of every key did not empty the tree. ")
+	}
+	BT.Insert(1, 1)
+	if err := BT.Verify(); err != nil || !BT.Contains(1) {
+		t.Fatal("Tree could not be used after deleting every key. ")
+	}
+}
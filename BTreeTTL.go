@@ -0,0 +1,308 @@
+package GoTrees
+
+import (
+	"container/heap"
+	"errors"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// bTreeExpiry keeps the expiry time of every entry inserted with a TTL, in unix nanoseconds. queue orders the entries by expiry time so a sweep only looks at entries that are due,
+// and handles finds the queue entry of a key-value when it is looked up or deleted.
+type bTreeExpiry struct {
+	now     func() time.Time
+	queue   expiryQueue
+	handles map[*keyValue]*expiryEntry
+}
+
+// expiryEntry is a key-value in the expiry queue. expires is int64 rather than int like the heap keys, so the times fit on 32 bit platforms too
+type expiryEntry struct {
+	kv      *keyValue
+	expires int64
+	// index is the position in the queue, kept up to date by Swap
+	index int
+}
+
+// expiryQueue is a min heap of expiry times for container/heap
+type expiryQueue []*expiryEntry
+
+func (q expiryQueue) Len() int {
+	return len(q)
+}
+
+func (q expiryQueue) Less(i, j int) bool {
+	return q[i].expires < q[j].expires
+}
+
+func (q expiryQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *expiryQueue) Push(x interface{}) {
+	entry := x.(*expiryEntry)
+	entry.index = len(*q)
+	*q = append(*q, entry)
+}
+
+func (q *expiryQueue) Pop() interface{} {
+	old := *q
+	entry := old[len(old)-1]
+	old[len(old)-1] = nil
+	*q = old[:len(old)-1]
+	entry.index = -1
+	return entry
+}
+
+func newBTreeExpiry(now func() time.Time) *bTreeExpiry {
+	return &bTreeExpiry{now: now, queue: expiryQueue{}, handles: map[*keyValue]*expiryEntry{}}
+}
+
+// expiryState returns the expiry state of bt, creating it with the real clock the first time
+func (bt *BTree) expiryState() *bTreeExpiry {
+	if bt.expiry == nil {
+		bt.expiry = newBTreeExpiry(time.Now)
+	}
+	return bt.expiry
+}
+
+// SetClock makes the B-Tree read the current time from now instead of time.Now, so tests can move time forward without waiting. nil restores time.Now.
+func (bt *BTree) SetClock(now func() time.Time) {
+	if now == nil {
+		now = time.Now
+	}
+	bt.expiryState().now = now
+}
+
+// InsertWithTTL inserts key like Insert, but the entry expires once ttl has passed. An expired entry is deleted the next time Find, Put or another update reaches it, or by Sweep,
+// so those calls change the tree and need the same lock as Insert. Put and the other updates change the value of a live entry but keep its expiry time.
+// FindAll, Keys, Range and the other reads still see expired entries until they are deleted. The trees returned by Union and the other set operations do not keep expiry times,
+// but UnionWith and the other in-place set operations keep the expiry time of each entry they keep.
+func (bt *BTree) InsertWithTTL(key int, value interface{}, ttl time.Duration) {
+	kv := newKeyValue(key, value)
+	bt.insertKeyValue(kv)
+	e := bt.expiryState()
+	now := e.nowNano()
+	// a TTL too long to add to now never expires
+	expires := int64(math.MaxInt64)
+	if now < 0 || int64(ttl) < math.MaxInt64-now {
+		expires = now + int64(ttl)
+	}
+	e.add(kv, expires)
+}
+
+// Sweep deletes every entry whose TTL has run out and returns how many were deleted. Entries are taken from the expiry queue in order of expiry time,
+// so a sweep takes O(log n) for each expired entry and never visits entries that are still live.
+func (bt *BTree) Sweep() int {
+	if bt.expiry == nil {
+		return 0
+	}
+	now := bt.expiry.nowNano()
+	deleted := 0
+	for len(bt.expiry.queue) > 0 && bt.expiry.queue[0].expires <= now {
+		// expireKey deletes the first entry along with any other expired occurrences of its key
+		deleted += bt.expireKey(bt.expiry.queue[0].kv.key, now)
+	}
+	return deleted
+}
+
+// StartSweeper starts a goroutine that calls Sweep each time tick fires, e.g. with the channel of a time.Ticker, until stop is called.
+// The B-Tree is not safe for concurrent use, so each sweep holds lock, which must be the lock that guards every other use of bt. With a sync.RWMutex that includes Find and Contains,
+// which delete expired entries and so need the write lock, not RLock. stop waits for a sweep in progress to finish, so it must not be called while holding lock.
+func (bt *BTree) StartSweeper(tick <-chan time.Time, lock sync.Locker) (stop func()) {
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		for {
+			select {
+			case <-done:
+				return
+			case <-tick:
+				lock.Lock()
+				bt.Sweep()
+				lock.Unlock()
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+		<-finished
+	}
+}
+
+// expireFound deletes the expired occurrences of key if the one find reaches has expired, so Find and the updates never see an expired value
+func (bt *BTree) expireFound(key int) {
+	if bt.expiry == nil {
+		return
+	}
+	if res := bt.find(key); res != nil {
+		if now := bt.expiry.nowNano(); bt.expiry.expired(res, now) {
+			bt.expireKey(key, now)
+		}
+	}
+}
+
+// expireKey deletes every occurrence of key that has expired by now and returns how many were deleted.
+// Delete cannot choose which duplicate it removes, so any live occurrences of key are deleted with the rest and inserted again in the same order with the same expiry times.
+func (bt *BTree) expireKey(key int, now int64) int {
+	kvs := []*keyValue{}
+	expired := 0
+	bt.ascendRange(key, key, func(kv *keyValue) bool {
+		kvs = append(kvs, kv)
+		if bt.expiry.expired(kv, now) {
+			expired++
+		}
+		return true
+	})
+	if expired == 0 {
+		return 0
+	}
+	// Delete forgets the expiry times, so they are read first. A nil handle is an occurrence without a TTL
+	handles := make([]*expiryEntry, len(kvs))
+	for i, kv := range kvs {
+		handles[i] = bt.expiry.handles[kv]
+	}
+	bt.DeleteAll(key)
+	for i, kv := range kvs {
+		if handles[i] == nil || handles[i].expires > now {
+			bt.insertKeyValue(kv)
+			if handles[i] != nil {
+				bt.expiry.add(kv, handles[i].expires)
+			}
+		}
+	}
+	return expired
+}
+
+// keepExpiry gives result, made by a set operation of bt and other, the clock of bt and the expiry times of the entries it was made from.
+// The set operations use the first occurrence of each key, and a key in both trees takes its expiry time from bt like its value.
+func (bt *BTree) keepExpiry(result *BTree, other *BTree) {
+	if bt.expiry == nil && other.expiry == nil {
+		return
+	}
+	if bt.expiry != nil {
+		result.expiry = newBTreeExpiry(bt.expiry.now)
+	} else {
+		result.expiry = newBTreeExpiry(other.expiry.now)
+	}
+	for key, expires := range firstExpiries(bt) {
+		if kv := result.find(key); kv != nil {
+			result.expiry.add(kv, expires)
+		}
+	}
+	for key, expires := range firstExpiries(other) {
+		if kv := result.find(key); kv != nil && bt.find(key) == nil {
+			result.expiry.add(kv, expires)
+		}
+	}
+}
+
+// firstExpiries returns the expiry time of each key whose first occurrence has one
+func firstExpiries(bt *BTree) map[int]int64 {
+	firsts := map[int]int64{}
+	if bt.expiry == nil {
+		return firsts
+	}
+	for kv, handle := range bt.expiry.handles {
+		first := false
+		bt.ascendRange(kv.key, kv.key, func(other *keyValue) bool {
+			first = other == kv
+			return false
+		})
+		if first {
+			firsts[kv.key] = handle.expires
+		}
+	}
+	return firsts
+}
+
+// forgetExpiry drops the expiry time of kv after it has been deleted from bt
+func (bt *BTree) forgetExpiry(kv *keyValue) {
+	if bt.expiry == nil {
+		return
+	}
+	if handle, ok := bt.expiry.handles[kv]; ok {
+		heap.Remove(&bt.expiry.queue, handle.index)
+		delete(bt.expiry.handles, kv)
+	}
+}
+
+func (e *bTreeExpiry) nowNano() int64 {
+	return e.now().UnixNano()
+}
+
+func (e *bTreeExpiry) add(kv *keyValue, expires int64) {
+	entry := &expiryEntry{kv: kv, expires: expires}
+	heap.Push(&e.queue, entry)
+	e.handles[kv] = entry
+}
+
+// expired reports whether kv was inserted with a TTL that ran out at or before now
+func (e *bTreeExpiry) expired(kv *keyValue, now int64) bool {
+	handle, ok := e.handles[kv]
+	return ok && handle.expires <= now
+}
+
+func (e *bTreeExpiry) clear() {
+	e.queue = expiryQueue{}
+	e.handles = map[*keyValue]*expiryEntry{}
+}
+
+// splitInto gives left the expiry times of the entries smaller than key and right the rest, after bt has been cut at key. Both use the clock of e.
+func (e *bTreeExpiry) splitInto(left, right *BTree, key int) {
+	left.expiry, right.expiry = newBTreeExpiry(e.now), newBTreeExpiry(e.now)
+	for kv, handle := range e.handles {
+		if kv.key < key {
+			left.expiry.add(kv, handle.expires)
+		} else {
+			right.expiry.add(kv, handle.expires)
+		}
+	}
+}
+
+// moveTo adds the expiry times of e to bt after its entries have been joined onto bt. bt keeps its own clock if it has one.
+func (e *bTreeExpiry) moveTo(bt *BTree) {
+	if bt.expiry == nil {
+		bt.expiry = newBTreeExpiry(e.now)
+	}
+	for kv, handle := range e.handles {
+		bt.expiry.add(kv, handle.expires)
+	}
+}
+
+// verifyExpiry checks that the expiry queue is a valid heap holding one entry for each expiry time, and that every entry with an expiry time is in the B-Tree.
+func (bt *BTree) verifyExpiry() error {
+	if bt.expiry == nil {
+		return nil
+	}
+	for i, entry := range bt.expiry.queue {
+		if entry.index != i {
+			return errors.New("expiry of key " + strconv.Itoa(entry.kv.key) + " is at " + strconv.Itoa(i) + " in the expiry queue but has index " + strconv.Itoa(entry.index))
+		}
+		if parent := (i - 1) / 2; i > 0 && entry.expires < bt.expiry.queue[parent].expires {
+			return errors.New("expiry of key " + strconv.Itoa(entry.kv.key) + " comes before its parent in the expiry queue")
+		}
+	}
+	if len(bt.expiry.queue) != len(bt.expiry.handles) {
+		return errors.New("expiry queue holds " + strconv.Itoa(len(bt.expiry.queue)) + " entries but " + strconv.Itoa(len(bt.expiry.handles)) + " key-values have an expiry time")
+	}
+	for kv, handle := range bt.expiry.handles {
+		if handle.index < 0 || handle.index >= len(bt.expiry.queue) || bt.expiry.queue[handle.index] != handle || handle.kv != kv {
+			return errors.New("key " + strconv.Itoa(kv.key) + " has an expiry time that is not in the expiry queue")
+		}
+		found := false
+		bt.ascendRange(kv.key, kv.key, func(other *keyValue) bool {
+			found = other == kv
+			return !found
+		})
+		if !found {
+			return errors.New("key " + strconv.Itoa(kv.key) + " has an expiry time but is not in the tree")
+		}
+	}
+	return nil
+}
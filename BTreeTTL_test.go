@@ -0,0 +1,355 @@
+package GoTrees
+
+import (
+	"math"
+	"math/rand"
+	sc "strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock only moves when it is advanced, so the TTL tests never wait on real time
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+// newTTLTree returns an empty b-tree that reads the time from a new fake clock
+func newTTLTree() (BTree, *fakeClock) {
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	BT := NewBTree(T, nAlloc)
+	BT.SetClock(clock.Now)
+	return BT, clock
+}
+
+func TestBTreeTTLEmptyAllOps(t *testing.T) {
+	BT := NewBTree(T, nAlloc)
+	if BT.Sweep() != 0 || BT.Find(1) != nil || BT.Verify() != nil {
+		t.Fatal("A TTL operation failed when the tree was empty ")
+	}
+	BT, clock := newTTLTree()
+	clock.Advance(time.Hour)
+	if BT.Sweep() != 0 || BT.Find(1) != nil || BT.Verify() != nil {
+		t.Fatal("A TTL operation failed when the tree was empty ")
+	}
+}
+
+func TestBTreeTTLFind(t *testing.T) {
+	BT, clock := newTTLTree()
+	// key i expires after i+1 seconds, negative keys never expire
+	for _, key := range rand.Perm(nRAND) {
+		BT.InsertWithTTL(key, key, time.Duration(key+1)*time.Second)
+		BT.Insert(-key-1, key)
+	}
+	for i := 0; i < nRAND; i++ {
+		clock.Advance(time.Second)
+		if BT.Find(i) != nil || BT.Contains(i) {
+			t.Fatal("Found key " + sc.Itoa(i) + " after it expired. ")
+		}
+		if val := BT.Find(i + 1); i+1 < nRAND && (val == nil || (*val).(int) != i+1) {
+			t.Fatal("Could not find key " + sc.Itoa(i+1) + " before it expired. ")
+		}
+		if val := BT.Find(-i - 1); val == nil || (*val).(int) != i {
+			t.Fatal("Could not find key " + sc.Itoa(-i-1) + " which has no TTL. ")
+		}
+		// only the keys that were looked up have been deleted so far
+		if BT.Size() != uint64(nRAND*2-(i+1)) {
+			t.Fatal("B-Tree size incorrect, expected " + sc.Itoa(nRAND*2-(i+1)) + " but got " + sc.Itoa(int(BT.Size())) + ". ")
+		}
+		if err := BT.Verify(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestBTreeTTLSweep(t *testing.T) {
+	BT, clock := newTTLTree()
+	expires := map[int]time.Duration{}
+	for _, key := range rand.Perm(nRAND * 10) {
+		if key%5 == 0 {
+			BT.Insert(key, key)
+			continue
+		}
+		ttl := time.Duration(rand.Intn(nRAND)+1) * time.Minute
+		expires[key] = ttl
+		BT.InsertWithTTL(key, key, ttl)
+	}
+	elapsed := time.Duration(0)
+	for len(expires) > 0 {
+		step := time.Duration(rand.Intn(10)) * time.Minute
+		clock.Advance(step)
+		elapsed += step
+		expected := 0
+		for key, ttl := range expires {
+			if ttl <= elapsed {
+				expected++
+				delete(expires, key)
+			}
+		}
+		if deleted := BT.Sweep(); deleted != expected {
+			t.Fatal("Sweep after " + elapsed.String() + " deleted " + sc.Itoa(deleted) + " key-values but expected " + sc.Itoa(expected))
+		}
+		if err := BT.Verify(); err != nil {
+			t.Fatal(err)
+		}
+		if BT.Size() != uint64(nRAND*2+len(expires)) {
+			t.Fatal("B-Tree size incorrect, expected " + sc.Itoa(nRAND*2+len(expires)) + " but got " + sc.Itoa(int(BT.Size())) + ". ")
+		}
+	}
+	for _, key := range BT.Keys() {
+		if key%5 != 0 {
+			t.Fatal("Key " + sc.Itoa(key) + " was not swept. ")
+		}
+	}
+}
+
+func TestBTreeTTLDuplicates(t *testing.T) {
+	BT, clock := newTTLTree()
+	// occurrence i of the key expires after i minutes, every third occurrence never expires
+	for i := 0; i < nRAND; i++ {
+		if i%3 == 0 {
+			BT.Insert(1, i)
+		} else {
+			BT.InsertWithTTL(1, i, time.Duration(i)*time.Minute)
+		}
+		BT.Insert(0, i)
+		BT.Insert(2, i)
+	}
+	clock.Advance(time.Duration(nRAND/2) * time.Minute)
+	expected := []int{}
+	for i := 0; i < nRAND; i++ {
+		if i%3 == 0 || i > nRAND/2 {
+			expected = append(expected, i)
+		}
+	}
+	if val := BT.Find(1); val == nil || ((*val).(int)%3 != 0 && (*val).(int) <= nRAND/2) {
+		t.Fatal("Find did not return a live duplicate of a partly expired key. ")
+	}
+	BT.Sweep()
+	checkKeys(t, "Duplicates after expiry", intValues(BT.FindAll(1)), expected)
+	if err := BT.Verify(); err != nil {
+		t.Fatal(err)
+	}
+
+	clock.Advance(time.Duration(nRAND) * time.Minute)
+	if deleted := BT.Sweep(); deleted != len(expected)-(nRAND+2)/3 {
+		t.Fatal("Sweep deleted " + sc.Itoa(deleted) + " duplicates but expected " + sc.Itoa(len(expected)-(nRAND+2)/3))
+	}
+	if BT.Count(0) != nRAND || BT.Count(1) != (nRAND+2)/3 || BT.Count(2) != nRAND {
+		t.Fatal("Sweep deleted the wrong duplicates. ")
+	}
+	if err := BT.Verify(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBTreeTTLUpdates(t *testing.T) {
+	BT, clock := newTTLTree()
+	for i := 0; i < nRAND; i++ {
+		BT.InsertWithTTL(i, i, time.Minute)
+	}
+	// updates keep the expiry time
+	for i := 0; i < nRAND; i += 2 {
+		BT.Put(i, -i)
+	}
+	// deleted entries leave the expiry queue
+	for i := 1; i < nRAND; i += 4 {
+		BT.Delete(i)
+	}
+	BT.DeleteRange(nRAND/2, nRAND-1)
+	if err := BT.Verify(); err != nil {
+		t.Fatal(err)
+	}
+	if uint64(len(BT.expiry.queue)) != BT.Size() {
+		t.Fatal("Expiry queue holds " + sc.Itoa(len(BT.expiry.queue)) + " entries but the tree holds " + sc.Itoa(int(BT.Size())))
+	}
+	clock.Advance(time.Minute)
+	if deleted, size := BT.Sweep(), BT.Size(); deleted == 0 || size != 0 {
+		t.Fatal("Sweep left " + sc.Itoa(int(size)) + " expired key-values. ")
+	}
+
+	BT.InsertWithTTL(1, 1, time.Minute)
+	BT.Clear()
+	if len(BT.expiry.queue) != 0 || BT.Verify() != nil {
+		t.Fatal("Clear did not clear the expiry queue. ")
+	}
+}
+
+func TestBTreeTTLLong(t *testing.T) {
+	BT, clock := newTTLTree()
+	// expiry times are unix nanoseconds, well past what a 32 bit int holds, and a TTL too long to add never expires
+	BT.InsertWithTTL(1, 1, time.Duration(math.MaxInt64))
+	BT.InsertWithTTL(2, 2, 100*365*24*time.Hour)
+	clock.Advance(99 * 365 * 24 * time.Hour)
+	if BT.Sweep() != 0 || !BT.Contains(1) || !BT.Contains(2) {
+		t.Fatal("A long TTL expired early. ")
+	}
+	clock.Advance(2 * 365 * 24 * time.Hour)
+	if BT.Sweep() != 1 || !BT.Contains(1) || BT.Contains(2) || BT.Verify() != nil {
+		t.Fatal("A long TTL did not expire once it had passed. ")
+	}
+}
+
+func TestBTreeTTLWriteAfterExpiry(t *testing.T) {
+	writes := map[string]func(BT *BTree){
+		"Put": func(BT *BTree) { BT.Put(1, "new") },
+		"PutIfAbsent": func(BT *BTree) {
+			if !BT.PutIfAbsent(1, "new") {
+				t.Fatal("PutIfAbsent did not insert over an expired key. ")
+			}
+		},
+		"Update": func(BT *BTree) {
+			BT.Update(1, func(old interface{}, ok bool) (interface{}, bool) {
+				if ok {
+					t.Fatal("Update was given the expired value " + old.(string))
+				}
+				return "new", true
+			})
+		},
+		"GetOrInsert": func(BT *BTree) {
+			if actual, loaded := BT.GetOrInsert(1, "new"); loaded || actual.(string) != "new" {
+				t.Fatal("GetOrInsert returned the expired value instead of inserting. ")
+			}
+		},
+		"Swap": func(BT *BTree) {
+			if _, loaded := BT.Swap(1, "new"); loaded {
+				t.Fatal("Swap returned the expired value as the previous one. ")
+			}
+		},
+	}
+	for name, write := range writes {
+		BT, clock := newTTLTree()
+		BT.InsertWithTTL(1, "old", time.Second)
+		clock.Advance(2 * time.Second)
+		write(&BT)
+		// the new value is stored without the old expiry time, so it outlives it
+		clock.Advance(time.Hour)
+		if val := BT.Find(1); val == nil || (*val).(string) != "new" {
+			t.Fatal(name + " after expiry did not store a live value. ")
+		}
+		if BT.Size() != 1 || BT.Sweep() != 0 || BT.Verify() != nil {
+			t.Fatal(name + " after expiry left the tree incorrect. ")
+		}
+	}
+}
+
+func TestBTreeTTLSetOps(t *testing.T) {
+	ops := map[string]func(A, B *BTree){
+		"UnionWith":               func(A, B *BTree) { A.UnionWith(B, nil) },
+		"IntersectWith":           func(A, B *BTree) { A.IntersectWith(B) },
+		"DifferenceWith":          func(A, B *BTree) { A.DifferenceWith(B) },
+		"SymmetricDifferenceWith": func(A, B *BTree) { A.SymmetricDifferenceWith(B) },
+	}
+	for name, op := range ops {
+		// even keys of A and keys divisible by 3 of B expire, the keys in both take their expiry time from A
+		A, clock := newTTLTree()
+		B := NewBTree(T, nAlloc)
+		B.SetClock(clock.Now)
+		for i := 0; i < nRAND; i++ {
+			if i%2 == 0 {
+				A.InsertWithTTL(i, i, time.Minute)
+			} else {
+				A.Insert(i, i)
+			}
+			if key := i + nRAND/2; key%3 == 0 {
+				B.InsertWithTTL(key, -key, time.Minute)
+			} else {
+				B.Insert(key, -key)
+			}
+		}
+		op(&A, &B)
+		if err := A.Verify(); err != nil {
+			t.Fatal(name + ": " + err.Error())
+		}
+		keys := A.Keys()
+		clock.Advance(time.Minute)
+		A.Sweep()
+		expected := []int{}
+		for _, key := range keys {
+			if (key < nRAND && key%2 != 0) || (key >= nRAND && key%3 != 0) {
+				expected = append(expected, key)
+			}
+		}
+		checkKeys(t, name+" after expiry", A.Keys(), expected)
+	}
+}
+
+func TestBTreeTTLSplitJoin(t *testing.T) {
+	BT, clock := newTTLTree()
+	for i := 0; i < nRAND; i++ {
+		if i%2 == 0 {
+			BT.InsertWithTTL(i, i, time.Duration(i+1)*time.Second)
+		} else {
+			BT.Insert(i, i)
+		}
+	}
+	left, right := BT.SplitAt(nRAND / 2)
+	for _, tree := range []*BTree{&left, &right} {
+		if err := tree.Verify(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(left.expiry.queue) != nRAND/4 || len(right.expiry.queue) != nRAND/4 {
+		t.Fatal("SplitAt did not move the expiry times with their keys. ")
+	}
+
+	// right has the same clock, so its entries expire with the rest
+	clock.Advance(time.Duration(nRAND*3/4) * time.Second)
+	expected := 0
+	for i := 0; i < nRAND; i += 2 {
+		if i+1 <= nRAND*3/4 {
+			expected++
+		}
+	}
+	if !left.Join(&right) {
+		t.Fatal("Join returned false for ordered trees. ")
+	}
+	if err := left.Verify(); err != nil {
+		t.Fatal(err)
+	}
+	if deleted := left.Sweep(); deleted != expected {
+		t.Fatal("Sweep after Join deleted " + sc.Itoa(deleted) + " key-values but expected " + sc.Itoa(expected))
+	}
+	if left.Size() != uint64(nRAND-expected) || left.Verify() != nil {
+		t.Fatal("Joined tree is incorrect after the sweep. ")
+	}
+}
+
+func TestBTreeSweeper(t *testing.T) {
+	BT, clock := newTTLTree()
+	var lock sync.Mutex
+	tick := make(chan time.Time)
+	stop := BT.StartSweeper(tick, &lock)
+
+	lock.Lock()
+	for i := 0; i < nRAND; i++ {
+		BT.InsertWithTTL(i, i, time.Duration(i+1)*time.Second)
+	}
+	clock.Advance(time.Duration(nRAND/2) * time.Second)
+	lock.Unlock()
+
+	// stop waits for the sweep started by the tick to finish
+	tick <- clock.Now()
+	stop()
+	stop()
+	if BT.Size() != uint64(nRAND/2) {
+		t.Fatal("Sweeper left " + sc.Itoa(int(BT.Size())) + " key-values but expected " + sc.Itoa(nRAND/2))
+	}
+	clock.Advance(time.Duration(nRAND) * time.Second)
+	select {
+	case tick <- clock.Now():
+		t.Fatal("Sweeper still received ticks after it was stopped. ")
+	default:
+	}
+	if BT.Size() != uint64(nRAND/2) || BT.Verify() != nil {
+		t.Fatal("Sweeper changed the tree after it was stopped. ")
+	}
+}
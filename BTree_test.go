@@ -206,3 +206,300 @@ func TestBTreeString(t *testing.T) {
 		t.Fatal("Expected output: \n" + expected + "\n but got \n" + BT.String())
 	}
 }
+
+func TestBTreeVerify(t *testing.T) {
+	BT := NewBTree(T, nAlloc)
+	keys := rand.Perm(nRAND)
+
+	if err := BT.Verify(); err != nil {
+		t.Fatal("Empty tree failed verification: " + err.Error())
+	}
+	for _, key := range keys {
+		BT.Insert(key, nil)
+		if err := BT.Verify(); err != nil {
+			t.Fatal("Tree failed verification after inserting " + sc.Itoa(key) + ": " + err.Error())
+		}
+	}
+	for _, key := range keys {
+		BT.Delete(key)
+		if err := BT.Verify(); err != nil {
+			t.Fatal("Tree failed verification after deleting " + sc.Itoa(key) + ": " + err.Error())
+		}
+	}
+
+	BT.Insert(1, nil)
+	BT.Insert(2, nil)
+	BT.root.nodes[0].key = 3
+	if BT.Verify() == nil {
+		t.Fatal("Tree with unsorted keys passed verification. ")
+	}
+}
+
+func TestBTreeAlloc(t *testing.T) {
+	BT := NewBTree(4, 1)
+	keys := rand.Perm(nRAND)
+
+	for _, key := range keys {
+		BT.Insert(key, nil)
+	}
+	if err := BT.Verify(); err != nil {
+		t.Fatal("Tree with preallocated nodes failed verification: " + err.Error())
+	}
+	for i, k := range keys {
+		if !BT.Delete(k) {
+			t.Fatal("BT returned false when tree should have been modified. ")
+		}
+		if err := BT.Verify(); err != nil {
+			t.Fatal("Tree with preallocated nodes failed verification after " + sc.Itoa(i+1) + " deletions: " + err.Error())
+		}
+	}
+}
+
+func TestBTreeDot(t *testing.T) {
+	BT := NewBTree(T, nAlloc)
+
+	BT.Insert(10, 10)
+	BT.Insert(11, 11)
+	BT.Insert(9, 9)
+	BT.Insert(8, 8)
+
+	expected := "digraph BTree {\n\tnode [shape=box];\n\tn0 [label=\"10\"];\n\tn0 -> n1;\n\tn0 -> n2;\n\tn1 [label=\"8 9\"];\n\tn2 [label=\"11\"];\n}\n"
+	actual := BT.Dot()
+	if actual != expected {
+		t.Fatal("Expected output: \n" + expected + "\n but got \n" + actual)
+	}
+}
+
+func TestBTreeStats(t *testing.T) {
+	BT := NewBTree(T, nAlloc)
+
+	stats := BT.Stats()
+	if stats.Nodes != 0 || stats.Size != 0 || len(stats.NodesPerLevel) != 0 {
+		t.Fatal("Empty tree should have empty stats. ")
+	}
+
+	BT.Insert(10, 10)
+	BT.Insert(11, 11)
+	BT.Insert(9, 9)
+	BT.Insert(8, 8)
+	BT.Insert(14, 14)
+	BT.Insert(12, 12)
+	BT.Insert(13, 13)
+
+	// [10 12] [8 9] [11] [13 14]
+	stats = BT.Stats()
+	if stats.Size != 7 || stats.Nodes != 4 {
+		t.Fatal("Expected 7 keys in 4 nodes but got " + sc.Itoa(int(stats.Size)) + " keys in " + sc.Itoa(int(stats.Nodes)) + " nodes. ")
+	}
+	if len(stats.NodesPerLevel) != 2 || stats.NodesPerLevel[0] != 1 || stats.NodesPerLevel[1] != 3 {
+		t.Fatal("Nodes per level was incorrect. ")
+	}
+	if stats.MinKeys != 1 || stats.MaxKeysSeen != 2 || stats.AvgKeys != 7.0/4 {
+		t.Fatal("Node occupancy was incorrect. ")
+	}
+	// MaxKeys is 3 so nodes with 1 key are 33% full and nodes with 2 keys are 66% full
+	if stats.MaxKeys != 3 || stats.FillHistogram[3] != 1 || stats.FillHistogram[6] != 3 {
+		t.Fatal("Fill histogram was incorrect. ")
+	}
+	if stats.MemoryBytes == 0 {
+		t.Fatal("Memory estimate should not be 0. ")
+	}
+
+	BT = NewBTree(4, 1)
+	BT.Insert(1, 1)
+	stats = BT.Stats()
+	// the root reserves 4 key-value slots and 5 child slots
+	if stats.WastedSlots != 8 {
+		t.Fatal("Expected 8 wasted slots but got " + sc.Itoa(int(stats.WastedSlots)))
+	}
+}
+
+func TestBTreeDuplicateOrder(t *testing.T) {
+	BT := NewBTree(T, nAlloc)
+
+	// values are the insertion order so equal keys must have increasing values
+	for i := 0; i < nRAND*5; i++ {
+		BT.Insert(rand.Intn(nRAND/10), i)
+	}
+	for i := 0; i < nRAND; i++ {
+		BT.Delete(rand.Intn(nRAND / 10))
+	}
+	if err := BT.Verify(); err != nil {
+		t.Fatal(err)
+	}
+	keys := BT.Keys()
+	vals := BT.Values()
+	for i := 1; i < len(keys); i++ {
+		if keys[i] == keys[i-1] && vals[i].(int) < vals[i-1].(int) {
+			t.Fatal("Key " + sc.Itoa(keys[i]) + " inserted at " + sc.Itoa(vals[i].(int)) + " came after the one inserted at " + sc.Itoa(vals[i-1].(int)))
+		}
+	}
+}
+
+func TestBTreeFindAll(t *testing.T) {
+	BT := NewBTree(T, nAlloc)
+	counts := make([]int, nRAND/10)
+
+	for i := 0; i < nRAND*2; i++ {
+		key := rand.Intn(nRAND / 10)
+		counts[key]++
+		BT.Insert(key, i)
+	}
+	for key, count := range counts {
+		vals := BT.FindAll(key)
+		if len(vals) != count || BT.Count(key) != count {
+			t.Fatal("Expected " + sc.Itoa(count) + " occurrences of " + sc.Itoa(key) + " but found " + sc.Itoa(len(vals)))
+		}
+		for i := 1; i < len(vals); i++ {
+			if vals[i].(int) < vals[i-1].(int) {
+				t.Fatal("FindAll did not return the values of " + sc.Itoa(key) + " in insertion order. ")
+			}
+		}
+	}
+	if len(BT.FindAll(nRAND)) != 0 || BT.Count(nRAND) != 0 {
+		t.Fatal("Found occurrences of a key that was not in the tree. ")
+	}
+}
+
+func TestBTreeDeleteAll(t *testing.T) {
+	BT := NewBTree(T, nAlloc)
+	counts := make([]int, nRAND/10)
+
+	for i := 0; i < nRAND*2; i++ {
+		key := rand.Intn(nRAND / 10)
+		counts[key]++
+		BT.Insert(key, i)
+	}
+	remaining := nRAND * 2
+	for key, count := range counts {
+		if deleted := BT.DeleteAll(key); deleted != count {
+			t.Fatal("Expected to delete " + sc.Itoa(count) + " occurrences of " + sc.Itoa(key) + " but deleted " + sc.Itoa(deleted))
+		}
+		remaining -= count
+		if BT.Contains(key) || BT.Size() != uint64(remaining) {
+			t.Fatal("Tree still holds " + sc.Itoa(key) + " or has the wrong size after DeleteAll. ")
+		}
+	}
+}
+
+func TestBTreePut(t *testing.T) {
+	BT := NewBTree(T, nAlloc)
+	keys := rand.Perm(nRAND)
+
+	for _, key := range keys {
+		BT.Put(key, key)
+	}
+	for _, key := range keys {
+		BT.Put(key, -key)
+	}
+	if BT.Size() != nRAND {
+		t.Fatal("Put added duplicate keys, expected size " + sc.Itoa(nRAND) + " but got " + sc.Itoa(int(BT.Size())))
+	}
+	for _, key := range keys {
+		if v := BT.Find(key); v == nil || (*v).(int) != -key {
+			t.Fatal("Put did not replace the value of " + sc.Itoa(key))
+		}
+	}
+	if err := BT.Verify(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBTreePutIfAbsent(t *testing.T) {
+	BT := NewBTree(T, nAlloc)
+	keys := rand.Perm(nRAND)
+
+	for _, key := range keys {
+		if !BT.PutIfAbsent(key, key) {
+			t.Fatal("PutIfAbsent did not insert missing key " + sc.Itoa(key))
+		}
+	}
+	for _, key := range keys {
+		if BT.PutIfAbsent(key, -key) {
+			t.Fatal("PutIfAbsent inserted existing key " + sc.Itoa(key))
+		}
+		if v := BT.Find(key); v == nil || (*v).(int) != key {
+			t.Fatal("PutIfAbsent changed the value of " + sc.Itoa(key))
+		}
+	}
+	if BT.Size() != nRAND {
+		t.Fatal("Expected size " + sc.Itoa(nRAND) + " but got " + sc.Itoa(int(BT.Size())))
+	}
+}
+
+func TestBTreeUpdate(t *testing.T) {
+	BT := NewBTree(T, nAlloc)
+	counter := func(old interface{}, ok bool) (interface{}, bool) {
+		if !ok {
+			return 1, true
+		}
+		return old.(int) + 1, true
+	}
+
+	for i := 0; i < nRAND; i++ {
+		BT.Update(i%10, counter)
+	}
+	for i := 0; i < 10; i++ {
+		if v := BT.Find(i); v == nil || (*v).(int) != nRAND/10 {
+			t.Fatal("Update did not count key " + sc.Itoa(i) + " correctly. ")
+		}
+	}
+	BT.Update(nRAND, func(old interface{}, ok bool) (interface{}, bool) {
+		return nil, false
+	})
+	if BT.Contains(nRAND) || BT.Size() != 10 {
+		t.Fatal("Update inserted a key when fn returned false. ")
+	}
+}
+
+func TestBTreeGetOrInsert(t *testing.T) {
+	BT := NewBTree(T, nAlloc)
+
+	if v, loaded := BT.GetOrInsert(1, "a"); loaded || v.(string) != "a" {
+		t.Fatal("GetOrInsert did not insert a missing key. ")
+	}
+	if v, loaded := BT.GetOrInsert(1, "b"); !loaded || v.(string) != "a" {
+		t.Fatal("GetOrInsert did not return the existing value. ")
+	}
+	if BT.Size() != 1 {
+		t.Fatal("Expected size 1 but got " + sc.Itoa(int(BT.Size())))
+	}
+}
+
+func TestBTreeSwap(t *testing.T) {
+	BT := NewBTree(T, nAlloc)
+
+	if v, loaded := BT.Swap(1, "a"); loaded || v != nil {
+		t.Fatal("Swap returned a previous value for a missing key. ")
+	}
+	if v, loaded := BT.Swap(1, "b"); !loaded || v.(string) != "a" {
+		t.Fatal("Swap did not return the previous value. ")
+	}
+	if v := BT.Find(1); v == nil || (*v).(string) != "b" || BT.Size() != 1 {
+		t.Fatal("Swap did not replace the value. ")
+	}
+}
+
+func TestBTreeInsertDeleteMixed(t *testing.T) {
+	BT := NewBTree(1, nAlloc)
+	inTree := map[int]bool{}
+
+	// interleaving inserts and deletes merges nodes past the split size, which must still split cleanly
+	for i := 0; i < nRAND*20; i++ {
+		key := rand.Intn(nRAND * 5)
+		if rand.Intn(2) == 0 && !inTree[key] {
+			BT.Insert(key, nil)
+			inTree[key] = true
+		} else if BT.Delete(key) != inTree[key] {
+			t.Fatal("Delete of " + sc.Itoa(key) + " did not match whether it was in the tree. ")
+		} else {
+			delete(inTree, key)
+		}
+		if err := BT.Verify(); err != nil {
+			t.Fatal("Tree failed verification after " + sc.Itoa(i+1) + " operations: " + err.Error())
+		}
+	}
+	if BT.Size() != uint64(len(inTree)) {
+		t.Fatal("BT size incorrect, expected " + sc.Itoa(len(inTree)) + " but got " + sc.Itoa(int(BT.Size())))
+	}
+}
@@ -0,0 +1,378 @@
+package GoTrees
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"sync/atomic"
+	"unsafe"
+)
+
+// ConcurrentSkipList is a lock-free ordered map that any number of goroutines can use at once. Every method is linearizable except Range, Walk, Keys, Values and Size, which are only weakly consistent.
+// Readers never write to shared memory, and writers change it only with compare-and-swap, so no goroutine ever waits for a lock held by another.
+// It is the skip list of Herlihy and Shavit: a key is removed by swapping its value for a deleted marker, then each level's link out of its node is marked so no node can be linked after it, and finally the node is unlinked by whichever goroutine passes it next.
+// Unlike SkipList, keys are unique. Share it by pointer once it has been made.
+type ConcurrentSkipList struct {
+	// seed is advanced once for every node to draw its levels, and size is counted as key-values are put and deleted. Both are first so they stay 64 bit aligned.
+	seed     uint64
+	size     int64
+	head     *csNode
+	maxLevel int
+	p        float64
+	// beforeDelete is called by Delete between reading the value of a node and swapping it out. It is only set by tests, through export_test.go, to change the value in that window
+	beforeDelete func(key int)
+}
+
+// csNode is a key-value with a link to the next node at each of its levels. val points to the current interface{} value, or is csDeleted once the key has been deleted.
+type csNode struct {
+	key  int
+	val  unsafe.Pointer
+	next []unsafe.Pointer
+}
+
+// csLink is the content of one link of a node. It is never changed once made, so a compare-and-swap of the pointer to it changes the next node and the mark together.
+// A marked link means the node that holds it is being deleted at that level.
+type csLink struct {
+	node   *csNode
+	marked bool
+}
+
+// csDeleted is stored as the value of a node once its key has been deleted
+var csDeleted = unsafe.Pointer(new(interface{}))
+
+// NewConcurrentSkipList returns an empty concurrent skip list where a node reaches each next level with probability p, up to maxLevel levels. Levels are drawn from a sequence seeded with seed, so the same inserts from one goroutine give the same list.
+// p is clamped to (0, 1) with 0.5 used when it is out of range, and maxLevel is clamped to [1, 64].
+func NewConcurrentSkipList(p float64, maxLevel int, seed int64) ConcurrentSkipList {
+	if p <= 0 || p >= 1 {
+		p = 0.5
+	}
+	if maxLevel < 1 {
+		maxLevel = 1
+	} else if maxLevel > maxSkipLevel {
+		maxLevel = maxSkipLevel
+	}
+	head := &csNode{next: make([]unsafe.Pointer, maxLevel)}
+	for i := range head.next {
+		head.next[i] = unsafe.Pointer(&csLink{})
+	}
+	return ConcurrentSkipList{seed: uint64(seed), size: 0, head: head, maxLevel: maxLevel, p: p}
+}
+
+func (n *csNode) link(level int) *csLink {
+	return (*csLink)(atomic.LoadPointer(&n.next[level]))
+}
+
+func (n *csNode) casLink(level int, old, new *csLink) bool {
+	return atomic.CompareAndSwapPointer(&n.next[level], unsafe.Pointer(old), unsafe.Pointer(new))
+}
+
+// splitmix64 scrambles x into a well distributed random number
+func splitmix64(x uint64) uint64 {
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
+
+// randomLevel returns the number of levels of a new node, each further level is added with probability p. Only the seed is shared, so goroutines do not contend on a random source.
+func (sl *ConcurrentSkipList) randomLevel() int {
+	x := atomic.AddUint64(&sl.seed, 0x9e3779b97f4a7c15)
+	level := 1
+	for level < sl.maxLevel {
+		x = splitmix64(x)
+		if float64(x>>11)/(1<<53) >= sl.p {
+			break
+		}
+		level++
+	}
+	return level
+}
+
+// find fills preds with the last node before key and succs with the first node from key on at every level, unlinking any marked nodes it passes.
+// It starts over from the head whenever another goroutine changes a link it is about to change. It returns whether succs[0] holds key.
+func (sl *ConcurrentSkipList) find(key int, preds, succs *[maxSkipLevel]*csNode) bool {
+retry:
+	for {
+		pred := sl.head
+		for level := sl.maxLevel - 1; level >= 0; level-- {
+			predLink := pred.link(level)
+			curr := predLink.node
+			for curr != nil {
+				currLink := curr.link(level)
+				if currLink.marked {
+					// curr is being deleted, unlink it unless pred is being deleted too
+					unlinked := &csLink{node: currLink.node}
+					if predLink.marked || !pred.casLink(level, predLink, unlinked) {
+						continue retry
+					}
+					predLink, curr = unlinked, currLink.node
+				} else if curr.key < key {
+					pred, predLink, curr = curr, currLink, currLink.node
+				} else {
+					break
+				}
+			}
+			preds[level], succs[level] = pred, curr
+		}
+		return succs[0] != nil && succs[0].key == key
+	}
+}
+
+// search returns the first node at the bottom level from key on that is not being deleted, without changing any links
+func (sl *ConcurrentSkipList) search(key int) *csNode {
+	pred := sl.head
+	var curr *csNode
+	for level := sl.maxLevel - 1; level >= 0; level-- {
+		curr = pred.link(level).node
+		for curr != nil {
+			currLink := curr.link(level)
+			if currLink.marked {
+				curr = currLink.node
+			} else if curr.key < key {
+				pred, curr = curr, currLink.node
+			} else {
+				break
+			}
+		}
+	}
+	return curr
+}
+
+// markNode marks every link of n from the top level down, so nothing can be linked after it. Any goroutine that finds n deleted helps with this.
+func (n *csNode) markNode() {
+	for level := len(n.next) - 1; level >= 0; level-- {
+		for {
+			l := n.link(level)
+			if l.marked || n.casLink(level, l, &csLink{node: l.node, marked: true}) {
+				break
+			}
+		}
+	}
+}
+
+// put stores value for key. If onlyIfAbsent is set a value already there is kept. It returns the previous value and whether there was one.
+func (sl *ConcurrentSkipList) put(key int, value interface{}, onlyIfAbsent bool) (interface{}, bool) {
+	var preds, succs [maxSkipLevel]*csNode
+	var n *csNode
+	for {
+		if sl.find(key, &preds, &succs) {
+			found := succs[0]
+			old := atomic.LoadPointer(&found.val)
+			if old == csDeleted {
+				// the key was deleted but its node is still linked, finish deleting it and try again
+				found.markNode()
+				continue
+			}
+			if onlyIfAbsent || atomic.CompareAndSwapPointer(&found.val, old, unsafe.Pointer(&value)) {
+				return *(*interface{})(old), true
+			}
+			continue
+		}
+		if n == nil {
+			n = &csNode{key: key, val: unsafe.Pointer(&value), next: make([]unsafe.Pointer, sl.randomLevel())}
+		}
+		// n is not shared until it is linked at the bottom level, so its links can be set directly
+		for i := range n.next {
+			n.next[i] = unsafe.Pointer(&csLink{node: succs[i]})
+		}
+		predLink := preds[0].link(0)
+		if predLink.marked || predLink.node != succs[0] || !preds[0].casLink(0, predLink, &csLink{node: n}) {
+			continue
+		}
+		atomic.AddInt64(&sl.size, 1)
+		sl.linkUpper(n, &preds, &succs)
+		return nil, false
+	}
+}
+
+// linkUpper links n into each level above the bottom one. It stops early if n starts being deleted, since a deleted node must not be linked any further.
+func (sl *ConcurrentSkipList) linkUpper(n *csNode, preds, succs *[maxSkipLevel]*csNode) {
+	for level := 1; level < len(n.next); level++ {
+		for {
+			nextLink := n.link(level)
+			if nextLink.marked {
+				return
+			}
+			if nextLink.node != succs[level] && !n.casLink(level, nextLink, &csLink{node: succs[level]}) {
+				continue
+			}
+			predLink := preds[level].link(level)
+			if !predLink.marked && predLink.node == succs[level] && preds[level].casLink(level, predLink, &csLink{node: n}) {
+				break
+			}
+			// the neighbours changed, look them up again
+			if !sl.find(n.key, preds, succs) || succs[0] != n {
+				return
+			}
+		}
+	}
+}
+
+// Put will set the value of key, inserting it if it is not in the list.
+func (sl *ConcurrentSkipList) Put(key int, value interface{}) {
+	sl.put(key, value, false)
+}
+
+// PutIfAbsent will insert key only if it is not already in the list. It will return whether or not the list was changed.
+func (sl *ConcurrentSkipList) PutIfAbsent(key int, value interface{}) bool {
+	_, loaded := sl.put(key, value, true)
+	return !loaded
+}
+
+// GetOrInsert returns the value of key if it is in the list, otherwise it inserts value and returns it. loaded reports whether the value was already there.
+func (sl *ConcurrentSkipList) GetOrInsert(key int, value interface{}) (actual interface{}, loaded bool) {
+	if actual, loaded = sl.put(key, value, true); !loaded {
+		actual = value
+	}
+	return actual, loaded
+}
+
+// Swap sets the value of key, inserting it if it is not in the list, and returns the previous value. loaded reports whether there was a previous value.
+func (sl *ConcurrentSkipList) Swap(key int, value interface{}) (previous interface{}, loaded bool) {
+	return sl.put(key, value, false)
+}
+
+// Get returns the value of key and whether it is in the list. It only reads, so it never waits for or slows down other goroutines.
+// Unlike Find on the other trees no pointer is returned, since the value may be replaced at any time.
+func (sl *ConcurrentSkipList) Get(key int) (interface{}, bool) {
+	n := sl.search(key)
+	if n == nil || n.key != key {
+		return nil, false
+	}
+	val := atomic.LoadPointer(&n.val)
+	if val == csDeleted {
+		return nil, false
+	}
+	return *(*interface{})(val), true
+}
+
+// Contains determines if key exists in the list and returns the result.
+func (sl *ConcurrentSkipList) Contains(key int) bool {
+	_, ok := sl.Get(key)
+	return ok
+}
+
+// Delete will delete key from the list. It will return whether or not the list was changed.
+func (sl *ConcurrentSkipList) Delete(key int) bool {
+	var preds, succs [maxSkipLevel]*csNode
+	for {
+		if !sl.find(key, &preds, &succs) {
+			return false
+		}
+		n := succs[0]
+		for {
+			val := atomic.LoadPointer(&n.val)
+			if val == csDeleted {
+				break
+			}
+			if sl.beforeDelete != nil {
+				sl.beforeDelete(key)
+			}
+			if atomic.CompareAndSwapPointer(&n.val, val, csDeleted) {
+				atomic.AddInt64(&sl.size, -1)
+				n.markNode()
+				// unlink the node from every level
+				sl.find(key, &preds, &succs)
+				return true
+			}
+			// a concurrent Put or Swap changed the value, the key is still live so delete the new value
+		}
+		// another goroutine deleted the key first, help it and check whether the key was put again since
+		n.markNode()
+	}
+}
+
+// Range calls fn on every key-value with lo <= key <= hi in order, until fn returns false.
+// It does not see a single moment of the list: a key-value put or deleted while it runs may or may not be seen, but every key is seen at most once and in order.
+func (sl *ConcurrentSkipList) Range(lo, hi int, fn func(key int, value interface{}) bool) {
+	for n := sl.search(lo); n != nil && n.key <= hi; n = n.link(0).node {
+		if val := atomic.LoadPointer(&n.val); val != csDeleted && !fn(n.key, *(*interface{})(val)) {
+			return
+		}
+	}
+}
+
+// Walk calls fn on every key-value in order, until fn returns false. Like Range it is only weakly consistent.
+func (sl *ConcurrentSkipList) Walk(fn func(key int, value interface{}) bool) {
+	sl.Range(math.MinInt, math.MaxInt, fn)
+}
+
+func (sl *ConcurrentSkipList) Keys() []int {
+	keys := []int{}
+	sl.Walk(func(key int, value interface{}) bool {
+		keys = append(keys, key)
+		return true
+	})
+	return keys
+}
+
+func (sl *ConcurrentSkipList) Values() []interface{} {
+	vals := []interface{}{}
+	sl.Walk(func(key int, value interface{}) bool {
+		vals = append(vals, value)
+		return true
+	})
+	return vals
+}
+
+// Clear deletes every key it walks over. Key-values put while it runs may remain.
+func (sl *ConcurrentSkipList) Clear() {
+	sl.Walk(func(key int, value interface{}) bool {
+		sl.Delete(key)
+		return true
+	})
+}
+
+// Size returns the number of key-values. While other goroutines change the list it is only an estimate.
+func (sl *ConcurrentSkipList) Size() uint64 {
+	if size := atomic.LoadInt64(&sl.size); size > 0 {
+		return uint64(size)
+	}
+	return 0
+}
+
+// Verify walks every level of the list and checks that each is in order and holds nodes of the bottom level. It must not run while other goroutines change the list.
+// It returns nil if the list is valid, otherwise an error describing the first problem found.
+func (sl *ConcurrentSkipList) Verify() error {
+	count := uint64(0)
+	bottom := map[*csNode]bool{}
+	var prev *csNode
+	for n := sl.head.link(0).node; n != nil; n = n.link(0).node {
+		if len(n.next) < 1 || len(n.next) > sl.maxLevel {
+			return errors.New("key " + strconv.Itoa(n.key) + " has " + strconv.Itoa(len(n.next)) + " levels")
+		}
+		if n.link(0).marked {
+			continue
+		}
+		if prev != nil && prev.key >= n.key {
+			return errors.New("key " + strconv.Itoa(n.key) + " comes after the key " + strconv.Itoa(prev.key))
+		}
+		if atomic.LoadPointer(&n.val) == csDeleted {
+			return errors.New("key " + strconv.Itoa(n.key) + " was deleted but is still linked")
+		}
+		bottom[n] = true
+		prev = n
+		count++
+	}
+	if count != sl.Size() {
+		return errors.New("list holds " + strconv.FormatUint(count, 10) + " keys but size is " + strconv.FormatUint(sl.Size(), 10))
+	}
+	for level := 1; level < sl.maxLevel; level++ {
+		prev = nil
+		for n := sl.head.link(level).node; n != nil; n = n.link(level).node {
+			if level >= len(n.next) {
+				return errors.New("key " + strconv.Itoa(n.key) + " is linked at level " + strconv.Itoa(level) + " above its height")
+			}
+			if n.link(level).marked {
+				continue
+			}
+			if !bottom[n] {
+				return errors.New("key " + strconv.Itoa(n.key) + " at level " + strconv.Itoa(level) + " is missing from the bottom level")
+			}
+			if prev != nil && prev.key >= n.key {
+				return errors.New("key " + strconv.Itoa(n.key) + " at level " + strconv.Itoa(level) + " comes after the key " + strconv.Itoa(prev.key))
+			}
+			prev = n
+		}
+	}
+	return nil
+}
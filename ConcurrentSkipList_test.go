@@ -0,0 +1,453 @@
+package GoTrees
+
+import (
+	"math/rand"
+	"sort"
+	sc "strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// nGoroutines is the number of goroutines the concurrent tests run at once
+const nGoroutines = 16
+
+func TestConcurrentSkipListEmptyAllOps(t *testing.T) {
+	SL := NewConcurrentSkipList(0.5, 16, 1)
+
+	keys := SL.Keys()
+	vals := SL.Values()
+	_, found := SL.Get(1)
+	changed := SL.Delete(1)
+
+	if len(keys) != 0 || len(vals) != 0 || found || changed || SL.Size() != 0 || SL.Verify() != nil {
+		t.Fatal("A concurrent skip list operation failed when the list was empty ")
+	}
+}
+
+func TestConcurrentSkipListSequential(t *testing.T) {
+	SL := NewConcurrentSkipList(0.5, 16, 1)
+	expected := map[int]int{}
+
+	for i := 0; i < nRAND*10; i++ {
+		key := rand.Intn(nRAND)
+		_, inMap := expected[key]
+		switch rand.Intn(4) {
+		case 0:
+			previous, loaded := SL.Swap(key, i)
+			if loaded != inMap || (loaded && previous.(int) != expected[key]) {
+				t.Fatal("Swap of " + sc.Itoa(key) + " returned the wrong previous value. ")
+			}
+			expected[key] = i
+		case 1:
+			if SL.PutIfAbsent(key, i) == inMap {
+				t.Fatal("PutIfAbsent of " + sc.Itoa(key) + " was wrong about whether the key was there. ")
+			}
+			if !inMap {
+				expected[key] = i
+			}
+		case 2:
+			if SL.Delete(key) != inMap {
+				t.Fatal("Delete of " + sc.Itoa(key) + " was wrong about whether the key was there. ")
+			}
+			delete(expected, key)
+		case 3:
+			val, ok := SL.Get(key)
+			if ok != inMap || (ok && val.(int) != expected[key]) {
+				t.Fatal("Get of " + sc.Itoa(key) + " returned the wrong value. ")
+			}
+		}
+		if err := SL.Verify(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	keys := []int{}
+	for key := range expected {
+		keys = append(keys, key)
+	}
+	sort.Ints(keys)
+	checkKeys(t, "Concurrent skip list", SL.Keys(), keys)
+	if SL.Size() != uint64(len(keys)) {
+		t.Fatal("Concurrent skip list size was " + sc.Itoa(int(SL.Size())) + " but expected " + sc.Itoa(len(keys)))
+	}
+	SL.Clear()
+	if SL.Size() != 0 || len(SL.Keys()) != 0 {
+		t.Fatal("Concurrent skip list was not empty after Clear. ")
+	}
+}
+
+func TestConcurrentSkipListDeleteRace(t *testing.T) {
+	SL := NewConcurrentSkipList(0.5, 16, 1)
+	for i := 0; i < nRAND; i++ {
+		SL.Put(i, i)
+	}
+	// a Put lands between Delete reading the value and swapping it out, the key is still live so Delete must delete it
+	puts := 0
+	SL.setBeforeDelete(func(key int) {
+		if puts < 3 {
+			puts++
+			SL.Put(key, -key)
+		}
+	})
+	if !SL.Delete(nRAND/2) || puts != 3 {
+		t.Fatal("Delete failed after a Put changed the value it read. ")
+	}
+	if SL.Contains(nRAND/2) || SL.Size() != uint64(nRAND-1) {
+		t.Fatal("Delete left the key behind after a Put changed the value it read. ")
+	}
+	for i := 0; i < nRAND; i++ {
+		if _, ok := SL.Get(i); ok != (i != nRAND/2) {
+			t.Fatal("Key " + sc.Itoa(i) + " was lost by a Delete that raced with a Put. ")
+		}
+	}
+
+	// a Delete lands in the window instead, so only one of the two deletes the key
+	SL.setBeforeDelete(func(key int) {
+		SL.setBeforeDelete(nil)
+		if !SL.Delete(key) {
+			t.Fatal("The inner Delete did not delete the key. ")
+		}
+	})
+	if SL.Delete(0) || SL.Contains(0) || SL.Size() != uint64(nRAND-2) {
+		t.Fatal("Two Deletes of the same key both deleted it. ")
+	}
+	if err := SL.Verify(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConcurrentSkipListDisjointWriters(t *testing.T) {
+	SL := NewConcurrentSkipList(0.5, 16, 1)
+	results := make([]map[int]int, nGoroutines)
+	var wg sync.WaitGroup
+
+	// every goroutine owns the keys equal to its id mod nGoroutines, so the end state is known even though they all share the list
+	for g := 0; g < nGoroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			r := rand.New(rand.NewSource(int64(g)))
+			owned := map[int]int{}
+			for i := 0; i < nRAND*10; i++ {
+				key := r.Intn(nRAND)*nGoroutines + g
+				if r.Intn(3) == 0 {
+					if SL.Delete(key) != (owned[key] != 0) {
+						t.Error("Delete of owned key " + sc.Itoa(key) + " was wrong about whether the key was there. ")
+					}
+					delete(owned, key)
+				} else {
+					SL.Put(key, i+1)
+					owned[key] = i + 1
+				}
+			}
+			results[g] = owned
+		}(g)
+	}
+	wg.Wait()
+
+	keys := []int{}
+	for _, owned := range results {
+		for key, val := range owned {
+			keys = append(keys, key)
+			if actual, ok := SL.Get(key); !ok || actual.(int) != val {
+				t.Fatal("Key " + sc.Itoa(key) + " did not hold the last value put by its goroutine. ")
+			}
+		}
+	}
+	sort.Ints(keys)
+	checkKeys(t, "Concurrent skip list", SL.Keys(), keys)
+	if err := SL.Verify(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConcurrentSkipListContention(t *testing.T) {
+	SL := NewConcurrentSkipList(0.5, 16, 1)
+	var inserted, deleted int64
+	var wg sync.WaitGroup
+
+	// every goroutine tries to insert then delete the same keys, each key must be inserted and deleted exactly once
+	for g := 0; g < nGoroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for key := 0; key < nRAND; key++ {
+				if SL.PutIfAbsent(key, g) {
+					atomic.AddInt64(&inserted, 1)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	if inserted != nRAND || SL.Size() != nRAND {
+		t.Fatal("PutIfAbsent succeeded " + sc.Itoa(int(inserted)) + " times for " + sc.Itoa(nRAND) + " keys. ")
+	}
+	if err := SL.Verify(); err != nil {
+		t.Fatal(err)
+	}
+
+	for g := 0; g < nGoroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for key := nRAND - 1; key >= 0; key-- {
+				if SL.Delete(key) {
+					atomic.AddInt64(&deleted, 1)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	if deleted != nRAND || SL.Size() != 0 || len(SL.Keys()) != 0 {
+		t.Fatal("Delete succeeded " + sc.Itoa(int(deleted)) + " times for " + sc.Itoa(nRAND) + " keys. ")
+	}
+	if err := SL.Verify(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConcurrentSkipListReaders(t *testing.T) {
+	SL := NewConcurrentSkipList(0.5, 16, 1)
+	// even keys are never changed, odd keys are put and deleted by the writers
+	for key := 0; key < nRAND*2; key += 2 {
+		SL.Put(key, key)
+	}
+	var stop int32
+	var writers, readers sync.WaitGroup
+	for g := 0; g < nGoroutines/2; g++ {
+		writers.Add(1)
+		go func(g int) {
+			defer writers.Done()
+			r := rand.New(rand.NewSource(int64(g)))
+			for i := 0; i < nRAND*20; i++ {
+				key := r.Intn(nRAND)*2 + 1
+				if r.Intn(2) == 0 {
+					SL.Put(key, key)
+				} else {
+					SL.Delete(key)
+				}
+			}
+		}(g)
+	}
+	for g := 0; g < nGoroutines/2; g++ {
+		readers.Add(1)
+		go func(g int) {
+			defer readers.Done()
+			r := rand.New(rand.NewSource(int64(g)))
+			for atomic.LoadInt32(&stop) == 0 {
+				key := r.Intn(nRAND) * 2
+				if val, ok := SL.Get(key); !ok || val.(int) != key {
+					t.Error("Reader could not get the unchanged key " + sc.Itoa(key) + ". ")
+					return
+				}
+				prev, even := -1, 0
+				SL.Range(key, key+nRAND/2, func(k int, v interface{}) bool {
+					if k <= prev || v.(int) != k {
+						t.Error("Range returned key " + sc.Itoa(k) + " after " + sc.Itoa(prev) + " or with the wrong value. ")
+					}
+					if k%2 == 0 {
+						even++
+					}
+					prev = k
+					return true
+				})
+				// a weakly consistent range still sees every key that was there the whole time
+				if expected := (min(key+nRAND/2, nRAND*2-2)-key)/2 + 1; even != expected {
+					t.Error("Range from " + sc.Itoa(key) + " saw " + sc.Itoa(even) + " unchanged keys but expected " + sc.Itoa(expected))
+					return
+				}
+			}
+		}(g)
+	}
+	writers.Wait()
+	atomic.StoreInt32(&stop, 1)
+	readers.Wait()
+	if err := SL.Verify(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// csOp is one operation on a single key of a concurrent skip list, with the logical times it was called and returned
+type csOp struct {
+	kind      int
+	arg       int
+	ok        bool
+	out       int
+	call, ret int64
+}
+
+const (
+	csOpSwap = iota
+	csOpPutIfAbsent
+	csOpGet
+	csOpDelete
+)
+
+// csState is the model of a single key: whether it is in the map and its value
+type csState struct {
+	present bool
+	val     int
+}
+
+// step applies op to the model and returns the new state, and whether op returned what the model says it should have
+func (s csState) step(op csOp) (csState, bool) {
+	switch op.kind {
+	case csOpSwap:
+		return csState{present: true, val: op.arg}, op.ok == s.present && (!op.ok || op.out == s.val)
+	case csOpPutIfAbsent:
+		if s.present {
+			return s, !op.ok
+		}
+		return csState{present: true, val: op.arg}, op.ok
+	case csOpGet:
+		return s, op.ok == s.present && (!op.ok || op.out == s.val)
+	default:
+		return csState{}, op.ok == s.present
+	}
+}
+
+// linearizable searches for an order of ops that respects their call and return times and is legal for the model, the Wing and Gong algorithm with memoization
+func linearizable(ops []csOp) bool {
+	type visit struct {
+		done  uint64
+		state csState
+	}
+	failed := map[visit]bool{}
+	var search func(done uint64, state csState) bool
+	search = func(done uint64, state csState) bool {
+		if done == 1<<len(ops)-1 {
+			return true
+		}
+		if failed[visit{done, state}] {
+			return false
+		}
+		// an op can go next only if it was called before every remaining op returned
+		firstRet := int64(1 << 62)
+		for i, op := range ops {
+			if done&(1<<i) == 0 && op.ret < firstRet {
+				firstRet = op.ret
+			}
+		}
+		for i, op := range ops {
+			if done&(1<<i) != 0 || op.call > firstRet {
+				continue
+			}
+			if next, legal := state.step(op); legal && search(done|1<<i, next) {
+				return true
+			}
+		}
+		failed[visit{done, state}] = true
+		return false
+	}
+	return search(0, csState{})
+}
+
+func TestConcurrentSkipListLinearizable(t *testing.T) {
+	workers, perWorker := 4, 6
+	for round := 0; round < nRAND*2; round++ {
+		SL := NewConcurrentSkipList(0.5, 8, int64(round))
+		for key := -nRAND/10 + 1; key < nRAND/10; key += 2 {
+			SL.Put(key, key)
+		}
+		var clock int64
+		var wg sync.WaitGroup
+		history := make([][]csOp, workers)
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func(w int) {
+				defer wg.Done()
+				r := rand.New(rand.NewSource(int64(round*workers + w)))
+				for i := 0; i < perWorker; i++ {
+					op := csOp{kind: r.Intn(4), arg: w*perWorker + i + 1}
+					op.call = atomic.AddInt64(&clock, 1)
+					switch op.kind {
+					case csOpSwap:
+						var prev interface{}
+						if prev, op.ok = SL.Swap(0, op.arg); op.ok {
+							op.out = prev.(int)
+						}
+					case csOpPutIfAbsent:
+						op.ok = SL.PutIfAbsent(0, op.arg)
+					case csOpGet:
+						var val interface{}
+						if val, op.ok = SL.Get(0); op.ok {
+							op.out = val.(int)
+						}
+					case csOpDelete:
+						op.ok = SL.Delete(0)
+					}
+					op.ret = atomic.AddInt64(&clock, 1)
+					history[w] = append(history[w], op)
+					// change the neighbours of the key so its links keep changing too
+					neighbour := r.Intn(nRAND/5) - nRAND/10
+					if neighbour != 0 {
+						SL.Delete(neighbour)
+						SL.Put(neighbour, neighbour)
+					}
+				}
+			}(w)
+		}
+		wg.Wait()
+		ops := []csOp{}
+		for _, h := range history {
+			ops = append(ops, h...)
+		}
+		if !linearizable(ops) {
+			t.Fatal("History of round " + sc.Itoa(round) + " on one key is not linearizable. ")
+		}
+		if err := SL.Verify(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestConcurrentSkipListLinearizableChecker(t *testing.T) {
+	// a Get that returns a value after a Delete of it has returned can not be ordered
+	ops := []csOp{
+		{kind: csOpSwap, arg: 1, call: 1, ret: 2},
+		{kind: csOpDelete, ok: true, call: 3, ret: 4},
+		{kind: csOpGet, ok: true, out: 1, call: 5, ret: 6},
+	}
+	if linearizable(ops) {
+		t.Fatal("Checker accepted a history that is not linearizable. ")
+	}
+	// when the Get overlaps the Delete it can be ordered first
+	ops[2].call = 3
+	if !linearizable(ops) {
+		t.Fatal("Checker rejected a history that is linearizable. ")
+	}
+}
+
+func BenchmarkConcurrentSkipListParallelGet(b *testing.B) {
+	SL := NewConcurrentSkipList(0.25, 32, 1)
+	for _, k := range rand.Perm(1 << 16) {
+		SL.Put(k, k)
+	}
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		r := rand.New(rand.NewSource(rand.Int63()))
+		for pb.Next() {
+			SL.Get(r.Intn(1 << 16))
+		}
+	})
+}
+
+func BenchmarkConcurrentSkipListParallelMixed(b *testing.B) {
+	SL := NewConcurrentSkipList(0.25, 32, 1)
+	for _, k := range rand.Perm(1 << 16) {
+		SL.Put(k, k)
+	}
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		r := rand.New(rand.NewSource(rand.Int63()))
+		for pb.Next() {
+			key := r.Intn(1 << 16)
+			// one write for every nine reads
+			if r.Intn(10) == 0 {
+				SL.Put(key, key)
+			} else {
+				SL.Get(key)
+			}
+		}
+	})
+}
@@ -0,0 +1,313 @@
+package GoTrees
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// ChangeKind is the kind of a Change between two trees
+type ChangeKind int
+
+const (
+	// Added is a key-value that is only in the new tree
+	Added ChangeKind = iota
+	// Removed is a key-value that is only in the old tree
+	Removed
+	// Changed is a key in both trees with different values
+	Changed
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	case Changed:
+		return "changed"
+	}
+	return "unknown"
+}
+
+// Change is one difference between an old and a new tree. Old is nil for Added and New is nil for Removed.
+// Index is the position of the occurrence among the duplicates of Key: in the old tree for Removed and Changed, in the new tree for Added. It is 0 for a key without duplicates.
+type Change struct {
+	Kind     ChangeKind
+	Key      int
+	Index    int
+	Old, New interface{}
+}
+
+// String formats the change as one line for a log: "+ key: new", "- key: old" or "~ key: old -> new". A duplicate after the first is written key[index].
+func (c Change) String() string {
+	key := strconv.Itoa(c.Key)
+	if c.Index > 0 {
+		key += "[" + strconv.Itoa(c.Index) + "]"
+	}
+	switch c.Kind {
+	case Added:
+		return "+ " + key + ": " + fmt.Sprint(c.New)
+	case Removed:
+		return "- " + key + ": " + fmt.Sprint(c.Old)
+	case Changed:
+		return "~ " + key + ": " + fmt.Sprint(c.Old) + " -> " + fmt.Sprint(c.New)
+	}
+	return "? " + key
+}
+
+// Patch is a list of changes in key order, as made by Diff, that can be replayed onto a tree with Apply.
+type Patch []Change
+
+// String formats every change on its own line.
+func (p Patch) String() string {
+	str := ""
+	for _, c := range p {
+		str += c.String() + "\n"
+	}
+	return str
+}
+
+// Diffable is a tree that Diff can compare, a *BTree, *BSTree or *SplayTree.
+type Diffable interface {
+	// iterator returns a function that returns each key-value in order, then false once there are none left
+	iterator() func() (int, interface{}, bool)
+}
+
+// Diff streams the changes that turn a into b to fn in key order, until fn returns false. The trees can be of different kinds.
+// Both trees are walked in order at the same time, like a merge, so only the paths to the current keys are held in memory. Values are compared with reflect.DeepEqual, the same rule as BTree.Diff.
+// Duplicate keys are paired up by position, extra occurrences in either tree are removed or added, and each change records the position it is for in Index.
+func Diff(a, b Diffable, fn func(c Change) bool) {
+	nextA, nextB := a.iterator(), b.iterator()
+	// indexA and indexB are the positions of the current key-values among the duplicates of their keys
+	indexA, indexB := 0, 0
+	keyA, valA, okA := nextA()
+	keyB, valB, okB := nextB()
+	advanceA := func() {
+		prev := keyA
+		keyA, valA, okA = nextA()
+		if okA && keyA == prev {
+			indexA++
+		} else {
+			indexA = 0
+		}
+	}
+	advanceB := func() {
+		prev := keyB
+		keyB, valB, okB = nextB()
+		if okB && keyB == prev {
+			indexB++
+		} else {
+			indexB = 0
+		}
+	}
+	for okA || okB {
+		var c Change
+		if okA && (!okB || keyA < keyB) {
+			c = Change{Kind: Removed, Key: keyA, Index: indexA, Old: valA}
+			advanceA()
+		} else if okB && (!okA || keyB < keyA) {
+			c = Change{Kind: Added, Key: keyB, Index: indexB, New: valB}
+			advanceB()
+		} else {
+			same := reflect.DeepEqual(valA, valB)
+			c = Change{Kind: Changed, Key: keyA, Index: indexA, Old: valA, New: valB}
+			advanceA()
+			advanceB()
+			if same {
+				continue
+			}
+		}
+		if !fn(c) {
+			return
+		}
+	}
+}
+
+// NewPatch collects every change Diff finds between a and b.
+func NewPatch(a, b Diffable) Patch {
+	patch := Patch{}
+	Diff(a, b, func(c Change) bool {
+		patch = append(patch, c)
+		return true
+	})
+	return patch
+}
+
+// Apply replays patch onto bt: added keys are inserted, removed keys are deleted and changed keys are set with Put.
+// Duplicate keys are changed at the position in Index, see applyKeyChanges. It stops at the first change that does not fit the tree and returns an error for it, the keys before it stay changed.
+func (bt *BTree) Apply(patch Patch) error {
+	return applyPatch(bt, patch)
+}
+
+// Apply replays patch onto bst: added keys are inserted, removed keys are deleted and changed keys are set with Put.
+// Duplicate keys are changed at the position in Index, see applyKeyChanges. It stops at the first change that does not fit the tree and returns an error for it, the keys before it stay changed.
+func (bst *BSTree) Apply(patch Patch) error {
+	return applyPatch(bst, patch)
+}
+
+// patchable is the part of a tree applyPatch needs
+type patchable interface {
+	Insert(key int, value interface{})
+	Put(key int, value interface{})
+	Delete(key int) bool
+	Contains(key int) bool
+	FindAll(key int) []interface{}
+	DeleteAll(key int) int
+}
+
+// applyPatch applies the changes to each key together, since a key with duplicates needs all of its changes to know which occurrence each one is for
+func applyPatch(tree patchable, patch Patch) error {
+	for i := 0; i < len(patch); {
+		j := i + 1
+		for j < len(patch) && patch[j].Key == patch[i].Key {
+			j++
+		}
+		if err := applyKeyChanges(tree, patch[i:j], i); err != nil {
+			return err
+		}
+		i = j
+	}
+	return nil
+}
+
+// applyKeyChanges applies the changes to one key, first is the index of the first of them in the patch. A key without duplicates on either side is changed in place,
+// otherwise each change is applied to the occurrence at its Index and the occurrences are deleted and inserted again in their new order, so all or none of the changes are applied.
+func applyKeyChanges(tree patchable, changes []Change, first int) error {
+	key := changes[0].Key
+	values := tree.FindAll(key)
+	duplicates := len(values) > 1
+	for _, c := range changes {
+		duplicates = duplicates || c.Index > 0
+	}
+	if !duplicates {
+		return applyChanges(tree, changes, first)
+	}
+	// Removed and Changed indexes are positions in values, Added indexes are positions in the result
+	result := append([]interface{}{}, values...)
+	removed := make([]bool, len(values))
+	added := []Change{}
+	for n, c := range changes {
+		i := strconv.Itoa(first + n)
+		if c.Kind == Added {
+			added = append(added, c)
+			continue
+		}
+		if c.Kind != Removed && c.Kind != Changed {
+			return errors.New("change " + i + " has unknown kind " + strconv.Itoa(int(c.Kind)))
+		}
+		if c.Index < 0 || c.Index >= len(values) || removed[c.Index] {
+			return errors.New("change " + i + " is for occurrence " + strconv.Itoa(c.Index) + " of key " + strconv.Itoa(key) + " but the tree has " + strconv.Itoa(len(values)))
+		}
+		if !reflect.DeepEqual(result[c.Index], c.Old) {
+			return errors.New("change " + i + " expects " + fmt.Sprint(c.Old) + " at occurrence " + strconv.Itoa(c.Index) + " of key " + strconv.Itoa(key) + " but it is " + fmt.Sprint(result[c.Index]))
+		}
+		if c.Kind == Removed {
+			removed[c.Index] = true
+		} else {
+			result[c.Index] = c.New
+		}
+	}
+	kept := []interface{}{}
+	for j, value := range result {
+		if !removed[j] {
+			kept = append(kept, value)
+		}
+	}
+	for _, c := range added {
+		if c.Index < 0 || c.Index > len(kept) {
+			return errors.New("change adds occurrence " + strconv.Itoa(c.Index) + " of key " + strconv.Itoa(key) + " but there are only " + strconv.Itoa(len(kept)) + " before it")
+		}
+		kept = append(kept, nil)
+		copy(kept[c.Index+1:], kept[c.Index:])
+		kept[c.Index] = c.New
+	}
+	tree.DeleteAll(key)
+	for _, value := range kept {
+		tree.Insert(key, value)
+	}
+	return nil
+}
+
+// applyChanges applies each change on its own, for a key without duplicates
+func applyChanges(tree patchable, changes []Change, first int) error {
+	for n, c := range changes {
+		i := strconv.Itoa(first + n)
+		switch c.Kind {
+		case Added:
+			tree.Insert(c.Key, c.New)
+		case Removed:
+			if !tree.Delete(c.Key) {
+				return errors.New("change " + i + " removes key " + strconv.Itoa(c.Key) + " which is not in the tree")
+			}
+		case Changed:
+			if !tree.Contains(c.Key) {
+				return errors.New("change " + i + " changes key " + strconv.Itoa(c.Key) + " which is not in the tree")
+			}
+			tree.Put(c.Key, c.New)
+		default:
+			return errors.New("change " + i + " has unknown kind " + strconv.Itoa(int(c.Kind)))
+		}
+	}
+	return nil
+}
+
+// iterator walks the B-Tree in order with a stack of the nodes on the path to the next key-value
+func (bt *BTree) iterator() func() (int, interface{}, bool) {
+	type frame struct {
+		btn *bTreeNode
+		i   int
+	}
+	stack := []frame{}
+	pushLeft := func(btn *bTreeNode) {
+		for {
+			stack = append(stack, frame{btn: btn})
+			if btn.numChildren == 0 {
+				return
+			}
+			btn = btn.children[0]
+		}
+	}
+	if bt.root != nil {
+		pushLeft(bt.root)
+	}
+	return func() (int, interface{}, bool) {
+		for len(stack) > 0 {
+			top := &stack[len(stack)-1]
+			if top.i == top.btn.length {
+				stack = stack[:len(stack)-1]
+				continue
+			}
+			kv := top.btn.nodes[top.i]
+			top.i++
+			if top.btn.numChildren > 0 {
+				// the child after this key-value comes next
+				pushLeft(top.btn.children[top.i])
+			}
+			return kv.key, kv.value, true
+		}
+		return 0, nil, false
+	}
+}
+
+// iterator walks the BST in order with a stack of the nodes whose right subtree is still to be visited
+func (bst *BSTree) iterator() func() (int, interface{}, bool) {
+	stack := []*node{}
+	pushLeft := func(n *node) {
+		for n != nil {
+			stack = append(stack, n)
+			n = n.Left
+		}
+	}
+	pushLeft(bst.root)
+	return func() (int, interface{}, bool) {
+		if len(stack) == 0 {
+			return 0, nil, false
+		}
+		n := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		pushLeft(n.Right)
+		return n.Key, n.Val, true
+	}
+}
@@ -0,0 +1,241 @@
+package GoTrees
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	sc "strconv"
+	"testing"
+)
+
+// randomVersions returns two maps of unique keys where the second is the first with some keys added, removed and changed
+func randomVersions() (map[int]int, map[int]int) {
+	a := map[int]int{}
+	b := map[int]int{}
+	for i := 0; i < nRAND; i++ {
+		key := rand.Intn(nRAND * 2)
+		a[key], b[key] = i, i
+	}
+	for i := 0; i < nRAND/2; i++ {
+		key := rand.Intn(nRAND * 2)
+		if rand.Intn(2) == 0 {
+			b[key] = -i
+		} else {
+			delete(b, key)
+		}
+	}
+	return a, b
+}
+
+// expectedPatch works out the patch from a to b by checking every key
+func expectedPatch(a, b map[int]int) Patch {
+	keys := []int{}
+	for key := range a {
+		keys = append(keys, key)
+	}
+	for key := range b {
+		if _, ok := a[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Ints(keys)
+	patch := Patch{}
+	for _, key := range keys {
+		old, inA := a[key]
+		new, inB := b[key]
+		if !inB {
+			patch = append(patch, Change{Kind: Removed, Key: key, Old: old})
+		} else if !inA {
+			patch = append(patch, Change{Kind: Added, Key: key, New: new})
+		} else if old != new {
+			patch = append(patch, Change{Kind: Changed, Key: key, Old: old, New: new})
+		}
+	}
+	return patch
+}
+
+func checkPatch(t *testing.T, name string, actual, expected Patch) {
+	if len(actual) != len(expected) {
+		t.Fatal(name + " returned " + sc.Itoa(len(actual)) + " changes but should have " + sc.Itoa(len(expected)))
+	}
+	for i := range actual {
+		if actual[i] != expected[i] {
+			t.Fatal(name + " change " + sc.Itoa(i) + " was " + actual[i].String() + " but should be " + expected[i].String())
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	for run := 0; run < 10; run++ {
+		a, b := randomVersions()
+		BTA, BTB := NewBTree(T, nAlloc), NewBTree(T, nAlloc)
+		BSTA, BSTB := NewBSTree(), NewBSTree()
+		for key, v := range a {
+			BTA.Insert(key, v)
+			BSTA.Insert(key, v)
+		}
+		for key, v := range b {
+			BTB.Insert(key, v)
+			BSTB.Insert(key, v)
+		}
+		expected := expectedPatch(a, b)
+		checkPatch(t, "B-Tree Diff", NewPatch(&BTA, &BTB), expected)
+		checkPatch(t, "BST Diff", NewPatch(&BSTA, &BSTB), expected)
+		checkPatch(t, "Mixed Diff", NewPatch(&BTA, &BSTB), expected)
+		checkPatch(t, "Mixed Diff", NewPatch(&BSTA, &BTB), expected)
+		if len(NewPatch(&BTA, &BSTA)) != 0 {
+			t.Fatal("Trees with the same key-values should have no changes. ")
+		}
+	}
+}
+
+func TestDiffStops(t *testing.T) {
+	A := NewBTree(T, nAlloc)
+	B := NewBSTree()
+	for i := 0; i < 10; i++ {
+		B.Insert(i, i)
+	}
+	count := 0
+	Diff(&A, &B, func(c Change) bool {
+		count++
+		return count < 3
+	})
+	if count != 3 {
+		t.Fatal("Diff should stop once fn returns false. ")
+	}
+}
+
+func TestDiffDuplicates(t *testing.T) {
+	A := NewBTree(T, nAlloc)
+	B := NewBTree(T, nAlloc)
+	A.Insert(1, "a")
+	A.Insert(1, "b")
+	B.Insert(1, "a")
+	B.Insert(1, "c")
+	B.Insert(1, "d")
+	expected := Patch{{Kind: Changed, Key: 1, Index: 1, Old: "b", New: "c"}, {Kind: Added, Key: 1, Index: 2, New: "d"}}
+	checkPatch(t, "Duplicate Diff", NewPatch(&A, &B), expected)
+}
+
+func TestApply(t *testing.T) {
+	for run := 0; run < 10; run++ {
+		a, b := randomVersions()
+		BTA, BTB := NewBTree(T, nAlloc), NewBTree(T, nAlloc)
+		BST := NewBSTree()
+		for key, v := range a {
+			BTA.Insert(key, v)
+			BST.Insert(key, v)
+		}
+		for key, v := range b {
+			BTB.Insert(key, v)
+		}
+		patch := NewPatch(&BTA, &BTB)
+		if err := BTA.Apply(patch); err != nil {
+			t.Fatal("Failed to apply a patch to the B-Tree: " + err.Error())
+		}
+		if err := BST.Apply(patch); err != nil {
+			t.Fatal("Failed to apply a patch to the BST: " + err.Error())
+		}
+		if len(NewPatch(&BTA, &BTB)) != 0 || len(NewPatch(&BST, &BTB)) != 0 {
+			t.Fatal("Applying the patch should make the trees the same. ")
+		}
+		if err := BTA.Verify(); err != nil {
+			t.Fatal("B-Tree failed verification after applying a patch: " + err.Error())
+		}
+	}
+
+	BT := NewBTree(T, nAlloc)
+	BT.Insert(1, "a")
+	err := BT.Apply(Patch{{Kind: Added, Key: 2, New: "b"}, {Kind: Removed, Key: 3, Old: "c"}, {Kind: Added, Key: 4, New: "d"}})
+	if err == nil {
+		t.Fatal("Removing a key that is not in the tree should fail. ")
+	}
+	if !BT.Contains(2) || BT.Contains(4) {
+		t.Fatal("Apply should stop at the change that failed. ")
+	}
+	if BT.Apply(Patch{{Kind: Changed, Key: 5, Old: "e", New: "f"}}) == nil {
+		t.Fatal("Changing a key that is not in the tree should fail. ")
+	}
+}
+
+func TestApplyDuplicates(t *testing.T) {
+	A, B := NewBTree(T, nAlloc), NewBTree(T, nAlloc)
+	for _, v := range []interface{}{7, "x", "y", "z"} {
+		A.Insert(7, v)
+	}
+	B.Insert(7, 7)
+	B.Insert(7, "x")
+	patch := NewPatch(&A, &B)
+	if err := A.Apply(patch); err != nil {
+		t.Fatal("Failed to apply a patch removing duplicates: " + err.Error())
+	}
+	if len(NewPatch(&A, &B)) != 0 {
+		t.Fatal("Applying " + patch.String() + " left the duplicates " + fmt.Sprint(A.FindAll(7)))
+	}
+
+	for run := 0; run < 10; run++ {
+		// a few keys with many duplicates, and few values so equal values repeat under a key
+		BTA, BTB := NewBTree(T, nAlloc), NewBTree(T, nAlloc)
+		BST := NewBSTree()
+		for i := 0; i < nRAND; i++ {
+			key, value := rand.Intn(5), rand.Intn(3)
+			BTA.Insert(key, value)
+			BST.Insert(key, value)
+			switch rand.Intn(3) {
+			case 0:
+				BTB.Insert(key, value)
+			case 1:
+				BTB.Insert(key, rand.Intn(3))
+			}
+			if rand.Intn(5) == 0 {
+				BTB.Insert(rand.Intn(5), rand.Intn(3))
+			}
+		}
+		patch := NewPatch(&BTA, &BTB)
+		// the B-Tree Diff finds the same positions as the package Diff
+		checkPatch(t, "B-Tree Diff of duplicates", BTA.Diff(&BTB), patch)
+		if err := BTA.Apply(patch); err != nil {
+			t.Fatal("Failed to apply a patch to the B-Tree: " + err.Error())
+		}
+		if err := BST.Apply(patch); err != nil {
+			t.Fatal("Failed to apply a patch to the BST: " + err.Error())
+		}
+		if len(NewPatch(&BTA, &BTB)) != 0 || len(NewPatch(&BST, &BTB)) != 0 {
+			t.Fatal("Applying the patch should put the duplicates in the same order. ")
+		}
+		if err := BTA.Verify(); err != nil {
+			t.Fatal("B-Tree failed verification after applying a patch: " + err.Error())
+		}
+	}
+
+	// equal values under one key are told apart by their position
+	BT := NewBTree(T, nAlloc)
+	BT.Insert(5, 1)
+	BT.Insert(5, 1)
+	BT2 := NewBTree(T, nAlloc)
+	BT2.Insert(5, 1)
+	BT2.Insert(5, 2)
+	patch = NewPatch(&BT, &BT2)
+	checkPatch(t, "Equal duplicates", patch, Patch{{Kind: Changed, Key: 5, Index: 1, Old: 1, New: 2}})
+	if err := BT.Apply(patch); err != nil {
+		t.Fatal("Failed to apply a patch to equal duplicates: " + err.Error())
+	}
+	checkKeys(t, "Equal duplicates after the patch", intValues(BT.FindAll(5)), []int{1, 2})
+
+	// a change whose old value is not at its position is refused and the tree left as it was
+	if BT.Apply(Patch{{Kind: Changed, Key: 5, Index: 0, Old: 2, New: 3}}) == nil {
+		t.Fatal("A change for an occurrence with a different value should fail. ")
+	}
+	if BT.Apply(Patch{{Kind: Removed, Key: 5, Index: 2, Old: 1}}) == nil {
+		t.Fatal("Removing an occurrence past the end of the duplicates should fail. ")
+	}
+	checkKeys(t, "Duplicates after refused patches", intValues(BT.FindAll(5)), []int{1, 2})
+}
+
+func TestPatchString(t *testing.T) {
+	patch := Patch{{Kind: Added, Key: 1, New: "a"}, {Kind: Removed, Key: 2, Old: 5}, {Kind: Changed, Key: 3, Old: nil, New: true}}
+	expected := "+ 1: a\n- 2: 5\n~ 3: <nil> -> true\n"
+	if patch.String() != expected {
+		t.Fatal("Patch string was incorrect, got " + sc.Quote(patch.String()))
+	}
+}
@@ -0,0 +1,147 @@
+package GoTrees
+
+// Number is the set of types a FenwickTree can sum
+type Number interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 | ~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr | ~float32 | ~float64
+}
+
+// FenwickTree (binary indexed tree) keeps prefix sums of an indexed array in a single slice, with O(log n) updates and queries.
+// Indexes start at 0 and ranges are inclusive on both ends.
+type FenwickTree[T Number] struct {
+	// tree is 1 indexed, tree[i] holds the sum of the lowbit(i) values ending at index i-1
+	tree []T
+}
+
+// NewFenwickTree returns a Fenwick tree of n zeros.
+func NewFenwickTree[T Number](n int) FenwickTree[T] {
+	return FenwickTree[T]{tree: make([]T, n+1)}
+}
+
+// NewFenwickTreeFromSlice builds a Fenwick tree over values in O(n).
+func NewFenwickTreeFromSlice[T Number](values []T) FenwickTree[T] {
+	ft := NewFenwickTree[T](len(values))
+	copy(ft.tree[1:], values)
+	for i := 1; i < len(ft.tree); i++ {
+		// push each partial sum up to the next node that covers it
+		if parent := i + (i & -i); parent < len(ft.tree) {
+			ft.tree[parent] += ft.tree[i]
+		}
+	}
+	return ft
+}
+
+// Len returns the number of indexes in the Fenwick tree.
+func (ft *FenwickTree[T]) Len() int {
+	return len(ft.tree) - 1
+}
+
+// Add adds delta to the value at index i. Out of range indexes are ignored.
+func (ft *FenwickTree[T]) Add(i int, delta T) {
+	if i < 0 {
+		return
+	}
+	for i++; i < len(ft.tree); i += i & -i {
+		ft.tree[i] += delta
+	}
+}
+
+// PrefixSum returns the sum of the values from index 0 to i. i is clipped to the indexes of the tree.
+func (ft *FenwickTree[T]) PrefixSum(i int) T {
+	var sum T
+	if i >= ft.Len() {
+		i = ft.Len() - 1
+	}
+	for i++; i > 0; i -= i & -i {
+		sum += ft.tree[i]
+	}
+	return sum
+}
+
+// RangeSum returns the sum of the values from index lo to hi, or 0 for an empty range.
+func (ft *FenwickTree[T]) RangeSum(lo, hi int) T {
+	if lo > hi {
+		var zero T
+		return zero
+	}
+	return ft.PrefixSum(hi) - ft.PrefixSum(lo-1)
+}
+
+// Get returns the value at index i.
+func (ft *FenwickTree[T]) Get(i int) T {
+	return ft.RangeSum(i, i)
+}
+
+// LowerBound returns the smallest index i with PrefixSum(i) >= sum, or Len() if there is none. Every value must be non-negative so the prefix sums are sorted.
+func (ft *FenwickTree[T]) LowerBound(sum T) int {
+	var zero T
+	if sum <= zero {
+		return 0
+	}
+	pos := 0
+	step := 1
+	for step*2 < len(ft.tree) {
+		step *= 2
+	}
+	// descend the implicit tree, taking every block whose total is still below sum
+	for ; step > 0; step /= 2 {
+		if next := pos + step; next < len(ft.tree) && ft.tree[next] < sum {
+			pos = next
+			sum -= ft.tree[next]
+		}
+	}
+	return pos
+}
+
+// FenwickTree2D keeps prefix sums of a 2D grid, with O(log rows * log cols) updates and queries.
+// Indexes start at 0 and ranges are inclusive on both ends.
+type FenwickTree2D[T Number] struct {
+	rows, cols int
+	tree       [][]T
+}
+
+// NewFenwickTree2D returns a 2D Fenwick tree of rows x cols zeros.
+func NewFenwickTree2D[T Number](rows, cols int) FenwickTree2D[T] {
+	tree := make([][]T, rows+1)
+	for i := range tree {
+		tree[i] = make([]T, cols+1)
+	}
+	return FenwickTree2D[T]{rows: rows, cols: cols, tree: tree}
+}
+
+// Add adds delta to the value at (row, col). Out of range indexes are ignored.
+func (ft *FenwickTree2D[T]) Add(row, col int, delta T) {
+	if row < 0 || col < 0 {
+		return
+	}
+	for i := row + 1; i <= ft.rows; i += i & -i {
+		for j := col + 1; j <= ft.cols; j += j & -j {
+			ft.tree[i][j] += delta
+		}
+	}
+}
+
+// PrefixSum returns the sum of every value from (0, 0) to (row, col). The indexes are clipped to the grid.
+func (ft *FenwickTree2D[T]) PrefixSum(row, col int) T {
+	var sum T
+	if row >= ft.rows {
+		row = ft.rows - 1
+	}
+	if col >= ft.cols {
+		col = ft.cols - 1
+	}
+	for i := row + 1; i > 0; i -= i & -i {
+		for j := col + 1; j > 0; j -= j & -j {
+			sum += ft.tree[i][j]
+		}
+	}
+	return sum
+}
+
+// RangeSum returns the sum of every value in the rectangle from (row1, col1) to (row2, col2), or 0 for an empty rectangle.
+func (ft *FenwickTree2D[T]) RangeSum(row1, col1, row2, col2 int) T {
+	if row1 > row2 || col1 > col2 {
+		var zero T
+		return zero
+	}
+	return ft.PrefixSum(row2, col2) - ft.PrefixSum(row1-1, col2) - ft.PrefixSum(row2, col1-1) + ft.PrefixSum(row1-1, col1-1)
+}
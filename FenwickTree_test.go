@@ -0,0 +1,117 @@
+package GoTrees
+
+import (
+	"math/rand"
+	sc "strconv"
+	"testing"
+)
+
+func TestFenwickTreePrefixSum(t *testing.T) {
+	values := make([]int, nRAND)
+	ft := NewFenwickTree[int](nRAND)
+
+	for i := 0; i < nRAND*10; i++ {
+		index := rand.Intn(nRAND)
+		delta := rand.Intn(nRAND) - nRAND/2
+		values[index] += delta
+		ft.Add(index, delta)
+
+		index = rand.Intn(nRAND)
+		expected := 0
+		for _, v := range values[:index+1] {
+			expected += v
+		}
+		if sum := ft.PrefixSum(index); sum != expected {
+			t.Fatal("Prefix sum to " + sc.Itoa(index) + " was " + sc.Itoa(sum) + " but expected " + sc.Itoa(expected))
+		}
+	}
+	if ft.PrefixSum(-1) != 0 || ft.PrefixSum(nRAND*2) != ft.PrefixSum(nRAND-1) {
+		t.Fatal("Out of range prefix sums were not clipped. ")
+	}
+}
+
+func TestFenwickTreeRangeSum(t *testing.T) {
+	values := make([]float64, nRAND)
+	for i := range values {
+		values[i] = float64(rand.Intn(nRAND)) / 4
+	}
+	ft := NewFenwickTreeFromSlice(values)
+
+	if ft.Len() != nRAND {
+		t.Fatal("Fenwick tree length incorrect, expected " + sc.Itoa(nRAND) + " but got " + sc.Itoa(ft.Len()))
+	}
+	for lo := 0; lo < nRAND; lo++ {
+		expected := 0.0
+		for hi := lo; hi < nRAND; hi++ {
+			expected += values[hi]
+			if sum := ft.RangeSum(lo, hi); sum != expected {
+				t.Fatal("Range sum of [" + sc.Itoa(lo) + ", " + sc.Itoa(hi) + "] was " + sc.FormatFloat(sum, 'f', 2, 64) + " but expected " + sc.FormatFloat(expected, 'f', 2, 64))
+			}
+		}
+		if ft.Get(lo) != values[lo] {
+			t.Fatal("Get returned the wrong value at " + sc.Itoa(lo))
+		}
+	}
+	if ft.RangeSum(5, 4) != 0 {
+		t.Fatal("An empty range did not sum to 0. ")
+	}
+}
+
+func TestFenwickTreeLowerBound(t *testing.T) {
+	values := make([]uint, nRAND)
+	for i := range values {
+		// zeros make several indexes share a prefix sum
+		values[i] = uint(rand.Intn(3))
+	}
+	ft := NewFenwickTreeFromSlice(values)
+
+	total := ft.PrefixSum(nRAND - 1)
+	for target := uint(0); target <= total+1; target++ {
+		expected := 0
+		sum := uint(0)
+		for expected < nRAND {
+			sum += values[expected]
+			if sum >= target {
+				break
+			}
+			expected++
+		}
+		if target == 0 {
+			expected = 0
+		}
+		if i := ft.LowerBound(target); i != expected {
+			t.Fatal("Lower bound of " + sc.Itoa(int(target)) + " was " + sc.Itoa(i) + " but expected " + sc.Itoa(expected))
+		}
+	}
+}
+
+func TestFenwickTree2D(t *testing.T) {
+	rows, cols := 12, 17
+	grid := make([][]int64, rows)
+	for i := range grid {
+		grid[i] = make([]int64, cols)
+	}
+	ft := NewFenwickTree2D[int64](rows, cols)
+
+	for i := 0; i < nRAND*5; i++ {
+		r, c := rand.Intn(rows), rand.Intn(cols)
+		delta := int64(rand.Intn(nRAND) - nRAND/2)
+		grid[r][c] += delta
+		ft.Add(r, c, delta)
+
+		r1, c1 := rand.Intn(rows), rand.Intn(cols)
+		r2, c2 := r1+rand.Intn(rows-r1), c1+rand.Intn(cols-c1)
+		expected := int64(0)
+		for r := r1; r <= r2; r++ {
+			for c := c1; c <= c2; c++ {
+				expected += grid[r][c]
+			}
+		}
+		if sum := ft.RangeSum(r1, c1, r2, c2); sum != expected {
+			t.Fatal("2D range sum was " + sc.Itoa(int(sum)) + " but expected " + sc.Itoa(int(expected)))
+		}
+	}
+	if ft.RangeSum(3, 3, 2, 5) != 0 {
+		t.Fatal("An empty rectangle did not sum to 0. ")
+	}
+}
@@ -0,0 +1,195 @@
+package GoTrees
+
+import (
+	"errors"
+	"strconv"
+)
+
+// HeapHandle is a key-value in one of the heaps. It is returned by Push so the key can later be changed with DecreaseKey, and by Peek and Pop.
+// A handle belongs to the heap it was pushed to (or merged into) until it is popped.
+type HeapHandle struct {
+	key int
+	val interface{}
+	// index is the position in an array heap, or 0 in a pairing heap. It is -1 once the handle has been popped.
+	index int
+	// child, next and prev link the nodes of a pairing heap. prev is the parent of a first child, otherwise the previous sibling.
+	child, next, prev *HeapHandle
+}
+
+func (h *HeapHandle) Key() int {
+	return h.key
+}
+
+func (h *HeapHandle) Value() interface{} {
+	return h.val
+}
+
+// minFirst is the default order of the heaps, smaller keys are popped first
+func minFirst(a, b int) bool {
+	return a < b
+}
+
+// DaryHeap is an array backed heap where each node has d children. A larger d makes the tree shallower, so DecreaseKey and Push are faster and Pop is slower.
+// Keys that are less than others by the heap's order are popped first, so it is a min heap by default and a max heap with a greater than function.
+type DaryHeap struct {
+	items []*HeapHandle
+	d     int
+	less  func(a, b int) bool
+}
+
+// NewDaryHeap returns an empty heap where each node has d children (at least 2). less orders the keys, nil makes it a min heap.
+func NewDaryHeap(d int, less func(a, b int) bool) DaryHeap {
+	if d < 2 {
+		d = 2
+	}
+	if less == nil {
+		less = minFirst
+	}
+	return DaryHeap{items: []*HeapHandle{}, d: d, less: less}
+}
+
+// Push adds key with value to the heap and returns its handle.
+func (h *DaryHeap) Push(key int, value interface{}) *HeapHandle {
+	item := &HeapHandle{key: key, val: value, index: len(h.items)}
+	h.items = append(h.items, item)
+	h.up(item.index)
+	return item
+}
+
+// Heapify adds every key with its value (values may be nil) in linear time, by sifting down each parent from the last one up. It returns the handles in the order of keys.
+func (h *DaryHeap) Heapify(keys []int, values []interface{}) []*HeapHandle {
+	handles := make([]*HeapHandle, len(keys))
+	for i, key := range keys {
+		handles[i] = &HeapHandle{key: key, index: len(h.items)}
+		if values != nil {
+			handles[i].val = values[i]
+		}
+		h.items = append(h.items, handles[i])
+	}
+	h.heapify()
+	return handles
+}
+
+// heapify restores the heap order of the whole array
+func (h *DaryHeap) heapify() {
+	for i := (len(h.items) - 2) / h.d; i >= 0; i-- {
+		h.down(i)
+	}
+}
+
+// Peek returns the first key-value without removing it, or nil if the heap is empty.
+func (h *DaryHeap) Peek() *HeapHandle {
+	if len(h.items) == 0 {
+		return nil
+	}
+	return h.items[0]
+}
+
+// Pop removes and returns the first key-value, or nil if the heap is empty.
+func (h *DaryHeap) Pop() *HeapHandle {
+	if len(h.items) == 0 {
+		return nil
+	}
+	top := h.items[0]
+	last := len(h.items) - 1
+	h.swap(0, last)
+	h.items[last] = nil
+	h.items = h.items[:last]
+	if last > 0 {
+		h.down(0)
+	}
+	top.index = -1
+	return top
+}
+
+// DecreaseKey changes the key of handle to key, which must not come after the current key in the heap's order. It returns false without changing the heap if it would, or if handle has been popped.
+func (h *DaryHeap) DecreaseKey(handle *HeapHandle, key int) bool {
+	if handle.index < 0 || h.less(handle.key, key) {
+		return false
+	}
+	handle.key = key
+	h.up(handle.index)
+	return true
+}
+
+// Merge moves every key-value of other into h in linear time, other is left empty. The handles of other now belong to h.
+func (h *DaryHeap) Merge(other *DaryHeap) {
+	for _, item := range other.items {
+		item.index = len(h.items)
+		h.items = append(h.items, item)
+	}
+	other.Clear()
+	h.heapify()
+}
+
+// up moves the item at i up until its parent comes before it
+func (h *DaryHeap) up(i int) {
+	for i > 0 {
+		parent := (i - 1) / h.d
+		if !h.less(h.items[i].key, h.items[parent].key) {
+			return
+		}
+		h.swap(i, parent)
+		i = parent
+	}
+}
+
+// down moves the item at i down until none of its children come before it
+func (h *DaryHeap) down(i int) {
+	for {
+		first := i
+		for c := h.d*i + 1; c <= h.d*i+h.d && c < len(h.items); c++ {
+			if h.less(h.items[c].key, h.items[first].key) {
+				first = c
+			}
+		}
+		if first == i {
+			return
+		}
+		h.swap(i, first)
+		i = first
+	}
+}
+
+func (h *DaryHeap) swap(i, j int) {
+	h.items[i], h.items[j] = h.items[j], h.items[i]
+	h.items[i].index = i
+	h.items[j].index = j
+}
+
+// Clear clears the heap of all key-values.
+func (h *DaryHeap) Clear() {
+	h.items = []*HeapHandle{}
+}
+
+func (h *DaryHeap) Size() uint64 {
+	return uint64(len(h.items))
+}
+
+// Verify checks that no key comes before its parent and that every handle knows its index. It returns nil if the heap is valid, otherwise an error describing the first problem found.
+func (h *DaryHeap) Verify() error {
+	for i, item := range h.items {
+		if item.index != i {
+			return errors.New("handle at index " + strconv.Itoa(i) + " has index " + strconv.Itoa(item.index))
+		}
+		if parent := (i - 1) / h.d; i > 0 && h.less(item.key, h.items[parent].key) {
+			return errors.New("key " + strconv.Itoa(item.key) + " comes before its parent " + strconv.Itoa(h.items[parent].key))
+		}
+	}
+	return nil
+}
+
+// BinaryHeap is the DaryHeap with two children per node, the usual array backed priority queue.
+type BinaryHeap struct {
+	DaryHeap
+}
+
+// NewBinaryHeap returns an empty binary heap. less orders the keys, nil makes it a min heap.
+func NewBinaryHeap(less func(a, b int) bool) BinaryHeap {
+	return BinaryHeap{DaryHeap: NewDaryHeap(2, less)}
+}
+
+// Merge moves every key-value of other into h in linear time, other is left empty. The handles of other now belong to h.
+func (h *BinaryHeap) Merge(other *BinaryHeap) {
+	h.DaryHeap.Merge(&other.DaryHeap)
+}
@@ -0,0 +1,208 @@
+package GoTrees
+
+import (
+	"container/heap"
+	"math/rand"
+	"sort"
+	sc "strconv"
+	"testing"
+)
+
+// refItem and refHeap are a reference min heap built on container/heap
+type refItem struct {
+	key, index int
+}
+
+type refHeap []*refItem
+
+func (h refHeap) Len() int            { return len(h) }
+func (h refHeap) Less(i, j int) bool  { return h[i].key < h[j].key }
+func (h refHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i]; h[i].index = i; h[j].index = j }
+func (h *refHeap) Push(x interface{}) { x.(*refItem).index = len(*h); *h = append(*h, x.(*refItem)) }
+func (h *refHeap) Pop() interface{} {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
+
+// testHeap is the method set shared by the heaps
+type testHeap interface {
+	Push(key int, value interface{}) *HeapHandle
+	Pop() *HeapHandle
+	Peek() *HeapHandle
+	DecreaseKey(handle *HeapHandle, key int) bool
+	Size() uint64
+	Verify() error
+}
+
+// checkAgainstReference runs random pushes, pops and decrease keys on h and a container/heap, and fails if they ever disagree
+func checkAgainstReference(t *testing.T, name string, h testHeap) {
+	ref := &refHeap{}
+	handles := map[*HeapHandle]*refItem{}
+	live := []*HeapHandle{}
+	for i := 0; i < nRAND*10; i++ {
+		switch op := rand.Intn(4); {
+		case op < 2:
+			key := rand.Intn(nRAND)
+			handle := h.Push(key, i)
+			if handle.Value().(int) != i {
+				t.Fatal(name + " handle has the wrong value. ")
+			}
+			item := &refItem{key: key}
+			heap.Push(ref, item)
+			handles[handle] = item
+			live = append(live, handle)
+		case op == 2 && len(live) > 0:
+			j := rand.Intn(len(live))
+			handle := live[j]
+			key := handle.Key() - rand.Intn(nRAND/2)
+			if !h.DecreaseKey(handle, key) {
+				t.Fatal(name + " failed to decrease a key. ")
+			}
+			handles[handle].key = key
+			heap.Fix(ref, handles[handle].index)
+			if h.DecreaseKey(handle, key+1) {
+				t.Fatal(name + " increased a key with DecreaseKey. ")
+			}
+		case op == 3 && ref.Len() > 0:
+			// equal keys can be popped in any order, so remove whichever key-value the heap popped from the reference
+			expected := (*ref)[0].key
+			if peek := h.Peek(); peek == nil || peek.Key() != expected {
+				t.Fatal(name + " peeked the wrong key, expected " + sc.Itoa(expected))
+			}
+			handle := h.Pop()
+			if handle.Key() != expected {
+				t.Fatal(name + " popped " + sc.Itoa(handle.Key()) + " but should have popped " + sc.Itoa(expected))
+			}
+			heap.Remove(ref, handles[handle].index)
+			if h.DecreaseKey(handle, handle.Key()-1) {
+				t.Fatal(name + " decreased the key of a popped handle. ")
+			}
+			for j := range live {
+				if live[j] == handle {
+					live = append(live[:j], live[j+1:]...)
+					break
+				}
+			}
+		}
+		if err := h.Verify(); err != nil {
+			t.Fatal(name + " failed verification: " + err.Error())
+		}
+		if h.Size() != uint64(ref.Len()) {
+			t.Fatal(name + " size incorrect. ")
+		}
+	}
+	for ref.Len() > 0 {
+		if h.Pop().Key() != heap.Pop(ref).(*refItem).key {
+			t.Fatal(name + " popped keys out of order. ")
+		}
+	}
+	if h.Pop() != nil || h.Peek() != nil {
+		t.Fatal(name + " should be empty. ")
+	}
+}
+
+func TestBinaryHeap(t *testing.T) {
+	h := NewBinaryHeap(nil)
+	checkAgainstReference(t, "Binary heap", &h)
+}
+
+func TestDaryHeap(t *testing.T) {
+	for d := 2; d <= 5; d++ {
+		h := NewDaryHeap(d, nil)
+		checkAgainstReference(t, sc.Itoa(d)+"-ary heap", &h)
+	}
+}
+
+func TestPairingHeap(t *testing.T) {
+	h := NewPairingHeap(nil)
+	checkAgainstReference(t, "Pairing heap", &h)
+}
+
+// popAll pops every key from h
+func popAll(h testHeap) []int {
+	keys := []int{}
+	for h.Size() > 0 {
+		keys = append(keys, h.Pop().Key())
+	}
+	return keys
+}
+
+func TestHeapMaxOrder(t *testing.T) {
+	greater := func(a, b int) bool { return a > b }
+	binary, dary, pairing := NewBinaryHeap(greater), NewDaryHeap(3, greater), NewPairingHeap(greater)
+	expected := []int{}
+	for i := 0; i < nRAND; i++ {
+		key := rand.Intn(nRAND)
+		expected = append(expected, key)
+		binary.Push(key, nil)
+		dary.Push(key, nil)
+		pairing.Push(key, nil)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(expected)))
+	checkKeys(t, "Binary max heap", popAll(&binary), expected)
+	checkKeys(t, "D-ary max heap", popAll(&dary), expected)
+	checkKeys(t, "Pairing max heap", popAll(&pairing), expected)
+
+	// decreasing in a max heap moves a key towards the top
+	handle := pairing.Push(1, nil)
+	pairing.Push(5, nil)
+	if !pairing.DecreaseKey(handle, 10) || pairing.Peek() != handle {
+		t.Fatal("DecreaseKey in a max heap should raise the key. ")
+	}
+}
+
+func TestHeapHeapifyAndMerge(t *testing.T) {
+	keysA, keysB := make([]int, nRAND), make([]int, nRAND/2)
+	for i := range keysA {
+		keysA[i] = rand.Intn(nRAND)
+	}
+	for i := range keysB {
+		keysB[i] = rand.Intn(nRAND)
+	}
+	expected := append(append([]int{}, keysA...), keysB...)
+	sort.Ints(expected)
+
+	binaryA, binaryB := NewBinaryHeap(nil), NewBinaryHeap(nil)
+	daryA, daryB := NewDaryHeap(4, nil), NewDaryHeap(4, nil)
+	pairingA, pairingB := NewPairingHeap(nil), NewPairingHeap(nil)
+	handles := binaryA.Heapify(keysA, nil)
+	daryA.Heapify(keysA, nil)
+	pairingA.Heapify(keysA, nil)
+	binaryB.Heapify(keysB, nil)
+	daryB.Heapify(keysB, nil)
+	pairingB.Heapify(keysB, nil)
+	for i, handle := range handles {
+		if handle.Key() != keysA[i] {
+			t.Fatal("Heapify should return the handles in the order of the keys. ")
+		}
+	}
+	if err := binaryA.Verify(); err != nil {
+		t.Fatal("Binary heap failed verification after heapify: " + err.Error())
+	}
+
+	binaryA.Merge(&binaryB)
+	daryA.Merge(&daryB)
+	pairingA.Merge(&pairingB)
+	if binaryB.Size() != 0 || daryB.Size() != 0 || pairingB.Size() != 0 {
+		t.Fatal("The merged heap should be left empty. ")
+	}
+	if err := pairingA.Verify(); err != nil {
+		t.Fatal("Pairing heap failed verification after merging: " + err.Error())
+	}
+	// handles keep working after a merge
+	if !binaryA.DecreaseKey(handles[0], -1) || binaryA.Peek() != handles[0] {
+		t.Fatal("Handle did not work after merging. ")
+	}
+	binaryA.Pop()
+	checkKeys(t, "Merged binary heap", popAll(&binaryA), append(expected[:0:0], removeFirst(expected, keysA[0])...))
+	checkKeys(t, "Merged d-ary heap", popAll(&daryA), expected)
+	checkKeys(t, "Merged pairing heap", popAll(&pairingA), expected)
+}
+
+// removeFirst returns sorted without its first occurrence of key
+func removeFirst(sorted []int, key int) []int {
+	i := sort.SearchInts(sorted, key)
+	return append(append([]int{}, sorted[:i]...), sorted[i+1:]...)
+}
@@ -0,0 +1,481 @@
+package GoTrees
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// IntervalTree is a self balancing (AVL) binary search tree of closed intervals [Lo, Hi] with values.
+// Intervals are ordered by Lo then Hi, and each node also tracks the largest Hi in its subtree so overlap queries can skip whole subtrees.
+type IntervalTree struct {
+	root *intervalNode
+	size uint64
+}
+
+// intervalNode is a node of the interval tree. max is the largest Hi in the subtree, height is the height of the subtree and count is the number of intervals in it.
+type intervalNode struct {
+	Lo, Hi      int
+	Val         interface{}
+	max         int
+	height      int
+	count       uint64
+	Left, Right *intervalNode
+}
+
+// IntervalEntry is an interval with its value, used to pass batches of intervals to InsertBatch
+type IntervalEntry struct {
+	Lo, Hi int
+	Value  interface{}
+}
+
+func newIntervalNode(lo, hi int, val interface{}) *intervalNode {
+	return &intervalNode{Lo: lo, Hi: hi, Val: val, max: hi, height: 1, count: 1}
+}
+
+// NewIntervalTree returns an empty interval tree. The values will be initialized the same way when doing IntervalTree{}
+func NewIntervalTree() IntervalTree {
+	return IntervalTree{root: nil, size: 0}
+}
+
+// Insert will insert the interval [lo, hi] into the tree. If lo is larger than hi they are swapped. Duplicate intervals are allowed.
+func (it *IntervalTree) Insert(lo, hi int, value interface{}) {
+	if lo > hi {
+		lo, hi = hi, lo
+	}
+	it.root = insertInterval(it.root, newIntervalNode(lo, hi, value))
+	it.size++
+}
+
+// InsertBatch will insert every interval of intervals, the same as calling Insert for each in order. The batch is sorted first (already sorted batches are left alone, intervals itself is never changed).
+// The sorted intervals are built into a balanced tree in linear time, which is then united with it by splitting the batch around each node of it and joining the pieces back together, taking O(m log(n/m + 1)) for m intervals.
+func (it *IntervalTree) InsertBatch(intervals []IntervalEntry) {
+	nodes := make([]*intervalNode, len(intervals))
+	for i, in := range intervals {
+		if in.Lo > in.Hi {
+			in.Lo, in.Hi = in.Hi, in.Lo
+		}
+		nodes[i] = newIntervalNode(in.Lo, in.Hi, in.Value)
+	}
+	before := func(a, b int) bool { return compareInterval(nodes[a].Lo, nodes[a].Hi, nodes[b]) < 0 }
+	if !sort.SliceIsSorted(nodes, before) {
+		// a stable sort keeps duplicate intervals in the order they appear in the batch
+		sort.SliceStable(nodes, before)
+	}
+	it.root = uniteIntervals(it.root, buildIntervals(nodes))
+	it.size += uint64(len(nodes))
+}
+
+// Delete will delete one occurrence of the interval [lo, hi]. It will return whether or not the tree was changed.
+func (it *IntervalTree) Delete(lo, hi int) bool {
+	if lo > hi {
+		lo, hi = hi, lo
+	}
+	deleted := false
+	it.root = deleteInterval(it.root, lo, hi, &deleted)
+	if deleted {
+		it.size--
+	}
+	return deleted
+}
+
+// Find will return the value of one occurrence of the interval [lo, hi], or nil if it is not in the tree.
+func (it *IntervalTree) Find(lo, hi int) *interface{} {
+	n := it.root
+	for n != nil {
+		if c := compareInterval(lo, hi, n); c == 0 {
+			return &n.Val
+		} else if c < 0 {
+			n = n.Left
+		} else {
+			n = n.Right
+		}
+	}
+	return nil
+}
+
+// SplitAt cuts the tree in two by start point. left will hold every interval with Lo smaller than lo and right will hold the rest. it is left empty since its nodes are reused.
+// The subtrees hanging off the search path are joined back together in O(log n), and the sizes of the halves are read from the counts kept in each node.
+func (it *IntervalTree) SplitAt(lo int) (left IntervalTree, right IntervalTree) {
+	l, r := splitIntervals(it.root, func(n *intervalNode) bool { return n.Lo < lo })
+	left = IntervalTree{root: l, size: intervalCount(l)}
+	right = IntervalTree{root: r, size: intervalCount(r)}
+	it.Clear()
+	return left, right
+}
+
+// Join appends every interval in right to it in O(log n). Every interval in it must come before or equal every interval in right, ordered by Lo then Hi, otherwise Join returns false and neither tree is changed.
+// right is left empty since its nodes are reused.
+func (it *IntervalTree) Join(right *IntervalTree) bool {
+	if right.root == nil {
+		return true
+	}
+	if it.root != nil {
+		last, first := it.root, right.root
+		for last.Right != nil {
+			last = last.Right
+		}
+		for first.Left != nil {
+			first = first.Left
+		}
+		if compareInterval(last.Lo, last.Hi, first) > 0 {
+			return false
+		}
+	}
+	// the first interval of right becomes the node between the two trees
+	var first *intervalNode
+	rest := removeMinInterval(right.root, &first)
+	it.root = joinIntervals(it.root, first, rest)
+	it.size += right.size
+	right.Clear()
+	return true
+}
+
+// Stab returns an iterator over every interval that contains point, in order.
+func (it *IntervalTree) Stab(point int) *IntervalIterator {
+	return it.Overlapping(point, point)
+}
+
+// Overlapping returns an iterator over every interval that overlaps [lo, hi], in order.
+func (it *IntervalTree) Overlapping(lo, hi int) *IntervalIterator {
+	if lo > hi {
+		lo, hi = hi, lo
+	}
+	return &IntervalIterator{lo: lo, hi: hi, next: it.root}
+}
+
+// Intervals returns every interval in order as (lo, hi) pairs along with their values.
+func (it *IntervalTree) Intervals() ([][2]int, []interface{}) {
+	intervals := make([][2]int, 0, it.size)
+	vals := make([]interface{}, 0, it.size)
+	nodeStack := []*intervalNode{}
+	n := it.root
+	for n != nil || len(nodeStack) != 0 {
+		if n != nil {
+			nodeStack = append(nodeStack, n)
+			n = n.Left
+		} else {
+			n = nodeStack[len(nodeStack)-1]
+			nodeStack = nodeStack[:len(nodeStack)-1]
+			intervals = append(intervals, [2]int{n.Lo, n.Hi})
+			vals = append(vals, n.Val)
+			n = n.Right
+		}
+	}
+	return intervals, vals
+}
+
+// Clear clears the interval tree of all nodes.
+func (it *IntervalTree) Clear() {
+	it.root = nil
+	it.size = 0
+}
+
+// Height returns the height of the tree, an empty tree has height 0.
+func (it *IntervalTree) Height() uint64 {
+	return uint64(intervalHeight(it.root))
+}
+
+func (it *IntervalTree) Size() uint64 {
+	return it.size
+}
+
+// Verify walks the whole tree and checks the ordering, balance, max endpoint and count of every node. It returns nil if the tree is valid, otherwise an error describing the first problem found.
+func (it *IntervalTree) Verify() error {
+	count := uint64(0)
+	var prev *intervalNode
+	if err := verifyIntervalNode(it.root, &prev, &count); err != nil {
+		return err
+	}
+	if count != it.size {
+		return errors.New("tree holds " + strconv.FormatUint(count, 10) + " intervals but size is " + strconv.FormatUint(it.size, 10))
+	}
+	return nil
+}
+
+// verifyIntervalNode checks n and its subtree. prev is the previous node in order.
+func verifyIntervalNode(n *intervalNode, prev **intervalNode, count *uint64) error {
+	if n == nil {
+		return nil
+	}
+	if err := verifyIntervalNode(n.Left, prev, count); err != nil {
+		return err
+	}
+	if *prev != nil && compareInterval((*prev).Lo, (*prev).Hi, n) > 0 {
+		return errors.New("interval " + intervalString(n) + " is out of order")
+	}
+	*prev = n
+	*count++
+	if err := verifyIntervalNode(n.Right, prev, count); err != nil {
+		return err
+	}
+	if n.height != 1+max(intervalHeight(n.Left), intervalHeight(n.Right)) {
+		return errors.New("interval " + intervalString(n) + " has the wrong height")
+	}
+	if b := balanceFactor(n); b < -1 || b > 1 {
+		return errors.New("interval " + intervalString(n) + " is unbalanced with balance factor " + strconv.Itoa(b))
+	}
+	if n.max != max(n.Hi, max(intervalMax(n.Left), intervalMax(n.Right))) {
+		return errors.New("interval " + intervalString(n) + " has the wrong max endpoint " + strconv.Itoa(n.max))
+	}
+	if n.count != 1+intervalCount(n.Left)+intervalCount(n.Right) {
+		return errors.New("interval " + intervalString(n) + " has the wrong count " + strconv.FormatUint(n.count, 10))
+	}
+	return nil
+}
+
+// IntervalIterator walks the intervals that overlap a query range in order. Call Next before reading the first interval.
+// The tree must not be changed while iterating.
+type IntervalIterator struct {
+	lo, hi    int
+	nodeStack []*intervalNode
+	next      *intervalNode
+	curr      *intervalNode
+}
+
+// Next moves to the next overlapping interval and returns false once there are no more.
+func (iter *IntervalIterator) Next() bool {
+	for {
+		// go left as far as possible, skipping subtrees that end before the query range
+		for iter.next != nil {
+			if iter.next.max < iter.lo {
+				iter.next = nil
+				break
+			}
+			iter.nodeStack = append(iter.nodeStack, iter.next)
+			iter.next = iter.next.Left
+		}
+		if len(iter.nodeStack) == 0 {
+			iter.curr = nil
+			return false
+		}
+		n := iter.nodeStack[len(iter.nodeStack)-1]
+		iter.nodeStack = iter.nodeStack[:len(iter.nodeStack)-1]
+		if n.Lo > iter.hi {
+			// every remaining interval starts after this one, so none of them can overlap
+			iter.nodeStack = nil
+			iter.curr = nil
+			return false
+		}
+		iter.next = n.Right
+		if n.Hi >= iter.lo {
+			iter.curr = n
+			return true
+		}
+	}
+}
+
+// Lo returns the start of the current interval
+func (iter *IntervalIterator) Lo() int {
+	return iter.curr.Lo
+}
+
+// Hi returns the end of the current interval
+func (iter *IntervalIterator) Hi() int {
+	return iter.curr.Hi
+}
+
+// Value returns the value of the current interval
+func (iter *IntervalIterator) Value() interface{} {
+	return iter.curr.Val
+}
+
+// compareInterval orders [lo, hi] against the interval of n by start then end
+func compareInterval(lo, hi int, n *intervalNode) int {
+	if lo != n.Lo {
+		if lo < n.Lo {
+			return -1
+		}
+		return 1
+	}
+	if hi != n.Hi {
+		if hi < n.Hi {
+			return -1
+		}
+		return 1
+	}
+	return 0
+}
+
+// insertInterval inserts n into the subtree of root and returns the new, rebalanced, root. Duplicates are placed in the right subtree.
+func insertInterval(root, n *intervalNode) *intervalNode {
+	if root == nil {
+		return n
+	}
+	if compareInterval(n.Lo, n.Hi, root) < 0 {
+		root.Left = insertInterval(root.Left, n)
+	} else {
+		root.Right = insertInterval(root.Right, n)
+	}
+	return rebalanceInterval(root)
+}
+
+// deleteInterval deletes one occurrence of [lo, hi] from the subtree of root and returns the new, rebalanced, root
+func deleteInterval(root *intervalNode, lo, hi int, deleted *bool) *intervalNode {
+	if root == nil {
+		return nil
+	}
+	c := compareInterval(lo, hi, root)
+	if c < 0 {
+		root.Left = deleteInterval(root.Left, lo, hi, deleted)
+	} else if c > 0 {
+		root.Right = deleteInterval(root.Right, lo, hi, deleted)
+	} else {
+		*deleted = true
+		if root.Left == nil {
+			return root.Right
+		} else if root.Right == nil {
+			return root.Left
+		}
+		// replace the node with its in order successor
+		var ios *intervalNode
+		root.Right = removeMinInterval(root.Right, &ios)
+		ios.Left, ios.Right = root.Left, root.Right
+		root = ios
+	}
+	return rebalanceInterval(root)
+}
+
+// removeMinInterval removes the leftmost node of the subtree of root, stores it in min and returns the new, rebalanced, root
+func removeMinInterval(root *intervalNode, min **intervalNode) *intervalNode {
+	if root.Left == nil {
+		*min = root
+		return root.Right
+	}
+	root.Left = removeMinInterval(root.Left, min)
+	return rebalanceInterval(root)
+}
+
+// splitIntervals splits the subtree of n into the intervals that are before the split point and the rest, and returns the roots of the two halves.
+// before must be true for a prefix of the intervals in order.
+func splitIntervals(n *intervalNode, before func(n *intervalNode) bool) (*intervalNode, *intervalNode) {
+	if n == nil {
+		return nil, nil
+	}
+	if before(n) {
+		left, right := splitIntervals(n.Right, before)
+		return joinIntervals(n.Left, n, left), right
+	}
+	left, right := splitIntervals(n.Left, before)
+	return left, joinIntervals(right, n, n.Right)
+}
+
+// uniteIntervals joins the nodes of a and b into one tree by splitting b around the root of a and uniting each side with the matching subtree of a.
+// Intervals equal in both keep every occurrence from a before those from b.
+func uniteIntervals(a, b *intervalNode) *intervalNode {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	left, right := splitIntervals(b, func(n *intervalNode) bool { return compareInterval(n.Lo, n.Hi, a) < 0 })
+	return joinIntervals(uniteIntervals(a.Left, left), a, uniteIntervals(a.Right, right))
+}
+
+// buildIntervals builds a balanced subtree from sorted nodes by making the middle node the root of each subtree
+func buildIntervals(nodes []*intervalNode) *intervalNode {
+	if len(nodes) == 0 {
+		return nil
+	}
+	mid := len(nodes) / 2
+	n := nodes[mid]
+	n.Left, n.Right = buildIntervals(nodes[:mid]), buildIntervals(nodes[mid+1:])
+	updateInterval(n)
+	return n
+}
+
+// joinIntervals joins two subtrees with the node mid between them, every interval in left must come before mid and every interval in right after it.
+// mid is hung off the spine of the taller tree where the heights are within one of each other and rebalanced on the way back up, so this takes O(difference in height). It returns the new root.
+func joinIntervals(left, mid, right *intervalNode) *intervalNode {
+	if intervalHeight(left) > intervalHeight(right)+1 {
+		left.Right = joinIntervals(left.Right, mid, right)
+		return rebalanceInterval(left)
+	}
+	if intervalHeight(right) > intervalHeight(left)+1 {
+		right.Left = joinIntervals(left, mid, right.Left)
+		return rebalanceInterval(right)
+	}
+	mid.Left, mid.Right = left, right
+	updateInterval(mid)
+	return mid
+}
+
+// rebalanceInterval updates the height and max of n, then rotates if the subtree of n is unbalanced. It returns the new root of the subtree.
+func rebalanceInterval(n *intervalNode) *intervalNode {
+	updateInterval(n)
+	b := balanceFactor(n)
+	if b < -1 {
+		// left heavy
+		if balanceFactor(n.Left) > 0 {
+			n.Left = rotateIntervalLeft(n.Left)
+		}
+		return rotateIntervalRight(n)
+	} else if b > 1 {
+		// right heavy
+		if balanceFactor(n.Right) < 0 {
+			n.Right = rotateIntervalRight(n.Right)
+		}
+		return rotateIntervalLeft(n)
+	}
+	return n
+}
+
+// rotateIntervalLeft makes the right child of n the root of the subtree
+func rotateIntervalLeft(n *intervalNode) *intervalNode {
+	r := n.Right
+	n.Right = r.Left
+	r.Left = n
+	updateInterval(n)
+	updateInterval(r)
+	return r
+}
+
+// rotateIntervalRight makes the left child of n the root of the subtree
+func rotateIntervalRight(n *intervalNode) *intervalNode {
+	l := n.Left
+	n.Left = l.Right
+	l.Right = n
+	updateInterval(n)
+	updateInterval(l)
+	return l
+}
+
+// updateInterval recalculates the height, max endpoint and count of n from its children
+func updateInterval(n *intervalNode) {
+	n.height = 1 + max(intervalHeight(n.Left), intervalHeight(n.Right))
+	n.max = max(n.Hi, max(intervalMax(n.Left), intervalMax(n.Right)))
+	n.count = 1 + intervalCount(n.Left) + intervalCount(n.Right)
+}
+
+// balanceFactor is the height of the right subtree minus the height of the left subtree
+func balanceFactor(n *intervalNode) int {
+	return intervalHeight(n.Right) - intervalHeight(n.Left)
+}
+
+func intervalHeight(n *intervalNode) int {
+	if n == nil {
+		return 0
+	}
+	return n.height
+}
+
+func intervalCount(n *intervalNode) uint64 {
+	if n == nil {
+		return 0
+	}
+	return n.count
+}
+
+// intervalMax returns the max endpoint of the subtree of n, or the smallest int if n is nil
+func intervalMax(n *intervalNode) int {
+	if n == nil {
+		return math.MinInt
+	}
+	return n.max
+}
+
+func intervalString(n *intervalNode) string {
+	return "[" + strconv.Itoa(n.Lo) + ", " + strconv.Itoa(n.Hi) + "]"
+}
@@ -0,0 +1,237 @@
+package GoTrees
+
+import (
+	"math/rand"
+	sc "strconv"
+	"testing"
+)
+
+// randomIntervals inserts nRAND random intervals into IT and returns them
+func randomIntervals(IT *IntervalTree) [][2]int {
+	intervals := make([][2]int, nRAND)
+	for i := range intervals {
+		lo := rand.Intn(nRAND)
+		intervals[i] = [2]int{lo, lo + rand.Intn(nRAND/5)}
+		IT.Insert(intervals[i][0], intervals[i][1], i)
+	}
+	return intervals
+}
+
+// bruteForceOverlap counts the intervals that overlap [lo, hi] by checking every one
+func bruteForceOverlap(intervals [][2]int, lo, hi int) int {
+	count := 0
+	for _, in := range intervals {
+		if in[0] <= hi && in[1] >= lo {
+			count++
+		}
+	}
+	return count
+}
+
+func TestIntervalTreeInsert(t *testing.T) {
+	IT := NewIntervalTree()
+	intervals := randomIntervals(&IT)
+
+	if err := IT.Verify(); err != nil {
+		t.Fatal("Interval tree failed verification: " + err.Error())
+	}
+	if IT.Size() != nRAND {
+		t.Fatal("Interval tree size incorrect, expected " + sc.Itoa(nRAND) + " but got " + sc.Itoa(int(IT.Size())))
+	}
+	for _, in := range intervals {
+		if IT.Find(in[0], in[1]) == nil {
+			t.Fatal("Could not find interval [" + sc.Itoa(in[0]) + ", " + sc.Itoa(in[1]) + "]. ")
+		}
+	}
+	// AVL trees are at most ~1.44 log2(n) tall
+	if IT.Height() > 10 {
+		t.Fatal("Interval tree is not balanced, height is " + sc.Itoa(int(IT.Height())))
+	}
+	sorted, _ := IT.Intervals()
+	for i := 1; i < len(sorted); i++ {
+		if sorted[i][0] < sorted[i-1][0] || (sorted[i][0] == sorted[i-1][0] && sorted[i][1] < sorted[i-1][1]) {
+			t.Fatal("Intervals are not in order at index " + sc.Itoa(i))
+		}
+	}
+}
+
+func TestIntervalTreeStab(t *testing.T) {
+	IT := NewIntervalTree()
+	intervals := randomIntervals(&IT)
+
+	for point := -1; point <= nRAND+nRAND/5; point++ {
+		count := 0
+		for iter := IT.Stab(point); iter.Next(); {
+			if iter.Lo() > point || iter.Hi() < point {
+				t.Fatal("Stab of " + sc.Itoa(point) + " returned [" + sc.Itoa(iter.Lo()) + ", " + sc.Itoa(iter.Hi()) + "]. ")
+			}
+			if intervals[iter.Value().(int)] != [2]int{iter.Lo(), iter.Hi()} {
+				t.Fatal("Stab returned the wrong value for an interval. ")
+			}
+			count++
+		}
+		if expected := bruteForceOverlap(intervals, point, point); count != expected {
+			t.Fatal("Stab of " + sc.Itoa(point) + " found " + sc.Itoa(count) + " intervals but expected " + sc.Itoa(expected))
+		}
+	}
+}
+
+func TestIntervalTreeOverlapping(t *testing.T) {
+	IT := NewIntervalTree()
+	intervals := randomIntervals(&IT)
+
+	for i := 0; i < nRAND; i++ {
+		lo := rand.Intn(nRAND+20) - 10
+		hi := lo + rand.Intn(nRAND/10)
+		count := 0
+		lastLo := lo - nRAND
+		for iter := IT.Overlapping(lo, hi); iter.Next(); {
+			if iter.Lo() > hi || iter.Hi() < lo {
+				t.Fatal("Overlapping returned an interval outside the query range. ")
+			}
+			if iter.Lo() < lastLo {
+				t.Fatal("Overlapping did not return intervals in order. ")
+			}
+			lastLo = iter.Lo()
+			count++
+		}
+		if expected := bruteForceOverlap(intervals, lo, hi); count != expected {
+			t.Fatal("Overlapping [" + sc.Itoa(lo) + ", " + sc.Itoa(hi) + "] found " + sc.Itoa(count) + " intervals but expected " + sc.Itoa(expected))
+		}
+	}
+}
+
+func TestIntervalTreeDelete(t *testing.T) {
+	IT := NewIntervalTree()
+	intervals := randomIntervals(&IT)
+
+	for i, in := range intervals {
+		if !IT.Delete(in[0], in[1]) {
+			t.Fatal("Interval tree returned false when tree should have been modified. ")
+		}
+		if err := IT.Verify(); err != nil {
+			t.Fatal("Interval tree failed verification after a delete: " + err.Error())
+		}
+		if IT.Size() != uint64(nRAND-(i+1)) {
+			t.Fatal("Interval tree size incorrect, expected " + sc.Itoa(nRAND-(i+1)) + " but got " + sc.Itoa(int(IT.Size())))
+		}
+		point := rand.Intn(nRAND)
+		count := 0
+		for iter := IT.Stab(point); iter.Next(); {
+			count++
+		}
+		if expected := bruteForceOverlap(intervals[i+1:], point, point); count != expected {
+			t.Fatal("Stab after deletes found " + sc.Itoa(count) + " intervals but expected " + sc.Itoa(expected))
+		}
+	}
+	if IT.Delete(1, 2) {
+		t.Fatal("Delete returned true on an empty tree. ")
+	}
+}
+
+func TestIntervalTreeSplitAt(t *testing.T) {
+	for trial := 0; trial < nRAND; trial++ {
+		IT := NewIntervalTree()
+		randomIntervals(&IT)
+		intervals, _ := IT.Intervals()
+		splitLo := rand.Intn(nRAND+2) - 1
+
+		left, right := IT.SplitAt(splitLo)
+		if err := left.Verify(); err != nil {
+			t.Fatal("Left half failed verification after splitting at " + sc.Itoa(splitLo) + ": " + err.Error())
+		}
+		if err := right.Verify(); err != nil {
+			t.Fatal("Right half failed verification after splitting at " + sc.Itoa(splitLo) + ": " + err.Error())
+		}
+		if IT.Size() != 0 {
+			t.Fatal("SplitAt did not empty the receiver. ")
+		}
+		split := 0
+		for split < len(intervals) && intervals[split][0] < splitLo {
+			split++
+		}
+		leftIntervals, _ := left.Intervals()
+		rightIntervals, _ := right.Intervals()
+		if len(leftIntervals) != split || len(rightIntervals) != len(intervals)-split {
+			t.Fatal("Splitting at " + sc.Itoa(splitLo) + " gave halves of " + sc.Itoa(len(leftIntervals)) + " and " + sc.Itoa(len(rightIntervals)) + " intervals. ")
+		}
+		for i, in := range append(leftIntervals, rightIntervals...) {
+			if in != intervals[i] {
+				t.Fatal("Halves of the split hold the wrong intervals. ")
+			}
+		}
+
+		if !left.Join(&right) {
+			t.Fatal("Join of two halves returned false. ")
+		}
+		if err := left.Verify(); err != nil {
+			t.Fatal("Joined tree failed verification: " + err.Error())
+		}
+		if left.Size() != nRAND || right.Size() != 0 {
+			t.Fatal("Join did not move every interval. ")
+		}
+	}
+}
+
+func TestIntervalTreeJoin(t *testing.T) {
+	left, right := NewIntervalTree(), NewIntervalTree()
+	// trees of very different heights must still join at the right level
+	for i := 0; i < nRAND; i++ {
+		left.Insert(i, i+1, i)
+	}
+	right.Insert(nRAND, nRAND+5, nil)
+	right.Insert(nRAND, nRAND+6, nil)
+
+	if !left.Join(&right) {
+		t.Fatal("Join returned false for ordered trees. ")
+	}
+	if err := left.Verify(); err != nil {
+		t.Fatal("Joined tree failed verification: " + err.Error())
+	}
+	if left.Size() != nRAND+2 || left.Find(nRAND, nRAND+6) == nil {
+		t.Fatal("Joined tree is missing intervals. ")
+	}
+	count := 0
+	for iter := left.Stab(nRAND + 6); iter.Next(); {
+		count++
+	}
+	if count != 1 {
+		t.Fatal("Joined tree has the wrong max endpoints. ")
+	}
+
+	overlap := NewIntervalTree()
+	overlap.Insert(0, 0, nil)
+	if left.Join(&overlap) {
+		t.Fatal("Join returned true for overlapping trees. ")
+	}
+	if left.Size() != nRAND+2 || overlap.Size() != 1 {
+		t.Fatal("A failed Join changed the trees. ")
+	}
+}
+
+func TestIntervalTreeInsertBatch(t *testing.T) {
+	IT, expected := NewIntervalTree(), NewIntervalTree()
+	for batch := 0; batch < 5; batch++ {
+		intervals := make([]IntervalEntry, nRAND)
+		for i := range intervals {
+			// few distinct intervals so there are duplicates within and between batches, some given backwards
+			lo := rand.Intn(nRAND / 10)
+			intervals[i] = IntervalEntry{Lo: lo, Hi: lo + rand.Intn(3) - 1, Value: batch*nRAND + i}
+			expected.Insert(intervals[i].Lo, intervals[i].Hi, intervals[i].Value)
+		}
+		IT.InsertBatch(intervals)
+		if err := IT.Verify(); err != nil {
+			t.Fatal("Interval tree failed verification after InsertBatch: " + err.Error())
+		}
+		if IT.Size() != expected.Size() {
+			t.Fatal("Interval tree size incorrect, expected " + sc.Itoa(int(expected.Size())) + " but got " + sc.Itoa(int(IT.Size())))
+		}
+		actualIntervals, actualVals := IT.Intervals()
+		expectedIntervals, expectedVals := expected.Intervals()
+		for i := range expectedIntervals {
+			if actualIntervals[i] != expectedIntervals[i] || actualVals[i] != expectedVals[i] {
+				t.Fatal("InsertBatch interval was incorrect at index " + sc.Itoa(i))
+			}
+		}
+	}
+}
@@ -0,0 +1,438 @@
+package GoTrees
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// KDMetric is a distance between two points. A metric used by a KDTree must never decrease when the difference along any one axis grows (like every Minkowski distance),
+// since the tree uses the distance to the splitting plane as a lower bound for every point on the other side.
+type KDMetric func(a, b []float64) float64
+
+// EuclideanDistance is the straight line distance between a and b. It is the default metric of a KDTree.
+func EuclideanDistance(a, b []float64) float64 {
+	sum := 0.0
+	for i := range a {
+		d := a[i] - b[i]
+		sum += d * d
+	}
+	return math.Sqrt(sum)
+}
+
+// ManhattanDistance is the sum of the distances between a and b along every axis.
+func ManhattanDistance(a, b []float64) float64 {
+	sum := 0.0
+	for i := range a {
+		sum += math.Abs(a[i] - b[i])
+	}
+	return sum
+}
+
+// ChebyshevDistance is the largest distance between a and b along any axis.
+func ChebyshevDistance(a, b []float64) float64 {
+	dist := 0.0
+	for i := range a {
+		dist = math.Max(dist, math.Abs(a[i]-b[i]))
+	}
+	return dist
+}
+
+// KDTree is a k-d tree of points with values. Each level splits the points along the next axis, points less than the splitting point go left and the rest go right.
+// Duplicate points are allowed. Inserts and deletes do not rebalance the tree, call Rebalance after many of them.
+type KDTree struct {
+	root   *kdNode
+	dims   int
+	metric KDMetric
+	size   uint64
+}
+
+// kdNode is a node of the k-d tree, it splits its subtree along axis
+type kdNode struct {
+	Point       []float64
+	Val         interface{}
+	axis        int
+	Left, Right *kdNode
+}
+
+// NewKDTree returns an empty k-d tree of points with dims dimensions. A nil metric uses EuclideanDistance.
+func NewKDTree(dims int, metric KDMetric) KDTree {
+	if metric == nil {
+		metric = EuclideanDistance
+	}
+	return KDTree{root: nil, dims: dims, metric: metric, size: 0}
+}
+
+// NewKDTreeFromPoints returns a balanced k-d tree holding points with their values, by splitting on the median point at every level. values may be nil, otherwise it must be as long as points. The points are copied.
+func NewKDTreeFromPoints(dims int, metric KDMetric, points [][]float64, values []interface{}) KDTree {
+	kd := NewKDTree(dims, metric)
+	nodes := make([]*kdNode, len(points))
+	for i, p := range points {
+		kd.checkDims(p)
+		nodes[i] = &kdNode{Point: append([]float64{}, p...)}
+		if values != nil {
+			nodes[i].Val = values[i]
+		}
+	}
+	kd.root = buildKDNode(nodes, 0, dims)
+	kd.size = uint64(len(points))
+	return kd
+}
+
+// buildKDNode builds a balanced subtree splitting nodes along axis
+func buildKDNode(nodes []*kdNode, axis, dims int) *kdNode {
+	if len(nodes) == 0 {
+		return nil
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].Point[axis] < nodes[j].Point[axis]
+	})
+	// points equal to the median must go right, so move the median to the first of them
+	mid := len(nodes) / 2
+	for mid > 0 && nodes[mid-1].Point[axis] == nodes[mid].Point[axis] {
+		mid--
+	}
+	n := nodes[mid]
+	n.axis = axis
+	next := (axis + 1) % dims
+	n.Left = buildKDNode(nodes[:mid], next, dims)
+	n.Right = buildKDNode(nodes[mid+1:], next, dims)
+	return n
+}
+
+// Insert will insert point into the tree. The point is copied. Duplicate points are allowed.
+func (kd *KDTree) Insert(point []float64, value interface{}) {
+	kd.checkDims(point)
+	n := &kdNode{Point: append([]float64{}, point...), Val: value}
+	link := &kd.root
+	for *link != nil {
+		n.axis = ((*link).axis + 1) % kd.dims
+		if point[(*link).axis] < (*link).Point[(*link).axis] {
+			link = &(*link).Left
+		} else {
+			link = &(*link).Right
+		}
+	}
+	*link = n
+	kd.size++
+}
+
+// Delete will delete one occurrence of point. It will return whether or not the tree was changed.
+func (kd *KDTree) Delete(point []float64) bool {
+	kd.checkDims(point)
+	deleted := false
+	kd.root = deleteKDNode(kd.root, point, nil, &deleted)
+	if deleted {
+		kd.size--
+	}
+	return deleted
+}
+
+// deleteKDNode deletes target from the subtree of root, or any node with point if target is nil. It returns the new root of the subtree.
+// A deleted node with children is replaced by the node with the smallest coordinate along its axis from the right subtree, which is then deleted in turn.
+// If there is no right subtree the left one is moved to the right, since everything in it is at least its own smallest coordinate.
+func deleteKDNode(root *kdNode, point []float64, target *kdNode, deleted *bool) *kdNode {
+	if root == nil {
+		return nil
+	}
+	if (target == nil && equalPoints(root.Point, point)) || root == target {
+		*deleted = true
+		if root.Left == nil && root.Right == nil {
+			return nil
+		}
+		if root.Right == nil {
+			root.Right, root.Left = root.Left, nil
+		}
+		min := minKDNode(root.Right, root.axis)
+		root.Point, root.Val = min.Point, min.Val
+		found := false
+		root.Right = deleteKDNode(root.Right, min.Point, min, &found)
+		return root
+	}
+	if point[root.axis] < root.Point[root.axis] {
+		root.Left = deleteKDNode(root.Left, point, target, deleted)
+	} else {
+		root.Right = deleteKDNode(root.Right, point, target, deleted)
+	}
+	return root
+}
+
+// minKDNode returns the node with the smallest coordinate along axis in the subtree of n
+func minKDNode(n *kdNode, axis int) *kdNode {
+	if n == nil {
+		return nil
+	}
+	if n.axis == axis {
+		if n.Left == nil {
+			return n
+		}
+		return minKDNode(n.Left, axis)
+	}
+	min := n
+	for _, child := range []*kdNode{minKDNode(n.Left, axis), minKDNode(n.Right, axis)} {
+		if child != nil && child.Point[axis] < min.Point[axis] {
+			min = child
+		}
+	}
+	return min
+}
+
+// Find will return the value of one occurrence of point, or nil if it is not in the tree.
+func (kd *KDTree) Find(point []float64) *interface{} {
+	kd.checkDims(point)
+	n := kd.root
+	for n != nil {
+		if equalPoints(n.Point, point) {
+			return &n.Val
+		}
+		if point[n.axis] < n.Point[n.axis] {
+			n = n.Left
+		} else {
+			n = n.Right
+		}
+	}
+	return nil
+}
+
+// Contains determines if point exists in the tree and returns the result.
+func (kd *KDTree) Contains(point []float64) bool {
+	return kd.Find(point) != nil
+}
+
+// Nearest returns the k points closest to point by the metric of the tree, closest first, and their values.
+func (kd *KDTree) Nearest(point []float64, k int) ([][]float64, []interface{}) {
+	kd.checkDims(point)
+	best := []*kdNode{}
+	dists := []float64{}
+	plane := append([]float64{}, point...)
+	var search func(n *kdNode)
+	search = func(n *kdNode) {
+		if n == nil || k <= 0 {
+			return
+		}
+		if d := kd.metric(point, n.Point); len(best) < k || d < dists[len(dists)-1] {
+			// keep best sorted by distance, dropping the furthest once there are k
+			i := sort.SearchFloat64s(dists, d)
+			for i < len(dists) && dists[i] == d {
+				i++
+			}
+			best = append(best[:i], append([]*kdNode{n}, best[i:]...)...)
+			dists = append(dists[:i], append([]float64{d}, dists[i:]...)...)
+			if len(best) > k {
+				best, dists = best[:k], dists[:k]
+			}
+		}
+		near, far := n.Left, n.Right
+		if point[n.axis] >= n.Point[n.axis] {
+			near, far = far, near
+		}
+		search(near)
+		if len(best) < k || kd.planeDistance(point, plane, n) <= dists[len(dists)-1] {
+			search(far)
+		}
+	}
+	search(kd.root)
+	return kdResults(best)
+}
+
+// Radius returns every point within distance r of point by the metric of the tree, and their values. They are in no particular order.
+func (kd *KDTree) Radius(point []float64, r float64) ([][]float64, []interface{}) {
+	kd.checkDims(point)
+	found := []*kdNode{}
+	plane := append([]float64{}, point...)
+	var search func(n *kdNode)
+	search = func(n *kdNode) {
+		if n == nil {
+			return
+		}
+		if kd.metric(point, n.Point) <= r {
+			found = append(found, n)
+		}
+		near, far := n.Left, n.Right
+		if point[n.axis] >= n.Point[n.axis] {
+			near, far = far, near
+		}
+		search(near)
+		if kd.planeDistance(point, plane, n) <= r {
+			search(far)
+		}
+	}
+	search(kd.root)
+	return kdResults(found)
+}
+
+// Box returns every point inside the axis aligned box from lo to hi, including its edges, and their values. They are in no particular order.
+func (kd *KDTree) Box(lo, hi []float64) ([][]float64, []interface{}) {
+	kd.checkDims(lo)
+	kd.checkDims(hi)
+	found := []*kdNode{}
+	var search func(n *kdNode)
+	search = func(n *kdNode) {
+		if n == nil {
+			return
+		}
+		inside := true
+		for i := range n.Point {
+			if n.Point[i] < lo[i] || n.Point[i] > hi[i] {
+				inside = false
+				break
+			}
+		}
+		if inside {
+			found = append(found, n)
+		}
+		if lo[n.axis] < n.Point[n.axis] {
+			search(n.Left)
+		}
+		if hi[n.axis] >= n.Point[n.axis] {
+			search(n.Right)
+		}
+	}
+	search(kd.root)
+	return kdResults(found)
+}
+
+// planeDistance returns the distance from point to the splitting plane of n. plane must hold a copy of point, it is restored before returning.
+func (kd *KDTree) planeDistance(point, plane []float64, n *kdNode) float64 {
+	plane[n.axis] = n.Point[n.axis]
+	d := kd.metric(point, plane)
+	plane[n.axis] = point[n.axis]
+	return d
+}
+
+// Points returns every point in the tree and their values, in pre order.
+func (kd *KDTree) Points() ([][]float64, []interface{}) {
+	nodes := make([]*kdNode, 0, kd.size)
+	var walk func(n *kdNode)
+	walk = func(n *kdNode) {
+		if n != nil {
+			nodes = append(nodes, n)
+			walk(n.Left)
+			walk(n.Right)
+		}
+	}
+	walk(kd.root)
+	return kdResults(nodes)
+}
+
+// Rebalance rebuilds the tree so it is balanced again, as if it was made by NewKDTreeFromPoints.
+func (kd *KDTree) Rebalance() {
+	points, values := kd.Points()
+	*kd = NewKDTreeFromPoints(kd.dims, kd.metric, points, values)
+}
+
+// Clear clears the tree of all nodes.
+func (kd *KDTree) Clear() {
+	kd.root = nil
+	kd.size = 0
+}
+
+// Dims returns the number of dimensions of the points in the tree.
+func (kd *KDTree) Dims() int {
+	return kd.dims
+}
+
+// Height returns the height of the tree, an empty tree has height 0.
+func (kd *KDTree) Height() uint64 {
+	var height func(n *kdNode) int
+	height = func(n *kdNode) int {
+		if n == nil {
+			return 0
+		}
+		return max(height(n.Left), height(n.Right)) + 1
+	}
+	return uint64(height(kd.root))
+}
+
+func (kd *KDTree) Size() uint64 {
+	return kd.size
+}
+
+// Verify walks the whole tree and checks that every node splits its subtree along the right axis. It returns nil if the tree is valid, otherwise an error describing the first problem found.
+func (kd *KDTree) Verify() error {
+	count := uint64(0)
+	lo := make([]float64, kd.dims)
+	hi := make([]float64, kd.dims)
+	for i := range lo {
+		lo[i], hi[i] = math.Inf(-1), math.Inf(1)
+	}
+	if err := verifyKDNode(kd.root, 0, kd.dims, lo, hi, &count); err != nil {
+		return err
+	}
+	if count != kd.size {
+		return errors.New("tree holds " + strconv.FormatUint(count, 10) + " points but size is " + strconv.FormatUint(kd.size, 10))
+	}
+	return nil
+}
+
+// verifyKDNode checks that every point of the subtree of n lies in lo <= point < hi, and that n splits along axis
+func verifyKDNode(n *kdNode, axis, dims int, lo, hi []float64, count *uint64) error {
+	if n == nil {
+		return nil
+	}
+	if n.axis != axis {
+		return errors.New("node " + pointString(n.Point) + " splits along axis " + strconv.Itoa(n.axis) + " but should split along " + strconv.Itoa(axis))
+	}
+	if len(n.Point) != dims {
+		return errors.New("node " + pointString(n.Point) + " does not have " + strconv.Itoa(dims) + " dimensions")
+	}
+	for i, x := range n.Point {
+		if x < lo[i] || x >= hi[i] {
+			return errors.New("node " + pointString(n.Point) + " is on the wrong side of a splitting plane along axis " + strconv.Itoa(i))
+		}
+	}
+	*count++
+	next := (axis + 1) % dims
+	split := n.Point[axis]
+	old := hi[axis]
+	hi[axis] = split
+	err := verifyKDNode(n.Left, next, dims, lo, hi, count)
+	hi[axis] = old
+	if err != nil {
+		return err
+	}
+	old = lo[axis]
+	lo[axis] = split
+	err = verifyKDNode(n.Right, next, dims, lo, hi, count)
+	lo[axis] = old
+	return err
+}
+
+// checkDims panics if point does not have the dimensions of the tree, since a query with the wrong dimensions can not be answered
+func (kd *KDTree) checkDims(point []float64) {
+	if len(point) != kd.dims {
+		panic("KDTree: point has " + strconv.Itoa(len(point)) + " dimensions but the tree has " + strconv.Itoa(kd.dims))
+	}
+}
+
+// kdResults splits nodes into their points and values
+func kdResults(nodes []*kdNode) ([][]float64, []interface{}) {
+	points := make([][]float64, len(nodes))
+	values := make([]interface{}, len(nodes))
+	for i, n := range nodes {
+		points[i] = n.Point
+		values[i] = n.Val
+	}
+	return points, values
+}
+
+func equalPoints(a, b []float64) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func pointString(point []float64) string {
+	str := "("
+	for i, x := range point {
+		if i > 0 {
+			str += ", "
+		}
+		str += strconv.FormatFloat(x, 'g', -1, 64)
+	}
+	return str + ")"
+}
@@ -0,0 +1,200 @@
+package GoTrees
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	sc "strconv"
+	"testing"
+)
+
+// randomPoint returns a point on a small grid so there are duplicate points and equal coordinates
+func randomPoint(dims int) []float64 {
+	point := make([]float64, dims)
+	for i := range point {
+		point[i] = float64(rand.Intn(20))
+	}
+	return point
+}
+
+// sortedDistances returns the distance from point to each of points in increasing order
+func sortedDistances(metric KDMetric, point []float64, points [][]float64) []float64 {
+	dists := make([]float64, len(points))
+	for i, p := range points {
+		dists[i] = metric(point, p)
+	}
+	sort.Float64s(dists)
+	return dists
+}
+
+// checkSameValues fails if the two lists do not hold the same values, in any order
+func checkSameValues(t *testing.T, name string, actual []interface{}, expected []int) {
+	ints := make([]int, len(actual))
+	for i, v := range actual {
+		ints[i] = v.(int)
+	}
+	sort.Ints(ints)
+	sort.Ints(expected)
+	checkKeys(t, name, ints, expected)
+}
+
+func TestKDTreeFromPoints(t *testing.T) {
+	for dims := 1; dims <= 3; dims++ {
+		points := make([][]float64, nRAND*10)
+		values := make([]interface{}, len(points))
+		for i := range points {
+			points[i] = make([]float64, dims)
+			for j := range points[i] {
+				points[i][j] = rand.Float64()
+			}
+			values[i] = i
+		}
+		KD := NewKDTreeFromPoints(dims, nil, points, values)
+		if err := KD.Verify(); err != nil {
+			t.Fatal("K-d tree failed verification after building: " + err.Error())
+		}
+		if KD.Size() != uint64(len(points)) {
+			t.Fatal("K-d tree size incorrect after building. ")
+		}
+		for _, p := range points {
+			if !KD.Contains(p) {
+				t.Fatal("Could not find " + pointString(p) + " after building. ")
+			}
+		}
+		if KD.Height() != uint64(math.Log2(float64(len(points))))+1 {
+			t.Fatal("K-d tree built from points is too tall, height " + sc.FormatUint(KD.Height(), 10))
+		}
+	}
+}
+
+func TestKDTreeInsertDelete(t *testing.T) {
+	KD := NewKDTree(2, nil)
+	points := [][]float64{}
+	for i := 0; i < nRAND*5; i++ {
+		p := randomPoint(2)
+		points = append(points, p)
+		KD.Insert(p, i)
+		if err := KD.Verify(); err != nil {
+			t.Fatal("K-d tree failed verification after inserting " + pointString(p) + ": " + err.Error())
+		}
+	}
+	if KD.Contains([]float64{-1, -1}) {
+		t.Fatal("Found a point that was not in the tree. ")
+	}
+
+	rand.Shuffle(len(points), func(i, j int) { points[i], points[j] = points[j], points[i] })
+	for i, p := range points {
+		if !KD.Delete(p) {
+			t.Fatal("Failed to delete " + pointString(p))
+		}
+		if err := KD.Verify(); err != nil {
+			t.Fatal("K-d tree failed verification after deleting " + pointString(p) + ": " + err.Error())
+		}
+		for _, other := range points[i+1:] {
+			if !KD.Contains(other) {
+				t.Fatal("Deleting " + pointString(p) + " lost " + pointString(other))
+			}
+		}
+	}
+	if KD.Size() != 0 || KD.Delete([]float64{0, 0}) {
+		t.Fatal("K-d tree should be empty after deleting every point. ")
+	}
+}
+
+func TestKDTreeNearest(t *testing.T) {
+	for _, metric := range []KDMetric{EuclideanDistance, ManhattanDistance, ChebyshevDistance} {
+		points := make([][]float64, nRAND*5)
+		for i := range points {
+			points[i] = randomPoint(3)
+		}
+		KD := NewKDTreeFromPoints(3, metric, points, nil)
+		// insert a few more so the tree is not perfectly balanced
+		for i := 0; i < nRAND; i++ {
+			p := randomPoint(3)
+			points = append(points, p)
+			KD.Insert(p, nil)
+		}
+
+		for i := 0; i < nRAND; i++ {
+			query := randomPoint(3)
+			k := rand.Intn(10) + 1
+			expected := sortedDistances(metric, query, points)[:k]
+			found, _ := KD.Nearest(query, k)
+			if len(found) != k {
+				t.Fatal("Nearest returned " + sc.Itoa(len(found)) + " points but should have " + sc.Itoa(k))
+			}
+			for j, p := range found {
+				// ties can be broken either way, so only compare distances
+				if d := metric(query, p); d != expected[j] {
+					t.Fatal("Nearest point " + sc.Itoa(j) + " to " + pointString(query) + " was at distance " + sc.FormatFloat(d, 'g', -1, 64) + " but should be " + sc.FormatFloat(expected[j], 'g', -1, 64))
+				}
+			}
+		}
+	}
+
+	KD := NewKDTree(2, nil)
+	if found, _ := KD.Nearest([]float64{0, 0}, 3); len(found) != 0 {
+		t.Fatal("Nearest on an empty tree should return nothing. ")
+	}
+	KD.Insert([]float64{1, 1}, nil)
+	if found, _ := KD.Nearest([]float64{0, 0}, 3); len(found) != 1 {
+		t.Fatal("Nearest should return every point when there are less than k. ")
+	}
+}
+
+func TestKDTreeRadiusAndBox(t *testing.T) {
+	points := make([][]float64, nRAND*5)
+	values := make([]interface{}, len(points))
+	for i := range points {
+		points[i] = randomPoint(2)
+		values[i] = i
+	}
+	KD := NewKDTreeFromPoints(2, nil, points, values)
+
+	for i := 0; i < nRAND; i++ {
+		query := randomPoint(2)
+		r := float64(rand.Intn(6))
+		expected := []int{}
+		for j, p := range points {
+			if EuclideanDistance(query, p) <= r {
+				expected = append(expected, j)
+			}
+		}
+		_, found := KD.Radius(query, r)
+		checkSameValues(t, "Radius", found, expected)
+
+		lo, hi := randomPoint(2), randomPoint(2)
+		for j := range lo {
+			lo[j], hi[j] = math.Min(lo[j], hi[j]), math.Max(lo[j], hi[j])
+		}
+		expected = []int{}
+		for j, p := range points {
+			if p[0] >= lo[0] && p[0] <= hi[0] && p[1] >= lo[1] && p[1] <= hi[1] {
+				expected = append(expected, j)
+			}
+		}
+		_, found = KD.Box(lo, hi)
+		checkSameValues(t, "Box", found, expected)
+	}
+}
+
+func TestKDTreeRebalance(t *testing.T) {
+	KD := NewKDTree(2, nil)
+	// sorted inserts make a degenerate tree
+	for i := 0; i < nRAND; i++ {
+		KD.Insert([]float64{float64(i), float64(i)}, i)
+	}
+	if KD.Height() != nRAND {
+		t.Fatal("Sorted inserts should make a tree of height " + sc.Itoa(nRAND))
+	}
+	KD.Rebalance()
+	if err := KD.Verify(); err != nil {
+		t.Fatal("K-d tree failed verification after rebalancing: " + err.Error())
+	}
+	if KD.Height() != 7 || KD.Size() != nRAND {
+		t.Fatal("Rebalanced tree should have height 7 but has height " + sc.FormatUint(KD.Height(), 10))
+	}
+	if v := KD.Find([]float64{42, 42}); v == nil || (*v).(int) != 42 {
+		t.Fatal("Rebalancing lost the value of a point. ")
+	}
+}
@@ -1,5 +1,7 @@
 package GoTrees
 
+import "sort"
+
 type keyValue struct {
 	key   int
 	value interface{}
@@ -8,3 +10,19 @@ type keyValue struct {
 func newKeyValue(key int, value interface{}) *keyValue {
 	return &keyValue{key: key, value: value}
 }
+
+// Entry is a key with its value, used to pass batches of key-values to the trees
+type Entry struct {
+	Key   int
+	Value interface{}
+}
+
+// sortedEntries returns kvs in key order, keeping duplicate keys in the order they appear. A sorted batch is returned as it is, otherwise a sorted copy is made so the caller's slice is not changed.
+func sortedEntries(kvs []Entry) []Entry {
+	if sort.SliceIsSorted(kvs, func(a, b int) bool { return kvs[a].Key < kvs[b].Key }) {
+		return kvs
+	}
+	sorted := append([]Entry{}, kvs...)
+	sort.SliceStable(sorted, func(a, b int) bool { return sorted[a].Key < sorted[b].Key })
+	return sorted
+}
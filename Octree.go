@@ -0,0 +1,103 @@
+package GoTrees
+
+// Box is an axis aligned box including its faces, used as the bounds and query region of an Octree.
+type Box struct {
+	MinX, MinY, MinZ, MaxX, MaxY, MaxZ float64
+}
+
+// Octree is the three dimensional QuadTree, a leaf holding more than capacity points is split into eight octants unless it is at the maximum depth.
+// Duplicate points are allowed. Iteration visits the octants in Z order: lower x before upper x, then lower y before upper y, then lower z before upper z.
+type Octree struct {
+	tree spatialTree
+}
+
+// NewOctree returns an empty octree covering bounds. capacity is the number of points a leaf holds before it is split (at least 1) and maxDepth is how many times the bounds can be split.
+func NewOctree(bounds Box, capacity, maxDepth int) Octree {
+	return Octree{tree: newSpatialTree([]float64{bounds.MinX, bounds.MinY, bounds.MinZ}, []float64{bounds.MaxX, bounds.MaxY, bounds.MaxZ}, capacity, maxDepth)}
+}
+
+// Insert will insert the point (x, y, z) into the tree. It returns false without changing the tree if the point is outside the bounds.
+func (ot *Octree) Insert(x, y, z float64, value interface{}) bool {
+	return ot.tree.Insert([]float64{x, y, z}, value)
+}
+
+// Remove will remove one occurrence of the point (x, y, z). It will return whether or not the tree was changed.
+func (ot *Octree) Remove(x, y, z float64) bool {
+	return ot.tree.Remove([]float64{x, y, z})
+}
+
+// Move will move one occurrence of the point from to the point to, keeping its value. It returns false without changing the tree if the point is not found or the new point is outside the bounds.
+func (ot *Octree) Move(from, to [3]float64) bool {
+	return ot.tree.Move(from[:], to[:])
+}
+
+// Find will return the value of one occurrence of the point (x, y, z), or nil if it is not in the tree.
+func (ot *Octree) Find(x, y, z float64) *interface{} {
+	return ot.tree.Find([]float64{x, y, z})
+}
+
+// Contains determines if the point (x, y, z) exists in the tree and returns the result.
+func (ot *Octree) Contains(x, y, z float64) bool {
+	return ot.tree.Find([]float64{x, y, z}) != nil
+}
+
+// Region returns every point inside region, including its faces, and their values in Z order.
+func (ot *Octree) Region(region Box) ([][3]float64, []interface{}) {
+	points := [][3]float64{}
+	values := []interface{}{}
+	ot.tree.Region([]float64{region.MinX, region.MinY, region.MinZ}, []float64{region.MaxX, region.MaxY, region.MaxZ}, func(point []float64, value interface{}) bool {
+		points = append(points, [3]float64{point[0], point[1], point[2]})
+		values = append(values, value)
+		return true
+	})
+	return points, values
+}
+
+// Nearest returns the k points closest to (x, y, z), closest first, and their values.
+func (ot *Octree) Nearest(x, y, z float64, k int) ([][3]float64, []interface{}) {
+	found := ot.tree.Nearest([]float64{x, y, z}, k)
+	points := make([][3]float64, len(found))
+	values := make([]interface{}, len(found))
+	for i, p := range found {
+		points[i] = [3]float64{p.point[0], p.point[1], p.point[2]}
+		values[i] = p.Val
+	}
+	return points, values
+}
+
+// Walk calls fn on every point in Z order, until fn returns false.
+func (ot *Octree) Walk(fn func(x, y, z float64, value interface{}) bool) {
+	ot.tree.Walk(func(point []float64, value interface{}) bool {
+		return fn(point[0], point[1], point[2], value)
+	})
+}
+
+// Points returns every point in Z order and their values.
+func (ot *Octree) Points() ([][3]float64, []interface{}) {
+	return ot.Region(ot.Bounds())
+}
+
+// Bounds returns the box covered by the tree.
+func (ot *Octree) Bounds() Box {
+	lo, hi := ot.tree.lo, ot.tree.hi
+	return Box{MinX: lo[0], MinY: lo[1], MinZ: lo[2], MaxX: hi[0], MaxY: hi[1], MaxZ: hi[2]}
+}
+
+// Clear clears the tree of all points.
+func (ot *Octree) Clear() {
+	ot.tree.Clear()
+}
+
+// Height returns the number of levels in the tree, an empty tree is a single leaf with height 1.
+func (ot *Octree) Height() uint64 {
+	return ot.tree.Height()
+}
+
+func (ot *Octree) Size() uint64 {
+	return ot.tree.size
+}
+
+// Verify walks the whole tree and checks that every point is inside the bounds of its node and every node is split only when it has to be. It returns nil if the tree is valid, otherwise an error describing the first problem found.
+func (ot *Octree) Verify() error {
+	return ot.tree.Verify()
+}
@@ -0,0 +1,100 @@
+package GoTrees
+
+import (
+	"math/rand"
+	"sort"
+	sc "strconv"
+	"testing"
+)
+
+func TestOctreeInsertRemove(t *testing.T) {
+	OT := NewOctree(Box{MaxX: 16, MaxY: 16, MaxZ: 16}, 2, 4)
+	points := [][3]float64{}
+	for i := 0; i < nRAND*5; i++ {
+		p := [3]float64{float64(rand.Intn(17)), float64(rand.Intn(17)), float64(rand.Intn(17))}
+		points = append(points, p)
+		OT.Insert(p[0], p[1], p[2], i)
+		if err := OT.Verify(); err != nil {
+			t.Fatal("Octree failed verification after inserting " + pointString(p[:]) + ": " + err.Error())
+		}
+	}
+	if OT.Insert(0, 0, -1, nil) || OT.Size() != uint64(len(points)) {
+		t.Fatal("Inserted a point outside the bounds. ")
+	}
+
+	if !OT.Move(points[0], [3]float64{16, 16, 16}) {
+		t.Fatal("Failed to move a point. ")
+	}
+	points[0] = [3]float64{16, 16, 16}
+	if err := OT.Verify(); err != nil {
+		t.Fatal("Octree failed verification after moving a point: " + err.Error())
+	}
+
+	rand.Shuffle(len(points), func(i, j int) { points[i], points[j] = points[j], points[i] })
+	for i, p := range points {
+		if !OT.Remove(p[0], p[1], p[2]) {
+			t.Fatal("Failed to remove " + pointString(p[:]))
+		}
+		if err := OT.Verify(); err != nil {
+			t.Fatal("Octree failed verification after removing " + pointString(p[:]) + ": " + err.Error())
+		}
+		if i%10 == 0 {
+			for _, other := range points[i+1:] {
+				if !OT.Contains(other[0], other[1], other[2]) {
+					t.Fatal("Removing " + pointString(p[:]) + " lost " + pointString(other[:]))
+				}
+			}
+		}
+	}
+	if OT.Size() != 0 || OT.Height() != 1 {
+		t.Fatal("Octree should be a single empty leaf after removing every point. ")
+	}
+}
+
+func TestOctreeQueries(t *testing.T) {
+	OT := NewOctree(Box{MinX: -8, MinY: -8, MinZ: -8, MaxX: 8, MaxY: 8, MaxZ: 8}, 4, 6)
+	points := [][3]float64{}
+	for i := 0; i < nRAND*5; i++ {
+		p := [3]float64{rand.Float64()*16 - 8, rand.Float64()*16 - 8, rand.Float64()*16 - 8}
+		points = append(points, p)
+		OT.Insert(p[0], p[1], p[2], i)
+	}
+
+	for i := 0; i < nRAND; i++ {
+		lo := [3]float64{rand.Float64()*16 - 8, rand.Float64()*16 - 8, rand.Float64()*16 - 8}
+		region := Box{MinX: lo[0], MinY: lo[1], MinZ: lo[2], MaxX: lo[0] + 6, MaxY: lo[1] + 6, MaxZ: lo[2] + 6}
+		expected := []int{}
+		for j, p := range points {
+			if inside(p[:], lo[:], []float64{region.MaxX, region.MaxY, region.MaxZ}) {
+				expected = append(expected, j)
+			}
+		}
+		_, found := OT.Region(region)
+		checkSameValues(t, "Region", found, expected)
+
+		k := rand.Intn(10) + 1
+		dists := make([]float64, len(points))
+		for j, p := range points {
+			dists[j] = EuclideanDistance(lo[:], p[:])
+		}
+		sort.Float64s(dists)
+		nearest, _ := OT.Nearest(lo[0], lo[1], lo[2], k)
+		if len(nearest) != k {
+			t.Fatal("Nearest returned " + sc.Itoa(len(nearest)) + " points but should have " + sc.Itoa(k))
+		}
+		for j, p := range nearest {
+			if EuclideanDistance(lo[:], p[:]) != dists[j] {
+				t.Fatal("Nearest point " + sc.Itoa(j) + " to " + pointString(lo[:]) + " was " + pointString(p[:]) + " which is not the " + sc.Itoa(j) + "th closest. ")
+			}
+		}
+	}
+
+	all, _ := OT.Points()
+	if len(all) != len(points) {
+		t.Fatal("Points should return every point in the tree. ")
+	}
+	OT.Clear()
+	if OT.Size() != 0 || OT.Contains(points[0][0], points[0][1], points[0][2]) {
+		t.Fatal("Octree should be empty after Clear. ")
+	}
+}
@@ -0,0 +1,182 @@
+package GoTrees
+
+import (
+	"errors"
+	"strconv"
+)
+
+// PairingHeap is a heap ordered tree where Push, Merge and DecreaseKey take constant time, and Pop takes amortised O(log n) by pairing up the children of the root.
+// Keys that are less than others by the heap's order are popped first, so it is a min heap by default and a max heap with a greater than function.
+type PairingHeap struct {
+	root *HeapHandle
+	less func(a, b int) bool
+	size uint64
+}
+
+// NewPairingHeap returns an empty pairing heap. less orders the keys, nil makes it a min heap.
+func NewPairingHeap(less func(a, b int) bool) PairingHeap {
+	if less == nil {
+		less = minFirst
+	}
+	return PairingHeap{root: nil, less: less, size: 0}
+}
+
+// Push adds key with value to the heap and returns its handle.
+func (h *PairingHeap) Push(key int, value interface{}) *HeapHandle {
+	item := &HeapHandle{key: key, val: value}
+	h.root = h.meld(h.root, item)
+	h.size++
+	return item
+}
+
+// Heapify adds every key with its value (values may be nil), which takes linear time since each Push is constant. It returns the handles in the order of keys.
+func (h *PairingHeap) Heapify(keys []int, values []interface{}) []*HeapHandle {
+	handles := make([]*HeapHandle, len(keys))
+	for i, key := range keys {
+		var value interface{}
+		if values != nil {
+			value = values[i]
+		}
+		handles[i] = h.Push(key, value)
+	}
+	return handles
+}
+
+// Peek returns the first key-value without removing it, or nil if the heap is empty.
+func (h *PairingHeap) Peek() *HeapHandle {
+	return h.root
+}
+
+// Pop removes and returns the first key-value, or nil if the heap is empty.
+func (h *PairingHeap) Pop() *HeapHandle {
+	top := h.root
+	if top == nil {
+		return nil
+	}
+	h.root = h.mergePairs(top.child)
+	if h.root != nil {
+		h.root.prev = nil
+	}
+	top.child = nil
+	top.index = -1
+	h.size--
+	return top
+}
+
+// DecreaseKey changes the key of handle to key, which must not come after the current key in the heap's order. It returns false without changing the heap if it would, or if handle has been popped.
+// The subtree of handle is cut from its parent and melded with the root.
+func (h *PairingHeap) DecreaseKey(handle *HeapHandle, key int) bool {
+	if handle.index < 0 || h.less(handle.key, key) {
+		return false
+	}
+	handle.key = key
+	if handle == h.root {
+		return true
+	}
+	if handle.prev.child == handle {
+		handle.prev.child = handle.next
+	} else {
+		handle.prev.next = handle.next
+	}
+	if handle.next != nil {
+		handle.next.prev = handle.prev
+	}
+	handle.next, handle.prev = nil, nil
+	h.root = h.meld(h.root, handle)
+	return true
+}
+
+// Merge moves every key-value of other into h in constant time, other is left empty. The handles of other now belong to h.
+func (h *PairingHeap) Merge(other *PairingHeap) {
+	h.root = h.meld(h.root, other.root)
+	h.size += other.size
+	other.Clear()
+}
+
+// meld makes the root that comes later the first child of the other, and returns the new root
+func (h *PairingHeap) meld(a, b *HeapHandle) *HeapHandle {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	if h.less(b.key, a.key) {
+		a, b = b, a
+	}
+	b.next = a.child
+	if a.child != nil {
+		a.child.prev = b
+	}
+	b.prev = a
+	a.child = b
+	return a
+}
+
+// mergePairs melds a list of siblings into one tree: first each pair from left to right, then the pairs from right to left
+func (h *PairingHeap) mergePairs(first *HeapHandle) *HeapHandle {
+	pairs := []*HeapHandle{}
+	for first != nil {
+		a, b := first, first.next
+		if b == nil {
+			first = nil
+		} else {
+			first = b.next
+		}
+		a.next, a.prev = nil, nil
+		if b != nil {
+			b.next, b.prev = nil, nil
+		}
+		pairs = append(pairs, h.meld(a, b))
+	}
+	var root *HeapHandle
+	for i := len(pairs) - 1; i >= 0; i-- {
+		root = h.meld(pairs[i], root)
+	}
+	return root
+}
+
+// Clear clears the heap of all key-values.
+func (h *PairingHeap) Clear() {
+	h.root = nil
+	h.size = 0
+}
+
+func (h *PairingHeap) Size() uint64 {
+	return h.size
+}
+
+// Verify checks that no key comes before its parent and that the sibling links agree with each other. It returns nil if the heap is valid, otherwise an error describing the first problem found.
+func (h *PairingHeap) Verify() error {
+	if h.root != nil && (h.root.prev != nil || h.root.next != nil) {
+		return errors.New("root has a parent or sibling")
+	}
+	count := uint64(0)
+	var verify func(n *HeapHandle) error
+	verify = func(n *HeapHandle) error {
+		count++
+		prev := n
+		for c := n.child; c != nil; c = c.next {
+			if c.prev != prev {
+				return errors.New("key " + strconv.Itoa(c.key) + " does not link back to its previous sibling or parent")
+			}
+			if h.less(c.key, n.key) {
+				return errors.New("key " + strconv.Itoa(c.key) + " comes before its parent " + strconv.Itoa(n.key))
+			}
+			if err := verify(c); err != nil {
+				return err
+			}
+			prev = c
+		}
+		return nil
+	}
+	if h.root != nil {
+		if err := verify(h.root); err != nil {
+			return err
+		}
+	}
+	if count != h.size {
+		return errors.New("heap holds " + strconv.FormatUint(count, 10) + " keys but size is " + strconv.FormatUint(h.size, 10))
+	}
+	return nil
+}
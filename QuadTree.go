@@ -0,0 +1,456 @@
+package GoTrees
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// QuadTree is a region quadtree of points with values inside fixed bounds. A leaf holding more than capacity points is split into four quadrants, unless it is at the maximum depth.
+// Duplicate points are allowed. Iteration visits the quadrants in Z order: lower x before upper x, then lower y before upper y.
+type QuadTree struct {
+	tree spatialTree
+}
+
+// NewQuadTree returns an empty quadtree covering bounds. capacity is the number of points a leaf holds before it is split (at least 1) and maxDepth is how many times the bounds can be split.
+func NewQuadTree(bounds Rect, capacity, maxDepth int) QuadTree {
+	return QuadTree{tree: newSpatialTree([]float64{bounds.MinX, bounds.MinY}, []float64{bounds.MaxX, bounds.MaxY}, capacity, maxDepth)}
+}
+
+// Insert will insert the point (x, y) into the tree. It returns false without changing the tree if the point is outside the bounds.
+func (qt *QuadTree) Insert(x, y float64, value interface{}) bool {
+	return qt.tree.Insert([]float64{x, y}, value)
+}
+
+// Remove will remove one occurrence of the point (x, y). It will return whether or not the tree was changed.
+func (qt *QuadTree) Remove(x, y float64) bool {
+	return qt.tree.Remove([]float64{x, y})
+}
+
+// Move will move one occurrence of the point from to the point to, keeping its value. It returns false without changing the tree if the point is not found or the new point is outside the bounds.
+func (qt *QuadTree) Move(from, to [2]float64) bool {
+	return qt.tree.Move(from[:], to[:])
+}
+
+// Find will return the value of one occurrence of the point (x, y), or nil if it is not in the tree.
+func (qt *QuadTree) Find(x, y float64) *interface{} {
+	return qt.tree.Find([]float64{x, y})
+}
+
+// Contains determines if the point (x, y) exists in the tree and returns the result.
+func (qt *QuadTree) Contains(x, y float64) bool {
+	return qt.tree.Find([]float64{x, y}) != nil
+}
+
+// Region returns every point inside region, including its edges, and their values in Z order.
+func (qt *QuadTree) Region(region Rect) ([][2]float64, []interface{}) {
+	points := [][2]float64{}
+	values := []interface{}{}
+	qt.tree.Region([]float64{region.MinX, region.MinY}, []float64{region.MaxX, region.MaxY}, func(point []float64, value interface{}) bool {
+		points = append(points, [2]float64{point[0], point[1]})
+		values = append(values, value)
+		return true
+	})
+	return points, values
+}
+
+// Nearest returns the k points closest to (x, y), closest first, and their values.
+func (qt *QuadTree) Nearest(x, y float64, k int) ([][2]float64, []interface{}) {
+	found := qt.tree.Nearest([]float64{x, y}, k)
+	points := make([][2]float64, len(found))
+	values := make([]interface{}, len(found))
+	for i, p := range found {
+		points[i] = [2]float64{p.point[0], p.point[1]}
+		values[i] = p.Val
+	}
+	return points, values
+}
+
+// Walk calls fn on every point in Z order, until fn returns false.
+func (qt *QuadTree) Walk(fn func(x, y float64, value interface{}) bool) {
+	qt.tree.Walk(func(point []float64, value interface{}) bool {
+		return fn(point[0], point[1], value)
+	})
+}
+
+// Points returns every point in Z order and their values.
+func (qt *QuadTree) Points() ([][2]float64, []interface{}) {
+	return qt.Region(qt.Bounds())
+}
+
+// Bounds returns the region covered by the tree.
+func (qt *QuadTree) Bounds() Rect {
+	return Rect{MinX: qt.tree.lo[0], MinY: qt.tree.lo[1], MaxX: qt.tree.hi[0], MaxY: qt.tree.hi[1]}
+}
+
+// Clear clears the tree of all points.
+func (qt *QuadTree) Clear() {
+	qt.tree.Clear()
+}
+
+// Height returns the number of levels in the tree, an empty tree is a single leaf with height 1.
+func (qt *QuadTree) Height() uint64 {
+	return qt.tree.Height()
+}
+
+func (qt *QuadTree) Size() uint64 {
+	return qt.tree.size
+}
+
+// Verify walks the whole tree and checks that every point is inside the bounds of its node and every node is split only when it has to be. It returns nil if the tree is valid, otherwise an error describing the first problem found.
+func (qt *QuadTree) Verify() error {
+	return qt.tree.Verify()
+}
+
+// spatialTree is the tree behind QuadTree and Octree. Each inner node splits its bounds in half along every axis, giving 2^dims children.
+// Child i holds the upper half along axis a when bit a of i is set, so visiting the children in index order is Z order.
+type spatialTree struct {
+	root     *spatialNode
+	lo, hi   []float64
+	capacity int
+	maxDepth int
+	size     uint64
+}
+
+// spatialNode is a node of a spatialTree. Only leaves hold points, and count is the number of points in the subtree.
+type spatialNode struct {
+	points   []spatialPoint
+	children []*spatialNode
+	count    int
+}
+
+type spatialPoint struct {
+	point []float64
+	Val   interface{}
+}
+
+func newSpatialTree(lo, hi []float64, capacity, maxDepth int) spatialTree {
+	if capacity < 1 {
+		capacity = 1
+	}
+	if maxDepth < 0 {
+		maxDepth = 0
+	}
+	return spatialTree{root: &spatialNode{}, lo: lo, hi: hi, capacity: capacity, maxDepth: maxDepth, size: 0}
+}
+
+// inside determines if point is in the box from lo to hi, including its edges
+func inside(point, lo, hi []float64) bool {
+	for i := range point {
+		if point[i] < lo[i] || point[i] > hi[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// childBounds returns the bounds of child i of the node with bounds lo to hi
+func childBounds(i int, lo, hi []float64) ([]float64, []float64) {
+	childLo := make([]float64, len(lo))
+	childHi := make([]float64, len(hi))
+	for a := range lo {
+		mid := lo[a] + (hi[a]-lo[a])/2
+		if i&(1<<a) != 0 {
+			childLo[a], childHi[a] = mid, hi[a]
+		} else {
+			childLo[a], childHi[a] = lo[a], mid
+		}
+	}
+	return childLo, childHi
+}
+
+// childIndex returns the child of the node with bounds lo to hi that point belongs in. Points on a middle line go to the upper half.
+func childIndex(point, lo, hi []float64) int {
+	i := 0
+	for a := range point {
+		if point[a] >= lo[a]+(hi[a]-lo[a])/2 {
+			i |= 1 << a
+		}
+	}
+	return i
+}
+
+func (st *spatialTree) Insert(point []float64, value interface{}) bool {
+	if !inside(point, st.lo, st.hi) {
+		return false
+	}
+	st.insert(st.root, 0, st.lo, st.hi, spatialPoint{point: point, Val: value})
+	st.size++
+	return true
+}
+
+func (st *spatialTree) insert(n *spatialNode, depth int, lo, hi []float64, p spatialPoint) {
+	n.count++
+	if n.children == nil {
+		n.points = append(n.points, p)
+		if len(n.points) > st.capacity && depth < st.maxDepth {
+			st.split(n, depth, lo, hi)
+		}
+		return
+	}
+	i := childIndex(p.point, lo, hi)
+	childLo, childHi := childBounds(i, lo, hi)
+	st.insert(n.children[i], depth+1, childLo, childHi, p)
+}
+
+// split turns the leaf n into an inner node, moving its points down into new leaves and splitting those again if they are still over capacity
+func (st *spatialTree) split(n *spatialNode, depth int, lo, hi []float64) {
+	n.children = make([]*spatialNode, 1<<len(lo))
+	for i := range n.children {
+		n.children[i] = &spatialNode{}
+	}
+	for _, p := range n.points {
+		child := n.children[childIndex(p.point, lo, hi)]
+		child.points = append(child.points, p)
+		child.count++
+	}
+	n.points = nil
+	for i, child := range n.children {
+		if len(child.points) > st.capacity && depth+1 < st.maxDepth {
+			childLo, childHi := childBounds(i, lo, hi)
+			st.split(child, depth+1, childLo, childHi)
+		}
+	}
+}
+
+func (st *spatialTree) Remove(point []float64) bool {
+	if _, ok := st.remove(st.root, st.lo, st.hi, point); ok {
+		st.size--
+		return true
+	}
+	return false
+}
+
+// remove removes one occurrence of point below n and returns its value. An inner node left with no more than capacity points is collapsed back into a leaf.
+func (st *spatialTree) remove(n *spatialNode, lo, hi, point []float64) (interface{}, bool) {
+	if n.children == nil {
+		for i, p := range n.points {
+			if equalPoints(p.point, point) {
+				n.points = append(n.points[:i], n.points[i+1:]...)
+				n.count--
+				return p.Val, true
+			}
+		}
+		return nil, false
+	}
+	i := childIndex(point, lo, hi)
+	childLo, childHi := childBounds(i, lo, hi)
+	value, ok := st.remove(n.children[i], childLo, childHi, point)
+	if !ok {
+		return nil, false
+	}
+	n.count--
+	if n.count <= st.capacity {
+		points := make([]spatialPoint, 0, n.count)
+		n.walk(func(p spatialPoint) bool {
+			points = append(points, p)
+			return true
+		})
+		n.points, n.children = points, nil
+	}
+	return value, true
+}
+
+func (st *spatialTree) Move(from, to []float64) bool {
+	if !inside(to, st.lo, st.hi) {
+		return false
+	}
+	value, ok := st.remove(st.root, st.lo, st.hi, from)
+	if !ok {
+		return false
+	}
+	st.insert(st.root, 0, st.lo, st.hi, spatialPoint{point: to, Val: value})
+	return true
+}
+
+func (st *spatialTree) Find(point []float64) *interface{} {
+	if !inside(point, st.lo, st.hi) {
+		return nil
+	}
+	n := st.root
+	lo, hi := st.lo, st.hi
+	for n.children != nil {
+		i := childIndex(point, lo, hi)
+		lo, hi = childBounds(i, lo, hi)
+		n = n.children[i]
+	}
+	for i := range n.points {
+		if equalPoints(n.points[i].point, point) {
+			return &n.points[i].Val
+		}
+	}
+	return nil
+}
+
+// Region calls fn on every point inside the box from lo to hi in Z order, until fn returns false. Nodes outside the box are skipped.
+func (st *spatialTree) Region(lo, hi []float64, fn func(point []float64, value interface{}) bool) {
+	var search func(n *spatialNode, nodeLo, nodeHi []float64) bool
+	search = func(n *spatialNode, nodeLo, nodeHi []float64) bool {
+		for a := range lo {
+			if nodeHi[a] < lo[a] || nodeLo[a] > hi[a] {
+				return true
+			}
+		}
+		if n.children == nil {
+			for _, p := range n.points {
+				if inside(p.point, lo, hi) && !fn(p.point, p.Val) {
+					return false
+				}
+			}
+			return true
+		}
+		for i, child := range n.children {
+			childLo, childHi := childBounds(i, nodeLo, nodeHi)
+			if !search(child, childLo, childHi) {
+				return false
+			}
+		}
+		return true
+	}
+	search(st.root, st.lo, st.hi)
+}
+
+// Nearest returns the k points closest to point, closest first. Children are visited closest first and skipped once they are further than the k-th closest point found so far.
+func (st *spatialTree) Nearest(point []float64, k int) []spatialPoint {
+	best := []spatialPoint{}
+	dists := []float64{}
+	var search func(n *spatialNode, lo, hi []float64)
+	search = func(n *spatialNode, lo, hi []float64) {
+		if k <= 0 || n.count == 0 || (len(best) == k && boxDistance(point, lo, hi) > dists[k-1]) {
+			return
+		}
+		if n.children == nil {
+			for _, p := range n.points {
+				d := EuclideanDistance(point, p.point)
+				if len(best) == k && d >= dists[k-1] {
+					continue
+				}
+				i := sort.SearchFloat64s(dists, d)
+				for i < len(dists) && dists[i] == d {
+					i++
+				}
+				best = append(best[:i], append([]spatialPoint{p}, best[i:]...)...)
+				dists = append(dists[:i], append([]float64{d}, dists[i:]...)...)
+				if len(best) > k {
+					best, dists = best[:k], dists[:k]
+				}
+			}
+			return
+		}
+		order := make([]int, len(n.children))
+		childDists := make([]float64, len(n.children))
+		for i := range n.children {
+			order[i] = i
+			childLo, childHi := childBounds(i, lo, hi)
+			childDists[i] = boxDistance(point, childLo, childHi)
+		}
+		sort.Slice(order, func(a, b int) bool {
+			return childDists[order[a]] < childDists[order[b]]
+		})
+		for _, i := range order {
+			childLo, childHi := childBounds(i, lo, hi)
+			search(n.children[i], childLo, childHi)
+		}
+	}
+	search(st.root, st.lo, st.hi)
+	return best
+}
+
+// boxDistance returns the distance from point to the closest point of the box from lo to hi
+func boxDistance(point, lo, hi []float64) float64 {
+	sum := 0.0
+	for a := range point {
+		d := math.Max(0, math.Max(lo[a]-point[a], point[a]-hi[a]))
+		sum += d * d
+	}
+	return math.Sqrt(sum)
+}
+
+func (st *spatialTree) Walk(fn func(point []float64, value interface{}) bool) {
+	st.root.walk(func(p spatialPoint) bool {
+		return fn(p.point, p.Val)
+	})
+}
+
+// walk calls fn on every point below n in Z order, until fn returns false. It returns false if fn stopped the walk.
+func (n *spatialNode) walk(fn func(p spatialPoint) bool) bool {
+	for _, p := range n.points {
+		if !fn(p) {
+			return false
+		}
+	}
+	for _, child := range n.children {
+		if !child.walk(fn) {
+			return false
+		}
+	}
+	return true
+}
+
+func (st *spatialTree) Clear() {
+	st.root = &spatialNode{}
+	st.size = 0
+}
+
+func (st *spatialTree) Height() uint64 {
+	var height func(n *spatialNode) uint64
+	height = func(n *spatialNode) uint64 {
+		h := uint64(0)
+		for _, child := range n.children {
+			if ch := height(child); ch > h {
+				h = ch
+			}
+		}
+		return h + 1
+	}
+	return height(st.root)
+}
+
+func (st *spatialTree) Verify() error {
+	if err := st.verifyNode(st.root, 0, st.lo, st.hi); err != nil {
+		return err
+	}
+	if uint64(st.root.count) != st.size {
+		return errors.New("tree holds " + strconv.Itoa(st.root.count) + " points but size is " + strconv.FormatUint(st.size, 10))
+	}
+	return nil
+}
+
+func (st *spatialTree) verifyNode(n *spatialNode, depth int, lo, hi []float64) error {
+	if n.children == nil {
+		if len(n.points) != n.count {
+			return errors.New("leaf at depth " + strconv.Itoa(depth) + " holds " + strconv.Itoa(len(n.points)) + " points but count is " + strconv.Itoa(n.count))
+		}
+		if n.count > st.capacity && depth < st.maxDepth {
+			return errors.New("leaf at depth " + strconv.Itoa(depth) + " holds " + strconv.Itoa(n.count) + " points and should have been split")
+		}
+		for _, p := range n.points {
+			if !inside(p.point, lo, hi) {
+				return errors.New("point " + pointString(p.point) + " is outside the bounds of its leaf at depth " + strconv.Itoa(depth))
+			}
+		}
+		return nil
+	}
+	if len(n.points) != 0 {
+		return errors.New("inner node at depth " + strconv.Itoa(depth) + " holds points")
+	}
+	if depth >= st.maxDepth {
+		return errors.New("inner node at depth " + strconv.Itoa(depth) + " is deeper than the maximum depth")
+	}
+	if n.count <= st.capacity {
+		return errors.New("inner node at depth " + strconv.Itoa(depth) + " holds " + strconv.Itoa(n.count) + " points and should have been collapsed")
+	}
+	count := 0
+	for i, child := range n.children {
+		childLo, childHi := childBounds(i, lo, hi)
+		if err := st.verifyNode(child, depth+1, childLo, childHi); err != nil {
+			return err
+		}
+		count += child.count
+	}
+	if count != n.count {
+		return errors.New("inner node at depth " + strconv.Itoa(depth) + " has count " + strconv.Itoa(n.count) + " but its children hold " + strconv.Itoa(count))
+	}
+	return nil
+}
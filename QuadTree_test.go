@@ -0,0 +1,150 @@
+package GoTrees
+
+import (
+	"math/rand"
+	"sort"
+	sc "strconv"
+	"testing"
+)
+
+// randomGridPoint returns a point on a grid inside [0, 64] so some points are equal or on the middle lines
+func randomGridPoint() [2]float64 {
+	return [2]float64{float64(rand.Intn(65)), float64(rand.Intn(65))}
+}
+
+func TestQuadTreeInsertRemove(t *testing.T) {
+	for _, capacity := range []int{1, 4} {
+		QT := NewQuadTree(NewRect(0, 0, 64, 64), capacity, 5)
+		points := [][2]float64{}
+		for i := 0; i < nRAND*5; i++ {
+			p := randomGridPoint()
+			points = append(points, p)
+			if !QT.Insert(p[0], p[1], i) {
+				t.Fatal("Failed to insert a point inside the bounds. ")
+			}
+			if err := QT.Verify(); err != nil {
+				t.Fatal("Quadtree failed verification after inserting " + pointString(p[:]) + ": " + err.Error())
+			}
+		}
+		if QT.Insert(65, 0, nil) || QT.Size() != uint64(len(points)) {
+			t.Fatal("Inserted a point outside the bounds. ")
+		}
+		if QT.Height() > 6 {
+			t.Fatal("Quadtree is deeper than its maximum depth. ")
+		}
+
+		rand.Shuffle(len(points), func(i, j int) { points[i], points[j] = points[j], points[i] })
+		for i, p := range points {
+			if !QT.Remove(p[0], p[1]) {
+				t.Fatal("Failed to remove " + pointString(p[:]))
+			}
+			if err := QT.Verify(); err != nil {
+				t.Fatal("Quadtree failed verification after removing " + pointString(p[:]) + ": " + err.Error())
+			}
+			for _, other := range points[i+1:] {
+				if !QT.Contains(other[0], other[1]) {
+					t.Fatal("Removing " + pointString(p[:]) + " lost " + pointString(other[:]))
+				}
+			}
+		}
+		if QT.Size() != 0 || QT.Height() != 1 || QT.Remove(1, 1) {
+			t.Fatal("Quadtree should be a single empty leaf after removing every point. ")
+		}
+	}
+}
+
+func TestQuadTreeMove(t *testing.T) {
+	QT := NewQuadTree(NewRect(0, 0, 64, 64), 2, 6)
+	for i := 0; i < nRAND; i++ {
+		QT.Insert(float64(i%64), float64(i/64), i)
+	}
+	if !QT.Move([2]float64{5, 0}, [2]float64{60, 60}) {
+		t.Fatal("Failed to move a point. ")
+	}
+	if err := QT.Verify(); err != nil {
+		t.Fatal("Quadtree failed verification after moving a point: " + err.Error())
+	}
+	if QT.Contains(5, 0) {
+		t.Fatal("Moved point is still at its old position. ")
+	}
+	if v := QT.Find(60, 60); v == nil || (*v).(int) != 5 {
+		t.Fatal("Moved point did not keep its value. ")
+	}
+	if QT.Move([2]float64{6, 0}, [2]float64{-1, 0}) || !QT.Contains(6, 0) {
+		t.Fatal("Moving a point outside the bounds should not change the tree. ")
+	}
+	if QT.Move([2]float64{63, 63}, [2]float64{1, 1}) || QT.Size() != nRAND {
+		t.Fatal("Moving a point that is not in the tree should not change the tree. ")
+	}
+}
+
+func TestQuadTreeQueries(t *testing.T) {
+	QT := NewQuadTree(NewRect(0, 0, 64, 64), 3, 8)
+	points := [][2]float64{}
+	for i := 0; i < nRAND*5; i++ {
+		p := [2]float64{rand.Float64() * 64, rand.Float64() * 64}
+		points = append(points, p)
+		QT.Insert(p[0], p[1], i)
+	}
+
+	for i := 0; i < nRAND; i++ {
+		a, b := randomGridPoint(), randomGridPoint()
+		region := NewRect(a[0], a[1], b[0], b[1])
+		expected := []int{}
+		for j, p := range points {
+			if region.Contains(NewRect(p[0], p[1], p[0], p[1])) {
+				expected = append(expected, j)
+			}
+		}
+		_, found := QT.Region(region)
+		checkSameValues(t, "Region", found, expected)
+
+		query := randomGridPoint()
+		k := rand.Intn(10) + 1
+		dists := make([]float64, len(points))
+		for j, p := range points {
+			dists[j] = EuclideanDistance(query[:], p[:])
+		}
+		sort.Float64s(dists)
+		nearest, _ := QT.Nearest(query[0], query[1], k)
+		if len(nearest) != k {
+			t.Fatal("Nearest returned " + sc.Itoa(len(nearest)) + " points but should have " + sc.Itoa(k))
+		}
+		for j, p := range nearest {
+			if EuclideanDistance(query[:], p[:]) != dists[j] {
+				t.Fatal("Nearest point " + sc.Itoa(j) + " to " + pointString(query[:]) + " was " + pointString(p[:]) + " which is not the " + sc.Itoa(j) + "th closest. ")
+			}
+		}
+	}
+}
+
+func TestQuadTreeWalkOrder(t *testing.T) {
+	QT := NewQuadTree(NewRect(0, 0, 4, 4), 1, 2)
+	// one point in the middle of every cell of a 4 by 4 grid, inserted in random order
+	for _, i := range rand.Perm(16) {
+		QT.Insert(float64(i%4)+.5, float64(i/4)+.5, i)
+	}
+	// Z order visits the cells of each quadrant before moving to the next quadrant
+	expected := []int{0, 1, 4, 5, 2, 3, 6, 7, 8, 9, 12, 13, 10, 11, 14, 15}
+	actual := []int{}
+	QT.Walk(func(x, y float64, value interface{}) bool {
+		actual = append(actual, value.(int))
+		return true
+	})
+	checkKeys(t, "Walk", actual, expected)
+	_, values := QT.Points()
+	for i, v := range values {
+		if v.(int) != expected[i] {
+			t.Fatal("Points should be in Z order, expected " + sc.Itoa(expected[i]) + " but got " + sc.Itoa(v.(int)))
+		}
+	}
+
+	count := 0
+	QT.Walk(func(x, y float64, value interface{}) bool {
+		count++
+		return count < 3
+	})
+	if count != 3 {
+		t.Fatal("Walk should stop once fn returns false. ")
+	}
+}
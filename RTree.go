@@ -0,0 +1,617 @@
+package GoTrees
+
+import (
+	"container/heap"
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// Rect is an axis aligned rectangle including its edges. A point is a rectangle with Min equal to Max.
+type Rect struct {
+	MinX, MinY, MaxX, MaxY float64
+}
+
+// NewRect returns the rectangle with corners (x1, y1) and (x2, y2), in any order.
+func NewRect(x1, y1, x2, y2 float64) Rect {
+	return Rect{MinX: math.Min(x1, x2), MinY: math.Min(y1, y2), MaxX: math.Max(x1, x2), MaxY: math.Max(y1, y2)}
+}
+
+func (r Rect) Area() float64 {
+	return (r.MaxX - r.MinX) * (r.MaxY - r.MinY)
+}
+
+// Union returns the smallest rectangle containing both r and other.
+func (r Rect) Union(other Rect) Rect {
+	return Rect{MinX: math.Min(r.MinX, other.MinX), MinY: math.Min(r.MinY, other.MinY), MaxX: math.Max(r.MaxX, other.MaxX), MaxY: math.Max(r.MaxY, other.MaxY)}
+}
+
+// Intersects determines if r and other share any point, touching edges count.
+func (r Rect) Intersects(other Rect) bool {
+	return r.MinX <= other.MaxX && other.MinX <= r.MaxX && r.MinY <= other.MaxY && other.MinY <= r.MaxY
+}
+
+// Contains determines if every point of other is in r.
+func (r Rect) Contains(other Rect) bool {
+	return r.MinX <= other.MinX && other.MaxX <= r.MaxX && r.MinY <= other.MinY && other.MaxY <= r.MaxY
+}
+
+// Distance returns the distance from (x, y) to the closest point of r, which is 0 if r contains it.
+func (r Rect) Distance(x, y float64) float64 {
+	dx := math.Max(0, math.Max(r.MinX-x, x-r.MaxX))
+	dy := math.Max(0, math.Max(r.MinY-y, y-r.MaxY))
+	return math.Sqrt(dx*dx + dy*dy)
+}
+
+func (r Rect) String() string {
+	return "[" + strconv.FormatFloat(r.MinX, 'g', -1, 64) + ", " + strconv.FormatFloat(r.MinY, 'g', -1, 64) + " - " + strconv.FormatFloat(r.MaxX, 'g', -1, 64) + ", " + strconv.FormatFloat(r.MaxY, 'g', -1, 64) + "]"
+}
+
+// margin is the half perimeter of r, which the R* split minimises
+func (r Rect) margin() float64 {
+	return (r.MaxX - r.MinX) + (r.MaxY - r.MinY)
+}
+
+// overlap returns the area shared by r and other
+func (r Rect) overlap(other Rect) float64 {
+	w := math.Min(r.MaxX, other.MaxX) - math.Max(r.MinX, other.MinX)
+	h := math.Min(r.MaxY, other.MaxY) - math.Max(r.MinY, other.MinY)
+	if w <= 0 || h <= 0 {
+		return 0
+	}
+	return w * h
+}
+
+// RTreeSplit is the heuristic an RTree uses to split a node that has overflowed
+type RTreeSplit int
+
+const (
+	// QuadraticSplit is Guttman's quadratic split, which starts both nodes from the two rectangles that would waste the most area together
+	QuadraticSplit RTreeSplit = iota
+	// RStarSplit is the split of the R* tree, which sorts along the axis with the smallest total margin and picks the distribution with the least overlap. Forced reinsertion is not done.
+	RStarSplit
+)
+
+// RTree is an R-tree of rectangles with values. Duplicate rectangles are allowed.
+// Like the BTree, a node holds at most 2*t+2 entries, it overflows by one entry and is then split in two, with the original node keeping one half in its own memory.
+// Every node except the root holds at least 40% of the maximum.
+type RTree struct {
+	root     *rTreeNode
+	max, min int
+	split    RTreeSplit
+	size     uint64
+	height   int
+}
+
+// rTreeNode is a node of the r-tree, the entries of a leaf hold values and the entries of an inner node hold children
+type rTreeNode struct {
+	entries []rTreeEntry
+	leaf    bool
+}
+
+// rTreeEntry is a rectangle with either a value or the child node whose entries it bounds
+type rTreeEntry struct {
+	rect  Rect
+	child *rTreeNode
+	Val   interface{}
+}
+
+// NewRTree returns an empty r-tree whose nodes hold up to 2*t+2 entries, split with the given heuristic.
+func NewRTree(t uint, split RTreeSplit) RTree {
+	max := int(2*t + 2)
+	min := max * 2 / 5
+	if min < 1 {
+		min = 1
+	}
+	return RTree{root: newRTreeNode(max, true), max: max, min: min, split: split, size: 0, height: 1}
+}
+
+func newRTreeNode(max int, leaf bool) *rTreeNode {
+	return &rTreeNode{entries: make([]rTreeEntry, 0, max+1), leaf: leaf}
+}
+
+// NewRTreeFromRects returns an r-tree holding rects with their values, packed with the Sort-Tile-Recursive algorithm. values may be nil, otherwise it must be as long as rects.
+// Each level is sorted into vertical slices by the center x, and each slice is sorted by the center y and cut into full nodes, so neighbouring rectangles share nodes.
+func NewRTreeFromRects(t uint, split RTreeSplit, rects []Rect, values []interface{}) RTree {
+	rt := NewRTree(t, split)
+	if len(rects) == 0 {
+		return rt
+	}
+	level := make([]rTreeEntry, len(rects))
+	for i, r := range rects {
+		level[i].rect = r
+		if values != nil {
+			level[i].Val = values[i]
+		}
+	}
+	leaf := true
+	rt.height = 0
+	for {
+		nodes := rt.packSTR(level, leaf)
+		rt.height++
+		if len(nodes) == 1 {
+			rt.root = nodes[0]
+			break
+		}
+		level = make([]rTreeEntry, len(nodes))
+		for i, n := range nodes {
+			level[i] = rTreeEntry{rect: n.bounds(), child: n}
+		}
+		leaf = false
+	}
+	rt.size = uint64(len(rects))
+	return rt
+}
+
+// packSTR packs the entries of one level into nodes as full as possible. Entries are spread evenly between the nodes of a slice, like BTree.bulkLoad does, so no node is left under filled.
+func (rt *RTree) packSTR(entries []rTreeEntry, leaf bool) []*rTreeNode {
+	numNodes := (len(entries) + rt.max - 1) / rt.max
+	numSlices := int(math.Ceil(math.Sqrt(float64(numNodes))))
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].rect.MinX+entries[i].rect.MaxX < entries[j].rect.MinX+entries[j].rect.MaxX
+	})
+	nodes := make([]*rTreeNode, 0, numNodes)
+	for s := 0; s < numSlices; s++ {
+		slice := entries[s*len(entries)/numSlices : (s+1)*len(entries)/numSlices]
+		sort.Slice(slice, func(i, j int) bool {
+			return slice[i].rect.MinY+slice[i].rect.MaxY < slice[j].rect.MinY+slice[j].rect.MaxY
+		})
+		sliceNodes := (len(slice) + rt.max - 1) / rt.max
+		for n := 0; n < sliceNodes; n++ {
+			node := newRTreeNode(rt.max, leaf)
+			node.entries = append(node.entries, slice[n*len(slice)/sliceNodes:(n+1)*len(slice)/sliceNodes]...)
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+// Insert will insert rect into the tree. Duplicate rectangles are allowed.
+func (rt *RTree) Insert(rect Rect, value interface{}) {
+	rt.insertEntry(rTreeEntry{rect: rect, Val: value}, 1)
+	rt.size++
+}
+
+// insertEntry adds entry to a node at the given level, counting leaves as level 1, then splits overflowing nodes on the way back up
+func (rt *RTree) insertEntry(entry rTreeEntry, level int) {
+	path := []*rTreeNode{rt.root}
+	indexes := []int{}
+	for h := rt.height; h > level; h-- {
+		curr := path[len(path)-1]
+		i := rt.chooseSubtree(curr, entry.rect)
+		path = append(path, curr.entries[i].child)
+		indexes = append(indexes, i)
+	}
+	curr := path[len(path)-1]
+	curr.entries = append(curr.entries, entry)
+
+	for d := len(path) - 1; d >= 0; d-- {
+		node := path[d]
+		var sibling *rTreeNode
+		if len(node.entries) > rt.max {
+			sibling = rt.splitNode(node)
+		}
+		if d == 0 {
+			if sibling != nil {
+				// the root split, grow the tree by one level
+				rt.root = newRTreeNode(rt.max, false)
+				rt.root.entries = append(rt.root.entries, rTreeEntry{rect: node.bounds(), child: node}, rTreeEntry{rect: sibling.bounds(), child: sibling})
+				rt.height++
+			}
+			break
+		}
+		parent := path[d-1]
+		parent.entries[indexes[d-1]].rect = node.bounds()
+		if sibling != nil {
+			parent.entries = append(parent.entries, rTreeEntry{rect: sibling.bounds(), child: sibling})
+		}
+	}
+}
+
+// chooseSubtree returns the index of the entry of n to descend into for rect. Both heuristics pick the least area enlargement, the R* split first picks the least overlap enlargement when the children are leaves.
+func (rt *RTree) chooseSubtree(n *rTreeNode, rect Rect) int {
+	best := 0
+	bestOverlap, bestEnlarge, bestArea := math.Inf(1), math.Inf(1), math.Inf(1)
+	overlapFirst := rt.split == RStarSplit && n.entries[0].child.leaf
+	for i, e := range n.entries {
+		grown := e.rect.Union(rect)
+		overlap := 0.0
+		if overlapFirst {
+			for j, other := range n.entries {
+				if j != i {
+					overlap += grown.overlap(other.rect) - e.rect.overlap(other.rect)
+				}
+			}
+		}
+		enlarge := grown.Area() - e.rect.Area()
+		area := e.rect.Area()
+		if overlap < bestOverlap || (overlap == bestOverlap && (enlarge < bestEnlarge || (enlarge == bestEnlarge && area < bestArea))) {
+			best, bestOverlap, bestEnlarge, bestArea = i, overlap, enlarge, area
+		}
+	}
+	return best
+}
+
+// splitNode splits the overflowing node n in two with the heuristic of the tree. n keeps the first group in its own memory and the second group is returned as a new node.
+func (rt *RTree) splitNode(n *rTreeNode) *rTreeNode {
+	var left, right []rTreeEntry
+	if rt.split == RStarSplit {
+		left, right = rt.splitRStar(n.entries)
+	} else {
+		left, right = rt.splitQuadratic(n.entries)
+	}
+	sibling := newRTreeNode(rt.max, n.leaf)
+	sibling.entries = append(sibling.entries, right...)
+	n.entries = append(n.entries[:0], left...)
+	return sibling
+}
+
+// splitQuadratic is Guttman's quadratic split of entries into two groups of at least min entries
+func (rt *RTree) splitQuadratic(entries []rTreeEntry) ([]rTreeEntry, []rTreeEntry) {
+	// the seeds are the pair that would waste the most area in one node
+	seedA, seedB := 0, 1
+	worst := math.Inf(-1)
+	for i := range entries {
+		for j := i + 1; j < len(entries); j++ {
+			waste := entries[i].rect.Union(entries[j].rect).Area() - entries[i].rect.Area() - entries[j].rect.Area()
+			if waste > worst {
+				seedA, seedB, worst = i, j, waste
+			}
+		}
+	}
+	left := []rTreeEntry{entries[seedA]}
+	right := []rTreeEntry{entries[seedB]}
+	leftRect, rightRect := entries[seedA].rect, entries[seedB].rect
+	remaining := make([]rTreeEntry, 0, len(entries)-2)
+	for i, e := range entries {
+		if i != seedA && i != seedB {
+			remaining = append(remaining, e)
+		}
+	}
+
+	for len(remaining) > 0 {
+		// a group that needs every remaining entry to reach the minimum takes them all
+		if len(left)+len(remaining) == rt.min {
+			left = append(left, remaining...)
+			break
+		}
+		if len(right)+len(remaining) == rt.min {
+			right = append(right, remaining...)
+			break
+		}
+		// place the entry with the strongest preference for one group next
+		next, nextDiff := 0, -1.0
+		for i, e := range remaining {
+			diff := math.Abs((leftRect.Union(e.rect).Area() - leftRect.Area()) - (rightRect.Union(e.rect).Area() - rightRect.Area()))
+			if diff > nextDiff {
+				next, nextDiff = i, diff
+			}
+		}
+		e := remaining[next]
+		remaining = append(remaining[:next], remaining[next+1:]...)
+		growLeft := leftRect.Union(e.rect).Area() - leftRect.Area()
+		growRight := rightRect.Union(e.rect).Area() - rightRect.Area()
+		if growLeft < growRight || (growLeft == growRight && (leftRect.Area() < rightRect.Area() || (leftRect.Area() == rightRect.Area() && len(left) <= len(right)))) {
+			left = append(left, e)
+			leftRect = leftRect.Union(e.rect)
+		} else {
+			right = append(right, e)
+			rightRect = rightRect.Union(e.rect)
+		}
+	}
+	return left, right
+}
+
+// splitRStar is the R* split of entries into two groups of at least min entries
+func (rt *RTree) splitRStar(entries []rTreeEntry) ([]rTreeEntry, []rTreeEntry) {
+	sorts := []func(a, b Rect) bool{
+		func(a, b Rect) bool { return a.MinX < b.MinX || (a.MinX == b.MinX && a.MaxX < b.MaxX) },
+		func(a, b Rect) bool { return a.MaxX < b.MaxX || (a.MaxX == b.MaxX && a.MinX < b.MinX) },
+		func(a, b Rect) bool { return a.MinY < b.MinY || (a.MinY == b.MinY && a.MaxY < b.MaxY) },
+		func(a, b Rect) bool { return a.MaxY < b.MaxY || (a.MaxY == b.MaxY && a.MinY < b.MinY) },
+	}
+	sorted := make([][]rTreeEntry, len(sorts))
+	for i, less := range sorts {
+		sorted[i] = append([]rTreeEntry{}, entries...)
+		sort.SliceStable(sorted[i], func(a, b int) bool {
+			return less(sorted[i][a].rect, sorted[i][b].rect)
+		})
+	}
+
+	// choose the axis whose distributions have the smallest total margin, x is sorts 0 and 1, y is sorts 2 and 3
+	bestAxis, bestMargin := 0, math.Inf(1)
+	for axis := 0; axis < 2; axis++ {
+		margin := 0.0
+		for _, s := range sorted[2*axis : 2*axis+2] {
+			for k := rt.min; k <= len(entries)-rt.min; k++ {
+				margin += entriesBounds(s[:k]).margin() + entriesBounds(s[k:]).margin()
+			}
+		}
+		if margin < bestMargin {
+			bestAxis, bestMargin = axis, margin
+		}
+	}
+
+	// along that axis, choose the distribution with the least overlap, then the least area
+	var left, right []rTreeEntry
+	bestOverlap, bestArea := math.Inf(1), math.Inf(1)
+	for _, s := range sorted[2*bestAxis : 2*bestAxis+2] {
+		for k := rt.min; k <= len(entries)-rt.min; k++ {
+			a, b := entriesBounds(s[:k]), entriesBounds(s[k:])
+			overlap, area := a.overlap(b), a.Area()+b.Area()
+			if overlap < bestOverlap || (overlap == bestOverlap && area < bestArea) {
+				left, right = s[:k], s[k:]
+				bestOverlap, bestArea = overlap, area
+			}
+		}
+	}
+	return left, right
+}
+
+// Delete will delete one occurrence of rect. Nodes left with less than the minimum number of entries are removed and their entries inserted again. It will return whether or not the tree was changed.
+func (rt *RTree) Delete(rect Rect) bool {
+	path := []*rTreeNode{}
+	indexes := []int{}
+	if !rt.findLeaf(rt.root, rect, &path, &indexes) {
+		return false
+	}
+	leaf := path[len(path)-1]
+	i := indexes[len(indexes)-1]
+	leaf.entries = append(leaf.entries[:i], leaf.entries[i+1:]...)
+	rt.size--
+
+	// condense the tree, collecting the entries of under filled nodes with the level they belong to
+	type orphan struct {
+		entry rTreeEntry
+		level int
+	}
+	orphans := []orphan{}
+	for d := len(path) - 1; d > 0; d-- {
+		node := path[d]
+		parent := path[d-1]
+		if len(node.entries) < rt.min {
+			parent.entries = append(parent.entries[:indexes[d-1]], parent.entries[indexes[d-1]+1:]...)
+			for _, e := range node.entries {
+				orphans = append(orphans, orphan{entry: e, level: rt.height - d})
+			}
+		} else {
+			parent.entries[indexes[d-1]].rect = node.bounds()
+		}
+	}
+	// an inner root with one child is not needed
+	for !rt.root.leaf && len(rt.root.entries) == 1 {
+		rt.root = rt.root.entries[0].child
+		rt.height--
+	}
+	if !rt.root.leaf && len(rt.root.entries) == 0 {
+		rt.root = newRTreeNode(rt.max, true)
+		rt.height = 1
+	}
+	for _, o := range orphans {
+		if o.level > rt.height {
+			// the tree shrank below the level of the orphan, put back its leaf entries instead
+			for _, e := range subtreeEntries(o.entry.child) {
+				rt.insertEntry(e, 1)
+			}
+		} else {
+			rt.insertEntry(o.entry, o.level)
+		}
+	}
+	return true
+}
+
+// findLeaf finds an entry equal to rect below n, recording the nodes and entry indexes on the way to it
+func (rt *RTree) findLeaf(n *rTreeNode, rect Rect, path *[]*rTreeNode, indexes *[]int) bool {
+	*path = append(*path, n)
+	for i, e := range n.entries {
+		if n.leaf {
+			if e.rect == rect {
+				*indexes = append(*indexes, i)
+				return true
+			}
+		} else if e.rect.Contains(rect) {
+			*indexes = append(*indexes, i)
+			if rt.findLeaf(e.child, rect, path, indexes) {
+				return true
+			}
+			*indexes = (*indexes)[:len(*indexes)-1]
+		}
+	}
+	*path = (*path)[:len(*path)-1]
+	return false
+}
+
+// Find will return the value of one occurrence of rect, or nil if it is not in the tree.
+func (rt *RTree) Find(rect Rect) *interface{} {
+	path := []*rTreeNode{}
+	indexes := []int{}
+	if !rt.findLeaf(rt.root, rect, &path, &indexes) {
+		return nil
+	}
+	return &path[len(path)-1].entries[indexes[len(indexes)-1]].Val
+}
+
+// Contains determines if rect exists in the tree and returns the result.
+func (rt *RTree) Contains(rect Rect) bool {
+	return rt.Find(rect) != nil
+}
+
+// Intersecting returns every rectangle that shares a point with query, and their values.
+func (rt *RTree) Intersecting(query Rect) ([]Rect, []interface{}) {
+	return rt.search(query.Intersects, query.Intersects)
+}
+
+// Within returns every rectangle that lies entirely inside query, and their values.
+func (rt *RTree) Within(query Rect) ([]Rect, []interface{}) {
+	return rt.search(query.Intersects, query.Contains)
+}
+
+// Containing returns every rectangle that contains all of query, and their values. Use a point rectangle to find the rectangles a point is in.
+func (rt *RTree) Containing(query Rect) ([]Rect, []interface{}) {
+	contains := func(r Rect) bool {
+		return r.Contains(query)
+	}
+	return rt.search(contains, contains)
+}
+
+// search returns the leaf entries that match, descending only into nodes whose bounds pass visit
+func (rt *RTree) search(visit, match func(r Rect) bool) ([]Rect, []interface{}) {
+	rects := []Rect{}
+	values := []interface{}{}
+	var walk func(n *rTreeNode)
+	walk = func(n *rTreeNode) {
+		for _, e := range n.entries {
+			if n.leaf {
+				if match(e.rect) {
+					rects = append(rects, e.rect)
+					values = append(values, e.Val)
+				}
+			} else if visit(e.rect) {
+				walk(e.child)
+			}
+		}
+	}
+	walk(rt.root)
+	return rects, values
+}
+
+// Nearest returns the k rectangles closest to (x, y), closest first, and their values. Nodes are visited best first, by the distance to their bounds.
+func (rt *RTree) Nearest(x, y float64, k int) ([]Rect, []interface{}) {
+	rects := []Rect{}
+	values := []interface{}{}
+	queue := &rTreeQueue{}
+	for _, e := range rt.root.entries {
+		heap.Push(queue, rTreeQueueItem{entry: e, leaf: rt.root.leaf, dist: e.rect.Distance(x, y)})
+	}
+	for queue.Len() > 0 && len(rects) < k {
+		item := heap.Pop(queue).(rTreeQueueItem)
+		if item.leaf {
+			// nothing left in the queue can be closer
+			rects = append(rects, item.entry.rect)
+			values = append(values, item.entry.Val)
+			continue
+		}
+		for _, e := range item.entry.child.entries {
+			heap.Push(queue, rTreeQueueItem{entry: e, leaf: item.entry.child.leaf, dist: e.rect.Distance(x, y)})
+		}
+	}
+	return rects, values
+}
+
+// rTreeQueue is a min heap of entries by distance for Nearest
+type rTreeQueue []rTreeQueueItem
+
+type rTreeQueueItem struct {
+	entry rTreeEntry
+	leaf  bool
+	dist  float64
+}
+
+func (q rTreeQueue) Len() int            { return len(q) }
+func (q rTreeQueue) Less(i, j int) bool  { return q[i].dist < q[j].dist }
+func (q rTreeQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *rTreeQueue) Push(x interface{}) { *q = append(*q, x.(rTreeQueueItem)) }
+func (q *rTreeQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
+
+// Rects returns every rectangle in the tree and their values, in the order of the leaves.
+func (rt *RTree) Rects() ([]Rect, []interface{}) {
+	return rt.search(func(r Rect) bool { return true }, func(r Rect) bool { return true })
+}
+
+// Bounds returns the smallest rectangle containing every rectangle in the tree, or the zero Rect if it is empty.
+func (rt *RTree) Bounds() Rect {
+	return rt.root.bounds()
+}
+
+// Clear clears the tree of all nodes.
+func (rt *RTree) Clear() {
+	rt.root = newRTreeNode(rt.max, true)
+	rt.size = 0
+	rt.height = 1
+}
+
+// Height returns the number of levels in the tree, an empty tree is a single leaf with height 1.
+func (rt *RTree) Height() uint64 {
+	return uint64(rt.height)
+}
+
+func (rt *RTree) Size() uint64 {
+	return rt.size
+}
+
+// Verify walks the whole tree and checks that every node is within its fill limits, every leaf is at the same depth and every inner entry is the exact bounds of its child. It returns nil if the tree is valid, otherwise an error describing the first problem found.
+func (rt *RTree) Verify() error {
+	count := uint64(0)
+	if err := rt.verifyNode(rt.root, 1, &count); err != nil {
+		return err
+	}
+	if count != rt.size {
+		return errors.New("tree holds " + strconv.FormatUint(count, 10) + " rectangles but size is " + strconv.FormatUint(rt.size, 10))
+	}
+	return nil
+}
+
+func (rt *RTree) verifyNode(n *rTreeNode, depth int, count *uint64) error {
+	if len(n.entries) > rt.max {
+		return errors.New("node at depth " + strconv.Itoa(depth) + " holds " + strconv.Itoa(len(n.entries)) + " entries, more than the maximum " + strconv.Itoa(rt.max))
+	}
+	if n != rt.root && len(n.entries) < rt.min {
+		return errors.New("node at depth " + strconv.Itoa(depth) + " holds " + strconv.Itoa(len(n.entries)) + " entries, less than the minimum " + strconv.Itoa(rt.min))
+	}
+	if n.leaf {
+		if depth != rt.height {
+			return errors.New("leaf at depth " + strconv.Itoa(depth) + " but the tree has height " + strconv.Itoa(rt.height))
+		}
+		*count += uint64(len(n.entries))
+		return nil
+	}
+	if n == rt.root && len(n.entries) < 2 {
+		return errors.New("inner root holds less than 2 entries")
+	}
+	for _, e := range n.entries {
+		if e.child == nil {
+			return errors.New("inner node at depth " + strconv.Itoa(depth) + " has an entry without a child")
+		}
+		if bounds := e.child.bounds(); bounds != e.rect {
+			return errors.New("entry " + e.rect.String() + " at depth " + strconv.Itoa(depth) + " does not match the bounds of its child " + bounds.String())
+		}
+		if err := rt.verifyNode(e.child, depth+1, count); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// bounds returns the smallest rectangle containing every entry of n
+func (n *rTreeNode) bounds() Rect {
+	return entriesBounds(n.entries)
+}
+
+func entriesBounds(entries []rTreeEntry) Rect {
+	if len(entries) == 0 {
+		return Rect{}
+	}
+	bounds := entries[0].rect
+	for _, e := range entries[1:] {
+		bounds = bounds.Union(e.rect)
+	}
+	return bounds
+}
+
+// subtreeEntries returns every leaf entry below n
+func subtreeEntries(n *rTreeNode) []rTreeEntry {
+	if n.leaf {
+		return n.entries
+	}
+	entries := []rTreeEntry{}
+	for _, e := range n.entries {
+		entries = append(entries, subtreeEntries(e.child)...)
+	}
+	return entries
+}
@@ -0,0 +1,181 @@
+package GoTrees
+
+import (
+	"math/rand"
+	"sort"
+	sc "strconv"
+	"testing"
+)
+
+// randomRect returns a small rectangle on a grid so some rectangles are equal or touch
+func randomRect() Rect {
+	x, y := float64(rand.Intn(100)), float64(rand.Intn(100))
+	return NewRect(x, y, x+float64(rand.Intn(10)), y+float64(rand.Intn(10)))
+}
+
+func TestRTreeInsert(t *testing.T) {
+	for _, split := range []RTreeSplit{QuadraticSplit, RStarSplit} {
+		for _, degree := range []uint{0, 1, 4} {
+			RT := NewRTree(degree, split)
+			rects := []Rect{}
+			for i := 0; i < nRAND*5; i++ {
+				r := randomRect()
+				rects = append(rects, r)
+				RT.Insert(r, i)
+				if err := RT.Verify(); err != nil {
+					t.Fatal("R-tree with t " + sc.Itoa(int(degree)) + " failed verification after inserting " + r.String() + ": " + err.Error())
+				}
+			}
+			for i, r := range rects {
+				if !RT.Contains(r) {
+					t.Fatal("Could not find " + r.String() + " with value " + sc.Itoa(i))
+				}
+			}
+			if RT.Contains(NewRect(-5, -5, -1, -1)) {
+				t.Fatal("Found a rectangle that was not in the tree. ")
+			}
+			if RT.Size() != uint64(len(rects)) {
+				t.Fatal("R-tree size incorrect after inserting. ")
+			}
+		}
+	}
+}
+
+func TestRTreeDelete(t *testing.T) {
+	for _, split := range []RTreeSplit{QuadraticSplit, RStarSplit} {
+		RT := NewRTree(1, split)
+		rects := []Rect{}
+		for i := 0; i < nRAND*5; i++ {
+			r := randomRect()
+			rects = append(rects, r)
+			RT.Insert(r, i)
+		}
+		rand.Shuffle(len(rects), func(i, j int) { rects[i], rects[j] = rects[j], rects[i] })
+		for i, r := range rects {
+			if !RT.Delete(r) {
+				t.Fatal("Failed to delete " + r.String())
+			}
+			if err := RT.Verify(); err != nil {
+				t.Fatal("R-tree failed verification after deleting " + r.String() + ": " + err.Error())
+			}
+			for _, other := range rects[i+1:] {
+				if !RT.Contains(other) {
+					t.Fatal("Deleting " + r.String() + " lost " + other.String())
+				}
+			}
+		}
+		if RT.Size() != 0 || RT.Height() != 1 || RT.Delete(rects[0]) {
+			t.Fatal("R-tree should be empty after deleting every rectangle. ")
+		}
+	}
+}
+
+func TestRTreeFromRects(t *testing.T) {
+	for _, n := range []int{0, 1, 5, nRAND, nRAND * 10} {
+		rects := make([]Rect, n)
+		values := make([]interface{}, n)
+		for i := range rects {
+			rects[i] = randomRect()
+			values[i] = i
+		}
+		RT := NewRTreeFromRects(2, RStarSplit, append([]Rect{}, rects...), values)
+		if err := RT.Verify(); err != nil {
+			t.Fatal("R-tree failed verification after loading " + sc.Itoa(n) + " rectangles: " + err.Error())
+		}
+		if RT.Size() != uint64(n) {
+			t.Fatal("R-tree size incorrect after loading. ")
+		}
+		for _, r := range rects {
+			if !RT.Contains(r) {
+				t.Fatal("Could not find " + r.String() + " after loading. ")
+			}
+		}
+		// the loaded tree should keep working with inserts and deletes
+		for i := 0; i < nRAND; i++ {
+			RT.Insert(randomRect(), nil)
+		}
+		for _, r := range rects {
+			RT.Delete(r)
+		}
+		if err := RT.Verify(); err != nil {
+			t.Fatal("Loaded r-tree failed verification after updates: " + err.Error())
+		}
+	}
+}
+
+func TestRTreeSearch(t *testing.T) {
+	rects := make([]Rect, nRAND*10)
+	values := make([]interface{}, len(rects))
+	for i := range rects {
+		rects[i] = randomRect()
+		values[i] = i
+	}
+	RT := NewRTreeFromRects(3, QuadraticSplit, append([]Rect{}, rects...), values)
+
+	for i := 0; i < nRAND; i++ {
+		query := randomRect()
+		query.MaxX += 20
+		query.MaxY += 20
+		intersecting, within, containing := []int{}, []int{}, []int{}
+		for j, r := range rects {
+			if r.Intersects(query) {
+				intersecting = append(intersecting, j)
+			}
+			if query.Contains(r) {
+				within = append(within, j)
+			}
+			if r.Contains(NewRect(query.MinX, query.MinY, query.MinX, query.MinY)) {
+				containing = append(containing, j)
+			}
+		}
+		_, found := RT.Intersecting(query)
+		checkSameValues(t, "Intersecting", found, intersecting)
+		_, found = RT.Within(query)
+		checkSameValues(t, "Within", found, within)
+		_, found = RT.Containing(NewRect(query.MinX, query.MinY, query.MinX, query.MinY))
+		checkSameValues(t, "Containing", found, containing)
+	}
+}
+
+func TestRTreeNearest(t *testing.T) {
+	RT := NewRTree(2, RStarSplit)
+	rects := []Rect{}
+	for i := 0; i < nRAND*5; i++ {
+		r := randomRect()
+		rects = append(rects, r)
+		RT.Insert(r, i)
+	}
+	for i := 0; i < nRAND; i++ {
+		x, y := rand.Float64()*120-10, rand.Float64()*120-10
+		k := rand.Intn(10) + 1
+		dists := make([]float64, len(rects))
+		for j, r := range rects {
+			dists[j] = r.Distance(x, y)
+		}
+		sort.Float64s(dists)
+		found, _ := RT.Nearest(x, y, k)
+		if len(found) != k {
+			t.Fatal("Nearest returned " + sc.Itoa(len(found)) + " rectangles but should have " + sc.Itoa(k))
+		}
+		for j, r := range found {
+			if r.Distance(x, y) != dists[j] {
+				t.Fatal("Nearest rectangle " + sc.Itoa(j) + " was " + r.String() + " which is not the " + sc.Itoa(j) + "th closest. ")
+			}
+		}
+	}
+}
+
+func TestRTreeClear(t *testing.T) {
+	RT := NewRTree(0, QuadraticSplit)
+	for i := 0; i < nRAND; i++ {
+		RT.Insert(randomRect(), i)
+	}
+	RT.Clear()
+	if RT.Size() != 0 || RT.Height() != 1 || RT.Bounds() != (Rect{}) {
+		t.Fatal("R-tree should be empty after Clear. ")
+	}
+	RT.Insert(NewRect(1, 1, 2, 2), nil)
+	if RT.Bounds() != NewRect(1, 1, 2, 2) {
+		t.Fatal("R-tree should be usable after Clear. ")
+	}
+}
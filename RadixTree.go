@@ -0,0 +1,316 @@
+package GoTrees
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+)
+
+// RadixTree is a compressed trie with string keys. Chains of nodes with a single child are merged into one node holding the shared part of the keys, and iteration is in lexicographic (byte) order.
+// Unlike the int keyed trees its keys are unique, so there is no Insert that keeps duplicates: Put stores a key or replaces its value, as Put does on a BTree.
+type RadixTree struct {
+	root *radixNode
+	size uint64
+}
+
+// radixNode is a node of the radix tree. prefix is the part of the key added by this node, and children are sorted by the first byte of their prefix.
+type radixNode struct {
+	prefix   string
+	leaf     bool
+	Val      interface{}
+	children []*radixNode
+}
+
+// NewRadixTree returns an empty radix tree.
+func NewRadixTree() RadixTree {
+	return RadixTree{root: &radixNode{}, size: 0}
+}
+
+// Put will set the value of key, inserting it if it is not in the radix tree.
+func (rt *RadixTree) Put(key string, value interface{}) {
+	if rt.root == nil {
+		rt.root = &radixNode{}
+	}
+	n := rt.root
+	search := key
+	for {
+		if search == "" {
+			// this node ends the key
+			if !n.leaf {
+				rt.size++
+			}
+			n.leaf = true
+			n.Val = value
+			return
+		}
+		i, child := n.child(search[0])
+		if child == nil {
+			n.addChild(&radixNode{prefix: search, leaf: true, Val: value})
+			rt.size++
+			return
+		}
+		common := commonPrefixLength(search, child.prefix)
+		if common < len(child.prefix) {
+			// key leaves the prefix of child part way through, split the child where they differ
+			split := &radixNode{prefix: child.prefix[:common], children: []*radixNode{child}}
+			child.prefix = child.prefix[common:]
+			n.children[i] = split
+			child = split
+		}
+		n = child
+		search = search[common:]
+	}
+}
+
+// Find will find key in the radix tree and return its value, or nil if it is not there.
+func (rt *RadixTree) Find(key string) *interface{} {
+	n := rt.findNode(key)
+	if n == nil || !n.leaf {
+		return nil
+	}
+	return &n.Val
+}
+
+// Contains determines if key exists in the radix tree and returns the result.
+func (rt *RadixTree) Contains(key string) bool {
+	return rt.Find(key) != nil
+}
+
+// Delete will delete key from the radix tree. It will return whether or not the tree was changed.
+func (rt *RadixTree) Delete(key string) bool {
+	if rt.root == nil {
+		return false
+	}
+	// parents of n are kept so emptied nodes can be removed and single children merged back up
+	parents := []*radixNode{}
+	n := rt.root
+	search := key
+	for search != "" {
+		_, child := n.child(search[0])
+		if child == nil || len(search) < len(child.prefix) || search[:len(child.prefix)] != child.prefix {
+			return false
+		}
+		parents = append(parents, n)
+		n = child
+		search = search[len(child.prefix):]
+	}
+	if !n.leaf {
+		return false
+	}
+	n.leaf = false
+	n.Val = nil
+	rt.size--
+
+	if n == rt.root {
+		return true
+	}
+	parent := parents[len(parents)-1]
+	if len(n.children) == 0 {
+		parent.removeChild(n.prefix[0])
+		// the parent may now be a pass through node with a single child
+		if parent != rt.root && !parent.leaf && len(parent.children) == 1 {
+			parent.mergeChild()
+		}
+	} else if len(n.children) == 1 {
+		n.mergeChild()
+	}
+	return true
+}
+
+// LongestPrefix finds the longest key in the radix tree that is a prefix of s. It returns the key and its value, or ("", nil) if no key is a prefix of s.
+func (rt *RadixTree) LongestPrefix(s string) (string, *interface{}) {
+	if rt.root == nil {
+		return "", nil
+	}
+	var best *radixNode
+	bestLength := 0
+	n := rt.root
+	consumed := 0
+	for {
+		if n.leaf {
+			best, bestLength = n, consumed
+		}
+		if consumed == len(s) {
+			break
+		}
+		_, child := n.child(s[consumed])
+		if child == nil || len(s)-consumed < len(child.prefix) || s[consumed:consumed+len(child.prefix)] != child.prefix {
+			break
+		}
+		n = child
+		consumed += len(child.prefix)
+	}
+	if best == nil {
+		return "", nil
+	}
+	return s[:bestLength], &best.Val
+}
+
+// WalkPrefix calls fn on every key that starts with prefix, in lexicographic order, until fn returns false.
+func (rt *RadixTree) WalkPrefix(prefix string, fn func(key string, value interface{}) bool) {
+	if rt.root == nil {
+		return
+	}
+	n := rt.root
+	key := ""
+	search := prefix
+	for search != "" {
+		_, child := n.child(search[0])
+		if child == nil {
+			return
+		}
+		common := commonPrefixLength(search, child.prefix)
+		if common == len(search) {
+			// the prefix ends inside (or at the end of) this child, so every key below it matches
+			n, key = child, key+child.prefix
+			break
+		}
+		if common < len(child.prefix) {
+			return
+		}
+		n, key = child, key+child.prefix
+		search = search[common:]
+	}
+	walkRadix(n, key, fn)
+}
+
+// Keys returns every key in lexicographic order.
+func (rt *RadixTree) Keys() []string {
+	keys := make([]string, 0, rt.size)
+	rt.WalkPrefix("", func(key string, value interface{}) bool {
+		keys = append(keys, key)
+		return true
+	})
+	return keys
+}
+
+// Values returns every value in lexicographic order of the keys.
+func (rt *RadixTree) Values() []interface{} {
+	vals := make([]interface{}, 0, rt.size)
+	rt.WalkPrefix("", func(key string, value interface{}) bool {
+		vals = append(vals, value)
+		return true
+	})
+	return vals
+}
+
+// Clear clears the radix tree of all nodes.
+func (rt *RadixTree) Clear() {
+	rt.root = &radixNode{}
+	rt.size = 0
+}
+
+func (rt *RadixTree) Size() uint64 {
+	return rt.size
+}
+
+// Verify walks the whole radix tree and checks that it is sorted and fully compressed. It returns nil if the tree is valid, otherwise an error describing the first problem found.
+func (rt *RadixTree) Verify() error {
+	count := uint64(0)
+	nodeStack := []*radixNode{rt.root}
+	for len(nodeStack) > 0 {
+		n := nodeStack[len(nodeStack)-1]
+		nodeStack = nodeStack[:len(nodeStack)-1]
+		if n.leaf {
+			count++
+		}
+		if n != rt.root && !n.leaf && len(n.children) < 2 {
+			return errors.New("node " + strconv.Quote(n.prefix) + " is not compressed, it has " + strconv.Itoa(len(n.children)) + " children and no key")
+		}
+		for i, child := range n.children {
+			if child == nil || child.prefix == "" {
+				return errors.New("node " + strconv.Quote(n.prefix) + " has a nil or empty child")
+			}
+			if i > 0 && n.children[i-1].prefix[0] >= child.prefix[0] {
+				return errors.New("children of node " + strconv.Quote(n.prefix) + " are not sorted by their first byte")
+			}
+			nodeStack = append(nodeStack, child)
+		}
+	}
+	if count != rt.size {
+		return errors.New("tree holds " + strconv.FormatUint(count, 10) + " keys but size is " + strconv.FormatUint(rt.size, 10))
+	}
+	return nil
+}
+
+// findNode returns the node that ends exactly at key, or nil if key ends part way through a node or leaves the tree
+func (rt *RadixTree) findNode(key string) *radixNode {
+	if rt.root == nil {
+		return nil
+	}
+	n := rt.root
+	search := key
+	for search != "" {
+		_, child := n.child(search[0])
+		if child == nil || len(search) < len(child.prefix) || search[:len(child.prefix)] != child.prefix {
+			return nil
+		}
+		n = child
+		search = search[len(child.prefix):]
+	}
+	return n
+}
+
+// walkRadix calls fn on every key in the subtree of n in order. key is the full key up to and including n. It returns false once the walk should stop.
+func walkRadix(n *radixNode, key string, fn func(key string, value interface{}) bool) bool {
+	nodeStack := []*radixNode{n}
+	keyStack := []string{key}
+	for len(nodeStack) > 0 {
+		n, key = nodeStack[len(nodeStack)-1], keyStack[len(keyStack)-1]
+		nodeStack, keyStack = nodeStack[:len(nodeStack)-1], keyStack[:len(keyStack)-1]
+		// a key comes before every longer key that starts with it
+		if n.leaf && !fn(key, n.Val) {
+			return false
+		}
+		// push the children in reverse so the smallest is visited first
+		for i := len(n.children) - 1; i >= 0; i-- {
+			nodeStack = append(nodeStack, n.children[i])
+			keyStack = append(keyStack, key+n.children[i].prefix)
+		}
+	}
+	return true
+}
+
+// child returns the index and child whose prefix starts with b, or nil if there is none
+func (n *radixNode) child(b byte) (int, *radixNode) {
+	i := sort.Search(len(n.children), func(i int) bool {
+		return n.children[i].prefix[0] >= b
+	})
+	if i < len(n.children) && n.children[i].prefix[0] == b {
+		return i, n.children[i]
+	}
+	return i, nil
+}
+
+// addChild adds child in sorted position, there must not be a child with the same first byte
+func (n *radixNode) addChild(child *radixNode) {
+	i, _ := n.child(child.prefix[0])
+	n.children = append(n.children, nil)
+	copy(n.children[i+1:], n.children[i:])
+	n.children[i] = child
+}
+
+// removeChild removes the child whose prefix starts with b
+func (n *radixNode) removeChild(b byte) {
+	if i, child := n.child(b); child != nil {
+		n.children = append(n.children[:i], n.children[i+1:]...)
+	}
+}
+
+// mergeChild merges the only child of n into n to keep the path compressed
+func (n *radixNode) mergeChild() {
+	child := n.children[0]
+	n.prefix += child.prefix
+	n.leaf = child.leaf
+	n.Val = child.Val
+	n.children = child.children
+}
+
+// commonPrefixLength returns the number of leading bytes a and b share
+func commonPrefixLength(a, b string) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return i
+}
@@ -0,0 +1,155 @@
+package GoTrees
+
+import (
+	"math/rand"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// randomWord returns a short word from a small alphabet so words share prefixes
+func randomWord() string {
+	word := make([]byte, rand.Intn(6))
+	for i := range word {
+		word[i] = "abc"[rand.Intn(3)]
+	}
+	return string(word)
+}
+
+func TestRadixTreePut(t *testing.T) {
+	RT := NewRadixTree()
+	words := map[string]int{}
+
+	for i := 0; i < nRAND; i++ {
+		word := randomWord()
+		words[word] = i
+		RT.Put(word, i)
+		if err := RT.Verify(); err != nil {
+			t.Fatal("Radix tree failed verification after inserting " + word + ": " + err.Error())
+		}
+	}
+	if RT.Size() != uint64(len(words)) {
+		t.Fatal("Radix tree size incorrect, inserting a key twice should replace it. ")
+	}
+	for word, i := range words {
+		if v := RT.Find(word); v == nil || (*v).(int) != i {
+			t.Fatal("Could not find the latest value of " + word)
+		}
+	}
+	if RT.Contains("abcabc") {
+		t.Fatal("Found a key that was not in the tree. ")
+	}
+
+	expected := []string{}
+	for word := range words {
+		expected = append(expected, word)
+	}
+	sort.Strings(expected)
+	keys := RT.Keys()
+	vals := RT.Values()
+	for i, k := range keys {
+		if k != expected[i] {
+			t.Fatal("Radix tree key was incorrect, expected " + expected[i] + " but got " + k)
+		}
+		if vals[i].(int) != words[k] {
+			t.Fatal("Radix tree value was incorrect for " + k)
+		}
+	}
+}
+
+func TestRadixTreeDelete(t *testing.T) {
+	RT := NewRadixTree()
+	words := map[string]bool{}
+	for i := 0; i < nRAND; i++ {
+		word := randomWord()
+		words[word] = true
+		RT.Put(word, word)
+	}
+
+	if RT.Delete("abcabc") {
+		t.Fatal("Delete returned true for a key that was not in the tree. ")
+	}
+	for word := range words {
+		if !RT.Delete(word) {
+			t.Fatal("Radix tree returned false when tree should have been modified. ")
+		}
+		delete(words, word)
+		if RT.Contains(word) {
+			t.Fatal("Found deleted key " + word)
+		}
+		if err := RT.Verify(); err != nil {
+			t.Fatal("Radix tree failed verification after deleting " + word + ": " + err.Error())
+		}
+		for other := range words {
+			if !RT.Contains(other) {
+				t.Fatal("Tree is missing key that wasn't deleted yet: " + other)
+			}
+		}
+	}
+	if RT.Size() != 0 || len(RT.Keys()) != 0 {
+		t.Fatal("Radix tree was not empty after deleting every key. ")
+	}
+}
+
+func TestRadixTreeLongestPrefix(t *testing.T) {
+	RT := NewRadixTree()
+	for _, word := range []string{"a", "abc", "abcde", "b", "ba"} {
+		RT.Put(word, word)
+	}
+
+	cases := map[string]string{"abcd": "abc", "abcdef": "abcde", "ab": "a", "bb": "b", "ba": "ba", "c": ""}
+	for s, expected := range cases {
+		key, v := RT.LongestPrefix(s)
+		if key != expected {
+			t.Fatal("Longest prefix of " + s + " was " + key + " but expected " + expected)
+		}
+		if (expected == "") != (v == nil) || (v != nil && (*v).(string) != expected) {
+			t.Fatal("Longest prefix of " + s + " returned the wrong value. ")
+		}
+	}
+
+	RT.Put("", "empty")
+	if key, v := RT.LongestPrefix("c"); key != "" || v == nil || (*v).(string) != "empty" {
+		t.Fatal("The empty key should be a prefix of every string. ")
+	}
+}
+
+func TestRadixTreeWalkPrefix(t *testing.T) {
+	RT := NewRadixTree()
+	words := map[string]bool{}
+	for i := 0; i < nRAND; i++ {
+		word := randomWord()
+		words[word] = true
+		RT.Put(word, word)
+	}
+
+	for _, prefix := range []string{"", "a", "ab", "abc", "cc", "bca", "aaaaaa"} {
+		expected := []string{}
+		for word := range words {
+			if strings.HasPrefix(word, prefix) {
+				expected = append(expected, word)
+			}
+		}
+		sort.Strings(expected)
+		actual := []string{}
+		RT.WalkPrefix(prefix, func(key string, value interface{}) bool {
+			if value.(string) != key {
+				t.Fatal("WalkPrefix returned the wrong value for " + key)
+			}
+			actual = append(actual, key)
+			return true
+		})
+		if strings.Join(actual, ",") != strings.Join(expected, ",") {
+			t.Fatal("WalkPrefix of " + prefix + " returned " + strings.Join(actual, ",") + " but expected " + strings.Join(expected, ","))
+		}
+	}
+
+	count := 0
+	RT.WalkPrefix("", func(key string, value interface{}) bool {
+		count++
+		return count < 3
+	})
+	if count != 3 {
+		t.Fatal("WalkPrefix did not stop when fn returned false. ")
+	}
+}
@@ -0,0 +1,428 @@
+package GoTrees
+
+import (
+	"errors"
+	"io"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// ropeChunk is the most bytes a rope node holds. Larger chunks make the tree smaller, smaller chunks make edits inside a chunk copy less.
+const ropeChunk = 512
+
+// Rope is a string stored as chunks in a balanced tree, so inserting and deleting in the middle of a large text takes O(log n) instead of copying the whole string.
+// The tree is a treap like Treap, but ordered by position instead of by key: each node holds a chunk and knows how many bytes and newlines are in its subtree, and Split and Merge find positions by those counts.
+// Positions are byte offsets, and lines and columns count from 0 with columns in bytes. Operations panic on a position outside the text, like slicing a string does.
+type Rope struct {
+	root *ropeNode
+	rng  *rand.Rand
+}
+
+// ropeNode is a chunk of text with a random priority no larger than its parent's, and the number of bytes and newlines in its subtree
+type ropeNode struct {
+	text string
+	// newlines is the number of newlines in text, so recounting a subtree does not scan its chunk again
+	newlines    int
+	priority    uint64
+	length      int
+	lines       int
+	Left, Right *ropeNode
+}
+
+// NewRope returns a rope holding str. The priorities come from a random source seeded with seed, so the same edits give the same tree. A Rope{} is empty and uses the seed 1.
+func NewRope(str string, seed int64) Rope {
+	r := Rope{root: nil, rng: rand.New(rand.NewSource(seed))}
+	r.root = r.build(str)
+	return r
+}
+
+func (r *Rope) newNode(text string) *ropeNode {
+	if r.rng == nil {
+		r.rng = rand.New(rand.NewSource(1))
+	}
+	n := &ropeNode{priority: r.rng.Uint64()}
+	n.setText(text)
+	n.update()
+	return n
+}
+
+// setText replaces the chunk of n and counts its newlines, the subtree counts still need an update
+func (n *ropeNode) setText(text string) {
+	n.text = text
+	n.newlines = strings.Count(text, "\n")
+}
+
+// build makes a tree of the chunks of str in order
+func (r *Rope) build(str string) *ropeNode {
+	var root *ropeNode
+	for len(str) > 0 {
+		size := ropeChunk
+		if len(str) < size {
+			size = len(str)
+		}
+		root = mergeTreaps(root, r.newNode(str[:size]))
+		str = str[size:]
+	}
+	return root
+}
+
+func ropeLength(n *ropeNode) int {
+	if n == nil {
+		return 0
+	}
+	return n.length
+}
+
+func ropeLines(n *ropeNode) int {
+	if n == nil {
+		return 0
+	}
+	return n.lines
+}
+
+// update recounts the subtree of n after its text or one of its children changed
+func (n *ropeNode) update() {
+	n.length = len(n.text) + ropeLength(n.Left) + ropeLength(n.Right)
+	n.lines = n.newlines + ropeLines(n.Left) + ropeLines(n.Right)
+}
+
+func (n *ropeNode) treapPriority() uint64 {
+	return n.priority
+}
+
+func (n *ropeNode) children() (*ropeNode, *ropeNode) {
+	return n.Left, n.Right
+}
+
+func (n *ropeNode) setChildren(left, right *ropeNode) {
+	n.Left, n.Right = left, right
+}
+
+// split cuts n into the first pos bytes and the rest. The whole chunks before pos are split off like a Treap splits by key,
+// then a chunk that pos falls inside is cut in two and its first half becomes a new node at the end of left.
+func (r *Rope) split(n *ropeNode, pos int) (*ropeNode, *ropeNode) {
+	// offset is pos within the subtree the split has reached
+	offset := pos
+	left, right := splitTreapBy(n, func(n *ropeNode) bool {
+		end := ropeLength(n.Left) + len(n.text)
+		if offset < end {
+			return false
+		}
+		offset -= end
+		return true
+	})
+	if cut := pos - ropeLength(left); cut > 0 {
+		left = mergeTreaps(left, r.newNode(cutFirst(right, cut)))
+	}
+	return left, right
+}
+
+// cutFirst removes the first cut bytes of the first chunk of n, which must be longer than cut, and returns them
+func cutFirst(n *ropeNode, cut int) string {
+	var head string
+	if n.Left != nil {
+		head = cutFirst(n.Left, cut)
+	} else {
+		head = n.text[:cut]
+		n.setText(n.text[cut:])
+	}
+	n.update()
+	return head
+}
+
+// appendToLast adds str to the end of the last chunk of n if it still fits in a chunk, so typing does not make a node per keystroke. It returns whether str was added.
+func appendToLast(n *ropeNode, str string) bool {
+	if n == nil {
+		return false
+	}
+	if n.Right != nil {
+		if !appendToLast(n.Right, str) {
+			return false
+		}
+	} else if len(n.text)+len(str) <= ropeChunk {
+		n.text += str
+		n.newlines += strings.Count(str, "\n")
+	} else {
+		return false
+	}
+	n.update()
+	return true
+}
+
+// checkPosition panics if pos is not in [0, length], since an edit outside the text can not be made
+func checkPosition(pos, length int) {
+	if pos < 0 || pos > length {
+		panic("Rope: position " + strconv.Itoa(pos) + " is out of range [0, " + strconv.Itoa(length) + "]")
+	}
+}
+
+// checkRange panics if [start, end) is not a range of the text
+func checkRange(start, end, length int) {
+	checkPosition(start, length)
+	checkPosition(end, length)
+	if start > end {
+		panic("Rope: range start " + strconv.Itoa(start) + " is after its end " + strconv.Itoa(end))
+	}
+}
+
+// Len returns the number of bytes in the rope.
+func (r *Rope) Len() int {
+	return ropeLength(r.root)
+}
+
+// Insert will insert str so it starts at the byte position at.
+func (r *Rope) Insert(at int, str string) {
+	checkPosition(at, r.Len())
+	if len(str) == 0 {
+		return
+	}
+	left, right := r.split(r.root, at)
+	if len(str) > ropeChunk || !appendToLast(left, str) {
+		left = mergeTreaps(left, r.build(str))
+	}
+	r.root = mergeTreaps(left, right)
+}
+
+// Delete will delete the bytes in [start, end).
+func (r *Rope) Delete(start, end int) {
+	checkRange(start, end, r.Len())
+	left, right := r.split(r.root, end)
+	left, _ = r.split(left, start)
+	r.root = mergeTreaps(left, right)
+}
+
+// Index returns the byte at position i.
+func (r *Rope) Index(i int) byte {
+	if i < 0 || i >= r.Len() {
+		panic("Rope: index " + strconv.Itoa(i) + " is out of range [0, " + strconv.Itoa(r.Len()) + ")")
+	}
+	n := r.root
+	for {
+		leftLength := ropeLength(n.Left)
+		if i < leftLength {
+			n = n.Left
+		} else if i < leftLength+len(n.text) {
+			return n.text[i-leftLength]
+		} else {
+			i -= leftLength + len(n.text)
+			n = n.Right
+		}
+	}
+}
+
+// Substring returns the bytes in [start, end) as a string. Only the chunks that overlap the range are visited.
+func (r *Rope) Substring(start, end int) string {
+	checkRange(start, end, r.Len())
+	var sb strings.Builder
+	sb.Grow(end - start)
+	var walk func(n *ropeNode, offset int)
+	walk = func(n *ropeNode, offset int) {
+		// offset is the position of the first byte of the subtree of n
+		if n == nil || offset >= end || offset+n.length <= start {
+			return
+		}
+		walk(n.Left, offset)
+		textStart := offset + ropeLength(n.Left)
+		lo, hi := start-textStart, end-textStart
+		if lo < 0 {
+			lo = 0
+		}
+		if hi > len(n.text) {
+			hi = len(n.text)
+		}
+		if lo < hi {
+			sb.WriteString(n.text[lo:hi])
+		}
+		walk(n.Right, textStart+len(n.text))
+	}
+	walk(r.root, 0)
+	return sb.String()
+}
+
+// String returns the whole text of the rope.
+func (r *Rope) String() string {
+	return r.Substring(0, r.Len())
+}
+
+// Concat appends the text of other to r in O(log n). other is left empty since its nodes are reused.
+func (r *Rope) Concat(other *Rope) {
+	r.root = mergeTreaps(r.root, other.root)
+	other.root = nil
+}
+
+// Split cuts the rope in two at the byte position at in O(log n). left holds the text before at and right the rest.
+// Both ropes share the random source of r, and r is left empty since its nodes are reused.
+func (r *Rope) Split(at int) (left Rope, right Rope) {
+	checkPosition(at, r.Len())
+	l, rr := r.split(r.root, at)
+	r.root = nil
+	return Rope{root: l, rng: r.rng}, Rope{root: rr, rng: r.rng}
+}
+
+// Lines returns the number of lines in the rope, which is one more than the number of newlines.
+func (r *Rope) Lines() int {
+	return ropeLines(r.root) + 1
+}
+
+// LineColumn returns the line and column of the byte position at. A newline is the last byte of its line.
+func (r *Rope) LineColumn(at int) (line, column int) {
+	checkPosition(at, r.Len())
+	// count the newlines before at, then the start of the line is just after the last of them
+	n, pos := r.root, at
+	for n != nil {
+		leftLength := ropeLength(n.Left)
+		if pos < leftLength {
+			n = n.Left
+			continue
+		}
+		line += ropeLines(n.Left)
+		if pos < leftLength+len(n.text) {
+			line += strings.Count(n.text[:pos-leftLength], "\n")
+			break
+		}
+		line += n.newlines
+		pos -= leftLength + len(n.text)
+		n = n.Right
+	}
+	return line, at - r.lineStart(line)
+}
+
+// Offset returns the byte position of column in line, the inverse of LineColumn. It panics if line does not exist or column is past the end of the line.
+func (r *Rope) Offset(line, column int) int {
+	start, end := r.lineBounds(line)
+	if column < 0 || start+column > end {
+		panic("Rope: column " + strconv.Itoa(column) + " is out of range [0, " + strconv.Itoa(end-start) + "] of line " + strconv.Itoa(line))
+	}
+	return start + column
+}
+
+// Line returns the text of line without its newline.
+func (r *Rope) Line(line int) string {
+	start, end := r.lineBounds(line)
+	if end > start && r.Index(end-1) == '\n' {
+		end--
+	}
+	return r.Substring(start, end)
+}
+
+// lineBounds returns the position of the first byte of line and the position just after its newline, or the end of the text for the last line
+func (r *Rope) lineBounds(line int) (int, int) {
+	if line < 0 || line >= r.Lines() {
+		panic("Rope: line " + strconv.Itoa(line) + " is out of range [0, " + strconv.Itoa(r.Lines()) + ")")
+	}
+	end := r.Len()
+	if line+1 < r.Lines() {
+		end = r.lineStart(line + 1)
+	}
+	return r.lineStart(line), end
+}
+
+// lineStart returns the position just after the newline that ends line-1, which is 0 for the first line
+func (r *Rope) lineStart(line int) int {
+	if line == 0 {
+		return 0
+	}
+	// k is the number of the newline being looked for within the subtree of n, counting from 1
+	n, k, pos := r.root, line, 0
+	for {
+		leftLines := ropeLines(n.Left)
+		if k <= leftLines {
+			n = n.Left
+			continue
+		}
+		k -= leftLines
+		pos += ropeLength(n.Left)
+		if k > n.newlines {
+			k -= n.newlines
+			pos += len(n.text)
+			n = n.Right
+			continue
+		}
+		i := -1
+		for ; k > 0; k-- {
+			i += strings.IndexByte(n.text[i+1:], '\n') + 1
+		}
+		return pos + i + 1
+	}
+}
+
+// Reader returns an io.Reader over the text of the rope, which reads the chunks in order without building the whole string. The rope must not change while it is read.
+func (r *Rope) Reader() io.Reader {
+	reader := &ropeReader{}
+	reader.pushLeft(r.root)
+	return reader
+}
+
+// ropeReader walks the chunks in order with a stack of the nodes whose chunk and right subtree are still to be read
+type ropeReader struct {
+	stack []*ropeNode
+	// chunk is the rest of the chunk being read
+	chunk string
+}
+
+func (rr *ropeReader) pushLeft(n *ropeNode) {
+	for n != nil {
+		rr.stack = append(rr.stack, n)
+		n = n.Left
+	}
+}
+
+func (rr *ropeReader) Read(p []byte) (int, error) {
+	read := 0
+	for read < len(p) {
+		if len(rr.chunk) == 0 {
+			if len(rr.stack) == 0 {
+				break
+			}
+			n := rr.stack[len(rr.stack)-1]
+			rr.stack = rr.stack[:len(rr.stack)-1]
+			rr.pushLeft(n.Right)
+			rr.chunk = n.text
+		}
+		copied := copy(p[read:], rr.chunk)
+		rr.chunk = rr.chunk[copied:]
+		read += copied
+	}
+	if read == 0 && len(p) > 0 {
+		return 0, io.EOF
+	}
+	return read, nil
+}
+
+// Verify walks the whole rope and checks that priorities are in heap order, every chunk holds 1 to ropeChunk bytes and every subtree count is correct.
+// It returns nil if the rope is valid, otherwise an error describing the first problem found.
+func (r *Rope) Verify() error {
+	pos := 0
+	var verify func(n *ropeNode) error
+	verify = func(n *ropeNode) error {
+		if n == nil {
+			return nil
+		}
+		for _, child := range []*ropeNode{n.Left, n.Right} {
+			if child != nil && child.priority > n.priority {
+				return errors.New("chunk at " + strconv.Itoa(pos) + " has a child with a higher priority")
+			}
+		}
+		if err := verify(n.Left); err != nil {
+			return err
+		}
+		if len(n.text) == 0 || len(n.text) > ropeChunk {
+			return errors.New("chunk at " + strconv.Itoa(pos) + " holds " + strconv.Itoa(len(n.text)) + " bytes")
+		}
+		if newlines := strings.Count(n.text, "\n"); n.newlines != newlines {
+			return errors.New("chunk at " + strconv.Itoa(pos) + " counts " + strconv.Itoa(n.newlines) + " newlines but has " + strconv.Itoa(newlines))
+		}
+		pos += len(n.text)
+		if err := verify(n.Right); err != nil {
+			return err
+		}
+		length := len(n.text) + ropeLength(n.Left) + ropeLength(n.Right)
+		lines := n.newlines + ropeLines(n.Left) + ropeLines(n.Right)
+		if n.length != length || n.lines != lines {
+			return errors.New("chunk ending at " + strconv.Itoa(pos) + " counts " + strconv.Itoa(n.length) + " bytes and " + strconv.Itoa(n.lines) + " newlines in its subtree but has " + strconv.Itoa(length) + " and " + strconv.Itoa(lines))
+		}
+		return nil
+	}
+	return verify(r.root)
+}
@@ -0,0 +1,202 @@
+package GoTrees
+
+import (
+	"io"
+	"math/rand"
+	sc "strconv"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// randomText returns n random bytes of lowercase letters and newlines
+func randomText(n int) string {
+	var sb strings.Builder
+	for i := 0; i < n; i++ {
+		if rand.Intn(20) == 0 {
+			sb.WriteByte('\n')
+		} else {
+			sb.WriteByte(byte('a' + rand.Intn(26)))
+		}
+	}
+	return sb.String()
+}
+
+// checkRope checks that the rope is valid and holds expected
+func checkRope(t *testing.T, name string, r *Rope, expected string) {
+	if err := r.Verify(); err != nil {
+		t.Fatal(err)
+	}
+	if r.Len() != len(expected) || r.String() != expected {
+		t.Fatal(name + " holds " + sc.Quote(r.String()) + " but expected " + sc.Quote(expected))
+	}
+}
+
+func TestRopeEmptyAllOps(t *testing.T) {
+	R := Rope{}
+
+	R.Insert(0, "")
+	R.Delete(0, 0)
+	left, right := R.Split(0)
+	line, column := R.LineColumn(0)
+
+	if R.Len() != 0 || R.String() != "" || R.Lines() != 1 || R.Line(0) != "" || line != 0 || column != 0 || left.Len() != 0 || right.Len() != 0 || R.Verify() != nil {
+		t.Fatal("A rope operation failed when the rope was empty ")
+	}
+	if read, err := io.ReadAll(R.Reader()); err != nil || len(read) != 0 {
+		t.Fatal("Reading an empty rope returned bytes or an error. ")
+	}
+}
+
+func TestRopeEdits(t *testing.T) {
+	text := randomText(ropeChunk * 5)
+	R := NewRope(text, int64(T))
+	checkRope(t, "New rope", &R, text)
+
+	for i := 0; i < nRAND*5; i++ {
+		if rand.Intn(2) == 0 {
+			at := rand.Intn(len(text) + 1)
+			// mostly short inserts like typing, sometimes whole chunks like pasting
+			str := randomText(rand.Intn(10))
+			if rand.Intn(10) == 0 {
+				str = randomText(rand.Intn(ropeChunk * 3))
+			}
+			R.Insert(at, str)
+			text = text[:at] + str + text[at:]
+		} else {
+			start := rand.Intn(len(text) + 1)
+			end := start + rand.Intn(len(text)-start+1)/4
+			R.Delete(start, end)
+			text = text[:start] + text[end:]
+		}
+		checkRope(t, "Rope after "+sc.Itoa(i+1)+" edits", &R, text)
+	}
+}
+
+func TestRopeIndexSubstring(t *testing.T) {
+	text := randomText(ropeChunk*3 + 7)
+	R := NewRope("", int64(T))
+	// insert in pieces so the chunks are not all full
+	for i := 0; i < len(text); i += 37 {
+		R.Insert(R.Len(), text[i:min(i+37, len(text))])
+	}
+	for i := 0; i < len(text); i++ {
+		if R.Index(i) != text[i] {
+			t.Fatal("Rope byte at " + sc.Itoa(i) + " was incorrect. ")
+		}
+	}
+	for i := 0; i < nRAND; i++ {
+		start := rand.Intn(len(text) + 1)
+		end := start + rand.Intn(len(text)-start+1)
+		if actual := R.Substring(start, end); actual != text[start:end] {
+			t.Fatal("Rope substring [" + sc.Itoa(start) + ", " + sc.Itoa(end) + ") was incorrect. ")
+		}
+	}
+}
+
+func TestRopeSplitConcat(t *testing.T) {
+	for i := 0; i < nRAND; i++ {
+		text := randomText(rand.Intn(ropeChunk * 4))
+		R := NewRope(text, int64(i))
+		at := rand.Intn(len(text) + 1)
+
+		left, right := R.Split(at)
+		checkRope(t, "Left rope of split at "+sc.Itoa(at), &left, text[:at])
+		checkRope(t, "Right rope of split at "+sc.Itoa(at), &right, text[at:])
+		if R.Len() != 0 {
+			t.Fatal("Split did not leave the rope empty. ")
+		}
+
+		right.Concat(&left)
+		checkRope(t, "Concatenated rope", &right, text[at:]+text[:at])
+		if left.Len() != 0 {
+			t.Fatal("Concat did not leave the other rope empty. ")
+		}
+	}
+}
+
+func TestRopeLines(t *testing.T) {
+	text := randomText(ropeChunk * 4)
+	R := NewRope(text, int64(T))
+	R.Insert(0, "\n")
+	R.Insert(R.Len(), "\n")
+	text = "\n" + text + "\n"
+	lines := strings.Split(text, "\n")
+
+	if R.Lines() != len(lines) {
+		t.Fatal("Rope has " + sc.Itoa(R.Lines()) + " lines but expected " + sc.Itoa(len(lines)))
+	}
+	for i, line := range lines {
+		if R.Line(i) != line {
+			t.Fatal("Rope line " + sc.Itoa(i) + " was " + sc.Quote(R.Line(i)) + " but expected " + sc.Quote(line))
+		}
+	}
+	line, column := 0, 0
+	for at := 0; at <= len(text); at++ {
+		actualLine, actualColumn := R.LineColumn(at)
+		if actualLine != line || actualColumn != column {
+			t.Fatal("Position " + sc.Itoa(at) + " is at " + sc.Itoa(actualLine) + ":" + sc.Itoa(actualColumn) + " but expected " + sc.Itoa(line) + ":" + sc.Itoa(column))
+		}
+		if R.Offset(line, column) != at {
+			t.Fatal("Offset of " + sc.Itoa(line) + ":" + sc.Itoa(column) + " was " + sc.Itoa(R.Offset(line, column)) + " but expected " + sc.Itoa(at))
+		}
+		if at < len(text) && text[at] == '\n' {
+			line, column = line+1, 0
+		} else {
+			column++
+		}
+	}
+}
+
+func TestRopeReader(t *testing.T) {
+	text := randomText(ropeChunk*3 + 11)
+	R := NewRope(text, int64(T))
+	R.Insert(ropeChunk, "inserted")
+	text = text[:ropeChunk] + "inserted" + text[ropeChunk:]
+
+	// TestReader reads with many buffer sizes and checks the io.Reader contract
+	if err := iotest.TestReader(R.Reader(), []byte(text)); err != nil {
+		t.Fatal(err)
+	}
+	if read, err := io.ReadAll(iotest.OneByteReader(R.Reader())); err != nil || string(read) != text {
+		t.Fatal("Reading the rope one byte at a time was incorrect. ")
+	}
+}
+
+func TestRopePanics(t *testing.T) {
+	R := NewRope("ab\ncd", 1)
+	for name, fn := range map[string]func(){
+		"Insert past the end":  func() { R.Insert(6, "x") },
+		"Delete reversed":      func() { R.Delete(3, 2) },
+		"Index at the end":     func() { R.Index(5) },
+		"Line past the last":   func() { R.Line(2) },
+		"Offset past the line": func() { R.Offset(0, 4) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatal(name + " did not panic. ")
+				}
+			}()
+			fn()
+		}()
+	}
+}
+
+func BenchmarkRopeInsert(b *testing.B) {
+	text := randomText(1 << 20)
+	R := NewRope(text, 1)
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		R.Insert(rand.Intn(R.Len()+1), "x")
+	}
+}
+
+func BenchmarkRopeStringInsert(b *testing.B) {
+	text := randomText(1 << 20)
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		at := rand.Intn(len(text) + 1)
+		text = text[:at] + "x" + text[at:]
+	}
+}
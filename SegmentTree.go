@@ -0,0 +1,158 @@
+package GoTrees
+
+// SegmentTree answers range aggregate queries over an indexed array. The aggregate is defined by an associative combine function and its identity, e.g. (a + b, 0) for sums or min(a, b) with the largest value for minimums.
+// Both point and range updates are supported. The only range update is assignment of one value to every index in the range, applied lazily; there is no range add.
+// An assigned segment needs the combination of its length copies of the value, which the repeat function given to NewSegmentTree returns in one step, e.g. value * count for sums or just value for minimums.
+// With a repeat function updates and queries take O(log n). Without one the copies are combined by repeated doubling, so a range update or a query below one takes O(log² n).
+// Indexes start at 0 and ranges are inclusive on both ends.
+type SegmentTree[T any] struct {
+	n        int
+	combine  func(a, b T) T
+	identity T
+	// tree holds the aggregate of each segment, with the root at 1 and the children of i at 2i and 2i+1
+	tree []T
+	// lazy holds an assignment that still has to be pushed down to the children of a segment
+	lazy    []T
+	hasLazy []bool
+	// repeatFn combines count copies of a value in one step, nil uses repeated doubling
+	repeatFn func(value T, count int) T
+}
+
+// NewSegmentTree builds a segment tree over a copy of values in O(n). repeat returns the combination of count copies of value and must agree with combine.
+// repeat may be nil for an aggregate that has no such shortcut, which makes range updates take O(log² n).
+func NewSegmentTree[T any](values []T, combine func(a, b T) T, identity T, repeat func(value T, count int) T) SegmentTree[T] {
+	st := SegmentTree[T]{n: len(values), combine: combine, identity: identity, repeatFn: repeat}
+	if st.n == 0 {
+		return st
+	}
+	st.tree = make([]T, 4*st.n)
+	st.lazy = make([]T, 4*st.n)
+	st.hasLazy = make([]bool, 4*st.n)
+	st.build(1, 0, st.n-1, values)
+	return st
+}
+
+func (st *SegmentTree[T]) build(i, lo, hi int, values []T) {
+	if lo == hi {
+		st.tree[i] = values[lo]
+		return
+	}
+	mid := (lo + hi) / 2
+	st.build(2*i, lo, mid, values)
+	st.build(2*i+1, mid+1, hi, values)
+	st.tree[i] = st.combine(st.tree[2*i], st.tree[2*i+1])
+}
+
+// Len returns the number of indexes in the segment tree.
+func (st *SegmentTree[T]) Len() int {
+	return st.n
+}
+
+// Get returns the value at index i, or the identity if i is out of range.
+func (st *SegmentTree[T]) Get(i int) T {
+	return st.Query(i, i)
+}
+
+// Set assigns value to index i. Out of range indexes are ignored.
+func (st *SegmentTree[T]) Set(i int, value T) {
+	st.SetRange(i, i, value)
+}
+
+// SetRange assigns value to every index from lo to hi. The range is clipped to the indexes of the tree.
+func (st *SegmentTree[T]) SetRange(lo, hi int, value T) {
+	lo, hi = st.clip(lo, hi)
+	if lo <= hi {
+		st.assign(1, 0, st.n-1, lo, hi, value)
+	}
+}
+
+// Query returns the combination of every value from lo to hi in order. The range is clipped to the indexes of the tree, and the identity is returned for an empty range.
+func (st *SegmentTree[T]) Query(lo, hi int) T {
+	lo, hi = st.clip(lo, hi)
+	if lo > hi {
+		return st.identity
+	}
+	return st.query(1, 0, st.n-1, lo, hi)
+}
+
+// Values returns every value in index order.
+func (st *SegmentTree[T]) Values() []T {
+	vals := make([]T, st.n)
+	for i := range vals {
+		vals[i] = st.Get(i)
+	}
+	return vals
+}
+
+func (st *SegmentTree[T]) clip(lo, hi int) (int, int) {
+	if lo < 0 {
+		lo = 0
+	}
+	if hi > st.n-1 {
+		hi = st.n - 1
+	}
+	return lo, hi
+}
+
+// assign sets every index from lo to hi within the segment [segLo, segHi] of node i
+func (st *SegmentTree[T]) assign(i, segLo, segHi, lo, hi int, value T) {
+	if hi < segLo || segHi < lo {
+		return
+	}
+	if lo <= segLo && segHi <= hi {
+		// the whole segment is covered, the children are updated later if they are needed
+		st.setSegment(i, segHi-segLo+1, value)
+		return
+	}
+	st.pushDown(i, segLo, segHi)
+	mid := (segLo + segHi) / 2
+	st.assign(2*i, segLo, mid, lo, hi, value)
+	st.assign(2*i+1, mid+1, segHi, lo, hi, value)
+	st.tree[i] = st.combine(st.tree[2*i], st.tree[2*i+1])
+}
+
+func (st *SegmentTree[T]) query(i, segLo, segHi, lo, hi int) T {
+	if hi < segLo || segHi < lo {
+		return st.identity
+	}
+	if lo <= segLo && segHi <= hi {
+		return st.tree[i]
+	}
+	st.pushDown(i, segLo, segHi)
+	mid := (segLo + segHi) / 2
+	return st.combine(st.query(2*i, segLo, mid, lo, hi), st.query(2*i+1, mid+1, segHi, lo, hi))
+}
+
+// setSegment assigns value to all length indexes of the segment of node i and remembers it for the children
+func (st *SegmentTree[T]) setSegment(i, length int, value T) {
+	st.tree[i] = st.repeat(value, length)
+	st.lazy[i] = value
+	st.hasLazy[i] = true
+}
+
+// pushDown passes a pending assignment of node i on to its children
+func (st *SegmentTree[T]) pushDown(i, segLo, segHi int) {
+	if !st.hasLazy[i] {
+		return
+	}
+	mid := (segLo + segHi) / 2
+	st.setSegment(2*i, mid-segLo+1, st.lazy[i])
+	st.setSegment(2*i+1, segHi-mid, st.lazy[i])
+	st.hasLazy[i] = false
+}
+
+// repeat combines count copies of value with the repeat function if one is set, otherwise by repeated doubling, which only needs combine to be associative
+func (st *SegmentTree[T]) repeat(value T, count int) T {
+	if st.repeatFn != nil {
+		return st.repeatFn(value, count)
+	}
+	result := st.identity
+	for count > 0 {
+		if count&1 == 1 {
+			result = st.combine(result, value)
+		}
+		value = st.combine(value, value)
+		count >>= 1
+	}
+	return result
+}
@@ -0,0 +1,147 @@
+package GoTrees
+
+import (
+	"math"
+	"math/rand"
+	sc "strconv"
+	"strings"
+	"testing"
+)
+
+func TestSegmentTreeQuery(t *testing.T) {
+	values := make([]int, nRAND)
+	for i := range values {
+		values[i] = rand.Intn(nRAND) - nRAND/2
+	}
+	sum := NewSegmentTree(values, func(a, b int) int { return a + b }, 0, func(value, count int) int { return value * count })
+	min := NewSegmentTree(values, func(a, b int) int {
+		if a < b {
+			return a
+		}
+		return b
+	}, math.MaxInt, func(value, count int) int { return value })
+
+	for lo := 0; lo < nRAND; lo++ {
+		expectedSum, expectedMin := 0, math.MaxInt
+		for hi := lo; hi < nRAND; hi++ {
+			expectedSum += values[hi]
+			if values[hi] < expectedMin {
+				expectedMin = values[hi]
+			}
+			if s := sum.Query(lo, hi); s != expectedSum {
+				t.Fatal("Sum of [" + sc.Itoa(lo) + ", " + sc.Itoa(hi) + "] was " + sc.Itoa(s) + " but expected " + sc.Itoa(expectedSum))
+			}
+			if m := min.Query(lo, hi); m != expectedMin {
+				t.Fatal("Min of [" + sc.Itoa(lo) + ", " + sc.Itoa(hi) + "] was " + sc.Itoa(m) + " but expected " + sc.Itoa(expectedMin))
+			}
+		}
+	}
+	if sum.Query(5, 4) != 0 || sum.Query(-10, -1) != 0 {
+		t.Fatal("An empty range did not return the identity. ")
+	}
+	if sum.Query(-10, nRAND+10) != sum.Query(0, nRAND-1) {
+		t.Fatal("An out of range query was not clipped. ")
+	}
+}
+
+func TestSegmentTreeUpdates(t *testing.T) {
+	values := make([]int, nRAND)
+	// no repeat function, so assigned segments are summed by repeated doubling
+	st := NewSegmentTree(values, func(a, b int) int { return a + b }, 0, nil)
+
+	for i := 0; i < nRAND*10; i++ {
+		lo := rand.Intn(nRAND)
+		hi := lo + rand.Intn(nRAND-lo)
+		v := rand.Intn(nRAND)
+		if rand.Intn(2) == 0 {
+			st.Set(lo, v)
+			values[lo] = v
+		} else {
+			st.SetRange(lo, hi, v)
+			for j := lo; j <= hi; j++ {
+				values[j] = v
+			}
+		}
+
+		lo = rand.Intn(nRAND)
+		hi = lo + rand.Intn(nRAND-lo)
+		expected := 0
+		for j := lo; j <= hi; j++ {
+			expected += values[j]
+		}
+		if s := st.Query(lo, hi); s != expected {
+			t.Fatal("Sum of [" + sc.Itoa(lo) + ", " + sc.Itoa(hi) + "] was " + sc.Itoa(s) + " but expected " + sc.Itoa(expected) + " after " + sc.Itoa(i+1) + " updates. ")
+		}
+	}
+	for i, v := range st.Values() {
+		if v != values[i] {
+			t.Fatal("Value at " + sc.Itoa(i) + " was " + sc.Itoa(v) + " but expected " + sc.Itoa(values[i]))
+		}
+	}
+}
+
+func TestSegmentTreeOrder(t *testing.T) {
+	// string concatenation is associative but not commutative, so this checks segments are combined in index order
+	st := NewSegmentTree([]string{"a", "b", "c", "d", "e"}, func(a, b string) string { return a + b }, "", strings.Repeat)
+
+	st.SetRange(1, 3, "x")
+	if q := st.Query(0, 4); q != "axxxe" {
+		t.Fatal("Expected axxxe but got " + q)
+	}
+	st.Set(2, "y")
+	if q := st.Query(1, 4); q != "xyxe" {
+		t.Fatal("Expected xyxe but got " + q)
+	}
+	if st.Get(0) != "a" || st.Len() != 5 {
+		t.Fatal("Get or Len returned the wrong result. ")
+	}
+
+	empty := NewSegmentTree([]string{}, func(a, b string) string { return a + b }, "", nil)
+	empty.Set(0, "a")
+	if empty.Query(0, 0) != "" || empty.Len() != 0 {
+		t.Fatal("Empty segment tree returned a value. ")
+	}
+}
+
+func TestSegmentTreeRepeat(t *testing.T) {
+	values := make([]int, nRAND)
+	combines := 0
+	st := NewSegmentTree(values, func(a, b int) int {
+		combines++
+		return a + b
+	}, 0, func(value, count int) int { return value * count })
+
+	for i := 0; i < nRAND*10; i++ {
+		lo := rand.Intn(nRAND)
+		hi := lo + rand.Intn(nRAND-lo)
+		v := rand.Intn(nRAND)
+		st.SetRange(lo, hi, v)
+		for j := lo; j <= hi; j++ {
+			values[j] = v
+		}
+		lo = rand.Intn(nRAND)
+		hi = lo + rand.Intn(nRAND-lo)
+		expected := 0
+		for j := lo; j <= hi; j++ {
+			expected += values[j]
+		}
+		if s := st.Query(lo, hi); s != expected {
+			t.Fatal("Sum of [" + sc.Itoa(lo) + ", " + sc.Itoa(hi) + "] was " + sc.Itoa(s) + " but expected " + sc.Itoa(expected) + " after " + sc.Itoa(i+1) + " updates. ")
+		}
+	}
+
+	// with a repeat function a range update only combines the nodes along its two edge paths
+	n := 1 << 16
+	big := NewSegmentTree(make([]int, n), func(a, b int) int {
+		combines++
+		return a + b
+	}, 0, func(value, count int) int { return value * count })
+	combines = 0
+	big.SetRange(1, n-2, 1)
+	if combines > 2*16 {
+		t.Fatal("A range update with a repeat function took " + sc.Itoa(combines) + " combines. ")
+	}
+	if big.Query(0, n-1) != n-2 {
+		t.Fatal("Range update with a repeat function gave the wrong sum. ")
+	}
+}
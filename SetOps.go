@@ -0,0 +1,145 @@
+package GoTrees
+
+// setOp selects which keys mergeSets keeps
+type setOp int
+
+const (
+	setUnion setOp = iota
+	setIntersect
+	setDifference
+	setSymmetricDifference
+)
+
+// mergeSets merges two sorted key-value lists with a single linear pass and returns a new sorted list without duplicate keys.
+// Each list is treated as a set, so only the first occurrence of a duplicate key is used. When a key is in both lists the value from a is kept, unless op is union and resolve is not nil.
+func mergeSets(a, b []*keyValue, op setOp, resolve func(key int, a, b interface{}) interface{}) []*keyValue {
+	merged := []*keyValue{}
+	i, j := 0, 0
+
+	for i < len(a) || j < len(b) {
+		if i < len(a) && j < len(b) && a[i].key == b[j].key {
+			// the key is in both sets
+			if op == setUnion || op == setIntersect {
+				value := a[i].value
+				if op == setUnion && resolve != nil {
+					value = resolve(a[i].key, a[i].value, b[j].value)
+				}
+				merged = append(merged, newKeyValue(a[i].key, value))
+			}
+			i = skipDuplicates(a, i)
+			j = skipDuplicates(b, j)
+		} else if j == len(b) || (i < len(a) && a[i].key < b[j].key) {
+			// the key is only in a
+			if op != setIntersect {
+				merged = append(merged, newKeyValue(a[i].key, a[i].value))
+			}
+			i = skipDuplicates(a, i)
+		} else {
+			// the key is only in b
+			if op == setUnion || op == setSymmetricDifference {
+				merged = append(merged, newKeyValue(b[j].key, b[j].value))
+			}
+			j = skipDuplicates(b, j)
+		}
+	}
+	return merged
+}
+
+// skipDuplicates returns the index of the first key-value after i with a different key
+func skipDuplicates(kvs []*keyValue, i int) int {
+	key := kvs[i].key
+	for i < len(kvs) && kvs[i].key == key {
+		i++
+	}
+	return i
+}
+
+// Union returns a new B-Tree with every key in bt or other. resolve picks the value of a key that is in both trees, if resolve is nil the value from bt is kept.
+// Both trees are treated as sets, so only the first occurrence of a duplicate key is used.
+func (bt *BTree) Union(other *BTree, resolve func(key int, a, b interface{}) interface{}) BTree {
+	return bt.bulkLoad(mergeSets(bt.slice(), other.slice(), setUnion, resolve))
+}
+
+// Intersect returns a new B-Tree with every key that is in both bt and other, using the values from bt.
+func (bt *BTree) Intersect(other *BTree) BTree {
+	return bt.bulkLoad(mergeSets(bt.slice(), other.slice(), setIntersect, nil))
+}
+
+// Difference returns a new B-Tree with every key in bt that is not in other.
+func (bt *BTree) Difference(other *BTree) BTree {
+	return bt.bulkLoad(mergeSets(bt.slice(), other.slice(), setDifference, nil))
+}
+
+// SymmetricDifference returns a new B-Tree with every key that is in exactly one of bt and other.
+func (bt *BTree) SymmetricDifference(other *BTree) BTree {
+	return bt.bulkLoad(mergeSets(bt.slice(), other.slice(), setSymmetricDifference, nil))
+}
+
+// UnionWith is Union, but bt is replaced with the result instead of returning a new B-Tree. Entries keep their expiry times.
+func (bt *BTree) UnionWith(other *BTree, resolve func(key int, a, b interface{}) interface{}) {
+	result := bt.Union(other, resolve)
+	bt.keepExpiry(&result, other)
+	*bt = result
+}
+
+// IntersectWith is Intersect, but bt is replaced with the result instead of returning a new B-Tree. Entries keep their expiry times.
+func (bt *BTree) IntersectWith(other *BTree) {
+	result := bt.Intersect(other)
+	bt.keepExpiry(&result, other)
+	*bt = result
+}
+
+// DifferenceWith is Difference, but bt is replaced with the result instead of returning a new B-Tree. Entries keep their expiry times.
+func (bt *BTree) DifferenceWith(other *BTree) {
+	result := bt.Difference(other)
+	bt.keepExpiry(&result, other)
+	*bt = result
+}
+
+// SymmetricDifferenceWith is SymmetricDifference, but bt is replaced with the result instead of returning a new B-Tree. Entries keep their expiry times.
+func (bt *BTree) SymmetricDifferenceWith(other *BTree) {
+	result := bt.SymmetricDifference(other)
+	bt.keepExpiry(&result, other)
+	*bt = result
+}
+
+// Union returns a new BST with every key in bst or other. resolve picks the value of a key that is in both trees, if resolve is nil the value from bst is kept.
+// Both trees are treated as sets, so only the first occurrence of a duplicate key is used.
+func (bst *BSTree) Union(other *BSTree, resolve func(key int, a, b interface{}) interface{}) BSTree {
+	return newBSTreeFromSorted(mergeSets(bst.keyValues(), other.keyValues(), setUnion, resolve))
+}
+
+// Intersect returns a new BST with every key that is in both bst and other, using the values from bst.
+func (bst *BSTree) Intersect(other *BSTree) BSTree {
+	return newBSTreeFromSorted(mergeSets(bst.keyValues(), other.keyValues(), setIntersect, nil))
+}
+
+// Difference returns a new BST with every key in bst that is not in other.
+func (bst *BSTree) Difference(other *BSTree) BSTree {
+	return newBSTreeFromSorted(mergeSets(bst.keyValues(), other.keyValues(), setDifference, nil))
+}
+
+// SymmetricDifference returns a new BST with every key that is in exactly one of bst and other.
+func (bst *BSTree) SymmetricDifference(other *BSTree) BSTree {
+	return newBSTreeFromSorted(mergeSets(bst.keyValues(), other.keyValues(), setSymmetricDifference, nil))
+}
+
+// UnionWith is Union, but bst is replaced with the result instead of returning a new BST.
+func (bst *BSTree) UnionWith(other *BSTree, resolve func(key int, a, b interface{}) interface{}) {
+	*bst = bst.Union(other, resolve)
+}
+
+// IntersectWith is Intersect, but bst is replaced with the result instead of returning a new BST.
+func (bst *BSTree) IntersectWith(other *BSTree) {
+	*bst = bst.Intersect(other)
+}
+
+// DifferenceWith is Difference, but bst is replaced with the result instead of returning a new BST.
+func (bst *BSTree) DifferenceWith(other *BSTree) {
+	*bst = bst.Difference(other)
+}
+
+// SymmetricDifferenceWith is SymmetricDifference, but bst is replaced with the result instead of returning a new BST.
+func (bst *BSTree) SymmetricDifferenceWith(other *BSTree) {
+	*bst = bst.SymmetricDifference(other)
+}
@@ -0,0 +1,178 @@
+package GoTrees
+
+import (
+	"math/bits"
+	"math/rand"
+	"sort"
+	sc "strconv"
+	"testing"
+)
+
+// randomSet returns nRAND random keys (with duplicates) and the set of those keys
+func randomSet() ([]int, map[int]bool) {
+	keys := make([]int, nRAND)
+	set := map[int]bool{}
+	for i := range keys {
+		keys[i] = rand.Intn(nRAND * 2)
+		set[keys[i]] = true
+	}
+	return keys, set
+}
+
+// expectedSet returns the sorted keys of a and b that are kept by keep
+func expectedSet(a, b map[int]bool, keep func(inA, inB bool) bool) []int {
+	keys := []int{}
+	for k := 0; k < nRAND*2; k++ {
+		if (a[k] || b[k]) && keep(a[k], b[k]) {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+func checkKeys(t *testing.T, name string, actual, expected []int) {
+	if len(actual) != len(expected) {
+		t.Fatal(name + " expected " + sc.Itoa(len(expected)) + " keys but got " + sc.Itoa(len(actual)))
+	}
+	for i, k := range actual {
+		if k != expected[i] {
+			t.Fatal(name + " key was incorrect, expected " + sc.Itoa(expected[i]) + " at index " + sc.Itoa(i) + " but got " + sc.Itoa(k) + ". ")
+		}
+	}
+}
+
+func TestBTreeSetOps(t *testing.T) {
+	A, B := NewBTree(T, nAlloc), NewBTree(T, nAlloc)
+	aKeys, aSet := randomSet()
+	bKeys, bSet := randomSet()
+	for _, k := range aKeys {
+		A.Insert(k, "a")
+	}
+	for _, k := range bKeys {
+		B.Insert(k, "b")
+	}
+
+	union := A.Union(&B, nil)
+	intersect := A.Intersect(&B)
+	difference := A.Difference(&B)
+	symmetric := A.SymmetricDifference(&B)
+	for _, result := range []BTree{union, intersect, difference, symmetric} {
+		if err := result.Verify(); err != nil {
+			t.Fatal("Result tree failed verification: " + err.Error())
+		}
+	}
+	checkKeys(t, "Union", union.Keys(), expectedSet(aSet, bSet, func(inA, inB bool) bool { return true }))
+	checkKeys(t, "Intersect", intersect.Keys(), expectedSet(aSet, bSet, func(inA, inB bool) bool { return inA && inB }))
+	checkKeys(t, "Difference", difference.Keys(), expectedSet(aSet, bSet, func(inA, inB bool) bool { return inA && !inB }))
+	checkKeys(t, "SymmetricDifference", symmetric.Keys(), expectedSet(aSet, bSet, func(inA, inB bool) bool { return inA != inB }))
+
+	// the receiver must not be changed by the non mutating operations
+	if A.Size() != nRAND {
+		t.Fatal("Union changed the receiver. ")
+	}
+	A.DifferenceWith(&B)
+	checkKeys(t, "DifferenceWith", A.Keys(), difference.Keys())
+	A.UnionWith(&B, nil)
+	checkKeys(t, "UnionWith", A.Keys(), union.Keys())
+	A.SymmetricDifferenceWith(&B)
+	checkKeys(t, "SymmetricDifferenceWith", A.Keys(), difference.Keys())
+	A.IntersectWith(&B)
+	checkKeys(t, "IntersectWith", A.Keys(), []int{})
+}
+
+func TestBTreeUnionResolve(t *testing.T) {
+	A, B := NewBTree(T, nAlloc), NewBTree(T, nAlloc)
+	for i := 0; i < nRAND; i++ {
+		A.Insert(i, i)
+		B.Insert(i+nRAND/2, i+nRAND/2)
+	}
+
+	union := A.Union(&B, func(key int, a, b interface{}) interface{} {
+		return a.(int) + b.(int)
+	})
+	for i, v := range union.Values() {
+		expected := i
+		if i >= nRAND/2 && i < nRAND {
+			expected = 2 * i
+		}
+		if v.(int) != expected {
+			t.Fatal("Union value for " + sc.Itoa(i) + " was " + sc.Itoa(v.(int)) + " but expected " + sc.Itoa(expected))
+		}
+	}
+}
+
+func TestBTreeBulkLoad(t *testing.T) {
+	for _, tParam := range []uint{0, 1, 4} {
+		BT := NewBTree(tParam, nAlloc)
+		for n := 0; n <= nRAND; n++ {
+			kvs := make([]*keyValue, n)
+			for i := range kvs {
+				kvs[i] = newKeyValue(i, i)
+			}
+			loaded := BT.bulkLoad(kvs)
+			if err := loaded.Verify(); err != nil {
+				t.Fatal("Bulk loading " + sc.Itoa(n) + " keys with t " + sc.Itoa(int(tParam)) + " failed verification: " + err.Error())
+			}
+			// the loaded tree must still support inserts and deletes
+			for _, k := range rand.Perm(n + 10) {
+				loaded.Insert(k, k)
+			}
+			for _, k := range rand.Perm(n) {
+				loaded.Delete(k)
+			}
+			if err := loaded.Verify(); err != nil {
+				t.Fatal("Bulk loaded tree failed verification after updates: " + err.Error())
+			}
+		}
+	}
+}
+
+func TestBSTreeSetOps(t *testing.T) {
+	A, B := NewBSTree(), NewBSTree()
+	aKeys, aSet := randomSet()
+	bKeys, bSet := randomSet()
+	for _, k := range aKeys {
+		A.Insert(k, "a")
+	}
+	for _, k := range bKeys {
+		B.Insert(k, "b")
+	}
+
+	union := A.Union(&B, nil)
+	intersect := A.Intersect(&B)
+	difference := A.Difference(&B)
+	symmetric := A.SymmetricDifference(&B)
+	for _, result := range []BSTree{union, intersect, difference, symmetric} {
+		if err := result.Verify(); err != nil {
+			t.Fatal("Result tree failed verification: " + err.Error())
+		}
+	}
+	checkKeys(t, "Union", union.Keys(), expectedSet(aSet, bSet, func(inA, inB bool) bool { return true }))
+	checkKeys(t, "Intersect", intersect.Keys(), expectedSet(aSet, bSet, func(inA, inB bool) bool { return inA && inB }))
+	checkKeys(t, "Difference", difference.Keys(), expectedSet(aSet, bSet, func(inA, inB bool) bool { return inA && !inB }))
+	checkKeys(t, "SymmetricDifference", symmetric.Keys(), expectedSet(aSet, bSet, func(inA, inB bool) bool { return inA != inB }))
+
+	// the result is built balanced
+	if union.Height() != uint64(bits.Len64(union.Size())) {
+		t.Fatal("Union was not built balanced, height " + sc.Itoa(int(union.Height())) + " for " + sc.Itoa(int(union.Size())) + " keys. ")
+	}
+
+	A.IntersectWith(&B)
+	checkKeys(t, "IntersectWith", A.Keys(), intersect.Keys())
+	A.UnionWith(&B, func(key int, a, b interface{}) interface{} { return b })
+	checkKeys(t, "UnionWith", A.Keys(), sortedKeys(bSet))
+	for _, v := range A.Values() {
+		if v.(string) != "b" {
+			t.Fatal("UnionWith did not use the resolved value. ")
+		}
+	}
+}
+
+func sortedKeys(set map[int]bool) []int {
+	keys := []int{}
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	return keys
+}
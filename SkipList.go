@@ -0,0 +1,299 @@
+package GoTrees
+
+import (
+	"errors"
+	"math/rand"
+	"strconv"
+)
+
+// maxSkipLevel is the most levels a skip list can have, enough for far more keys than fit in memory with p = 1/2
+const maxSkipLevel = 64
+
+// SkipList is an ordered map of linked lists stacked in levels. Every key-value is in the bottom list, and each list above skips over the one below by holding a random subset of its nodes.
+// A node reaches each next level with probability p, so searches take O(log n) steps on average. Duplicate keys are kept in insertion order, like in a BTree.
+type SkipList struct {
+	// head is a sentinel with a link at every level, its key is never used
+	head     *skipNode
+	level    int
+	maxLevel int
+	p        float64
+	rng      *rand.Rand
+	size     uint64
+}
+
+// skipNode is a key-value with a link to the next node at each of its levels
+type skipNode struct {
+	key  int
+	val  interface{}
+	next []*skipNode
+}
+
+// NewSkipList returns an empty skip list where a node reaches each next level with probability p, up to maxLevel levels. Levels are drawn from a random source seeded with seed.
+// p is clamped to (0, 1) with 0.5 used when it is out of range, and maxLevel is clamped to [1, 64].
+func NewSkipList(p float64, maxLevel int, seed int64) SkipList {
+	if p <= 0 || p >= 1 {
+		p = 0.5
+	}
+	if maxLevel < 1 {
+		maxLevel = 1
+	} else if maxLevel > maxSkipLevel {
+		maxLevel = maxSkipLevel
+	}
+	head := &skipNode{next: make([]*skipNode, maxLevel)}
+	return SkipList{head: head, level: 1, maxLevel: maxLevel, p: p, rng: rand.New(rand.NewSource(seed)), size: 0}
+}
+
+// randomLevel returns the number of levels of a new node, each further level is added with probability p
+func (sl *SkipList) randomLevel() int {
+	level := 1
+	for level < sl.maxLevel && sl.rng.Float64() < sl.p {
+		level++
+	}
+	return level
+}
+
+// predecessors returns the last node at each level with a key smaller than key, or smaller than or equal to it if orEqual is set. Levels above sl.level are the head.
+func (sl *SkipList) predecessors(key int, orEqual bool) [maxSkipLevel]*skipNode {
+	var update [maxSkipLevel]*skipNode
+	n := sl.head
+	for i := sl.level - 1; i >= 0; i-- {
+		for next := n.next[i]; next != nil && (next.key < key || (orEqual && next.key == key)); next = n.next[i] {
+			n = next
+		}
+		update[i] = n
+	}
+	for i := sl.level; i < sl.maxLevel; i++ {
+		update[i] = sl.head
+	}
+	return update
+}
+
+// Insert will insert node into the skip list. A duplicate key is always placed after the existing equal keys, so iteration returns equal keys in insertion order.
+func (sl *SkipList) Insert(key int, value interface{}) {
+	update := sl.predecessors(key, true)
+	sl.insertAfter(&update, key, value)
+}
+
+// insertAfter links a new node in after the nodes of update at each of its levels
+func (sl *SkipList) insertAfter(update *[maxSkipLevel]*skipNode, key int, value interface{}) {
+	level := sl.randomLevel()
+	n := &skipNode{key: key, val: value, next: make([]*skipNode, level)}
+	for i := 0; i < level; i++ {
+		n.next[i] = update[i].next[i]
+		update[i].next[i] = n
+	}
+	if level > sl.level {
+		sl.level = level
+	}
+	sl.size++
+}
+
+// Put will set the value of key, inserting it if it is not in the skip list. If key has duplicates only the first occurrence is changed.
+func (sl *SkipList) Put(key int, value interface{}) {
+	update := sl.predecessors(key, false)
+	if n := update[0].next[0]; n != nil && n.key == key {
+		n.val = value
+		return
+	}
+	sl.insertAfter(&update, key, value)
+}
+
+// Find will find key in the skip list and return the node. Find will return the first occurrence of key.
+func (sl *SkipList) Find(key int) *interface{} {
+	n := sl.head
+	for i := sl.level - 1; i >= 0; i-- {
+		for next := n.next[i]; next != nil && next.key < key; next = n.next[i] {
+			n = next
+		}
+	}
+	if n = n.next[0]; n != nil && n.key == key {
+		return &n.val
+	}
+	return nil
+}
+
+// Contains determines if key exists in the skip list and returns the result.
+func (sl *SkipList) Contains(key int) bool {
+	return sl.Find(key) != nil
+}
+
+// FindAll returns the values of every occurrence of key in insertion order. It returns an empty slice if key is not in the skip list.
+func (sl *SkipList) FindAll(key int) []interface{} {
+	vals := []interface{}{}
+	sl.Range(key, key, func(key int, value interface{}) bool {
+		vals = append(vals, value)
+		return true
+	})
+	return vals
+}
+
+// Count returns the number of occurrences of key in the skip list.
+func (sl *SkipList) Count(key int) int {
+	count := 0
+	sl.Range(key, key, func(key int, value interface{}) bool {
+		count++
+		return true
+	})
+	return count
+}
+
+// Delete will delete the first occurrence of key in the skip list. It will return whether or not the list was changed.
+func (sl *SkipList) Delete(key int) bool {
+	update := sl.predecessors(key, false)
+	n := update[0].next[0]
+	if n == nil || n.key != key {
+		return false
+	}
+	// n is the first node with key, so it follows the predecessors on every level it is in
+	for i := range n.next {
+		update[i].next[i] = n.next[i]
+	}
+	sl.size--
+	sl.dropEmptyLevels()
+	return true
+}
+
+// DeleteAll will delete every occurrence of key in the skip list. It will return the number of key-values deleted.
+func (sl *SkipList) DeleteAll(key int) int {
+	return sl.DeleteRange(key, key)
+}
+
+// DeleteRange will delete every key-value with lo <= key <= hi and return how many were deleted.
+// The deleted nodes are unlinked a whole level at a time, so it takes O(log n) plus the number deleted.
+func (sl *SkipList) DeleteRange(lo, hi int) int {
+	if lo > hi {
+		return 0
+	}
+	update := sl.predecessors(lo, false)
+	deleted := 0
+	for n := update[0].next[0]; n != nil && n.key <= hi; n = n.next[0] {
+		deleted++
+	}
+	for i := 0; i < sl.level; i++ {
+		next := update[i].next[i]
+		for next != nil && next.key <= hi {
+			next = next.next[i]
+		}
+		update[i].next[i] = next
+	}
+	sl.size -= uint64(deleted)
+	sl.dropEmptyLevels()
+	return deleted
+}
+
+// dropEmptyLevels lowers the level of the skip list past any levels with no nodes left
+func (sl *SkipList) dropEmptyLevels() {
+	for sl.level > 1 && sl.head.next[sl.level-1] == nil {
+		sl.level--
+	}
+}
+
+// Walk calls fn on every key-value in order, until fn returns false.
+func (sl *SkipList) Walk(fn func(key int, value interface{}) bool) {
+	for n := sl.head.next[0]; n != nil; n = n.next[0] {
+		if !fn(n.key, n.val) {
+			return
+		}
+	}
+}
+
+// Range calls fn on every key-value with lo <= key <= hi in order, until fn returns false.
+func (sl *SkipList) Range(lo, hi int, fn func(key int, value interface{}) bool) {
+	update := sl.predecessors(lo, false)
+	for n := update[0].next[0]; n != nil && n.key <= hi; n = n.next[0] {
+		if !fn(n.key, n.val) {
+			return
+		}
+	}
+}
+
+func (sl *SkipList) Keys() []int {
+	keys := make([]int, 0, sl.size)
+	for n := sl.head.next[0]; n != nil; n = n.next[0] {
+		keys = append(keys, n.key)
+	}
+	return keys
+}
+
+func (sl *SkipList) Values() []interface{} {
+	vals := make([]interface{}, 0, sl.size)
+	for n := sl.head.next[0]; n != nil; n = n.next[0] {
+		vals = append(vals, n.val)
+	}
+	return vals
+}
+
+// Clear clears the skip list of all key-values. The random source is kept.
+func (sl *SkipList) Clear() {
+	for i := range sl.head.next {
+		sl.head.next[i] = nil
+	}
+	sl.level = 1
+	sl.size = 0
+}
+
+// Height returns the number of levels in use.
+func (sl *SkipList) Height() uint64 {
+	return uint64(sl.level)
+}
+
+func (sl *SkipList) Size() uint64 {
+	return sl.size
+}
+
+// Verify walks every level of the skip list and checks that each is in order and holds a subset of the level below.
+// It returns nil if the list is valid, otherwise an error describing the first problem found.
+func (sl *SkipList) Verify() error {
+	if sl.level < 1 || sl.level > sl.maxLevel {
+		return errors.New("skip list has " + strconv.Itoa(sl.level) + " levels but the maximum is " + strconv.Itoa(sl.maxLevel))
+	}
+	for i := sl.level; i < sl.maxLevel; i++ {
+		if sl.head.next[i] != nil {
+			return errors.New("level " + strconv.Itoa(i) + " holds nodes but is above the level of the skip list")
+		}
+	}
+	if sl.level > 1 && sl.head.next[sl.level-1] == nil {
+		return errors.New("top level " + strconv.Itoa(sl.level-1) + " is empty")
+	}
+	count := uint64(0)
+	for n := sl.head.next[0]; n != nil; n = n.next[0] {
+		count++
+		if len(n.next) < 1 || len(n.next) > sl.maxLevel {
+			return errors.New("key " + strconv.Itoa(n.key) + " has " + strconv.Itoa(len(n.next)) + " levels")
+		}
+	}
+	if count != sl.size {
+		return errors.New("list holds " + strconv.FormatUint(count, 10) + " keys but size is " + strconv.FormatUint(sl.size, 10))
+	}
+	for i := 1; i < sl.level; i++ {
+		// walk the level below alongside, every node of this level must be found in it
+		below := sl.head.next[i-1]
+		linked, tall := 0, 0
+		for n := sl.head.next[i]; n != nil; n = n.next[i] {
+			linked++
+			if i >= len(n.next) {
+				return errors.New("key " + strconv.Itoa(n.key) + " is linked at level " + strconv.Itoa(i) + " above its height")
+			}
+			for below != nil && below != n {
+				below = below.next[i-1]
+			}
+			if below == nil {
+				return errors.New("key " + strconv.Itoa(n.key) + " at level " + strconv.Itoa(i) + " is missing from the level below or out of order")
+			}
+		}
+		for n := sl.head.next[0]; n != nil; n = n.next[0] {
+			if len(n.next) > i {
+				tall++
+			}
+		}
+		if linked != tall {
+			return errors.New("level " + strconv.Itoa(i) + " links " + strconv.Itoa(linked) + " nodes but " + strconv.Itoa(tall) + " nodes reach it")
+		}
+	}
+	for n := sl.head.next[0]; n != nil && n.next[0] != nil; n = n.next[0] {
+		if n.next[0].key < n.key {
+			return errors.New("key " + strconv.Itoa(n.next[0].key) + " comes after the larger key " + strconv.Itoa(n.key))
+		}
+	}
+	return nil
+}
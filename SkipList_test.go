@@ -0,0 +1,359 @@
+package GoTrees
+
+import (
+	"math/rand"
+	sc "strconv"
+	"testing"
+)
+
+func TestSkipListEmptyAllOps(t *testing.T) {
+	SL := NewSkipList(0.5, 16, 1)
+
+	keys := SL.Keys()
+	vals := SL.Values()
+	h := SL.Height()
+	val := SL.Find(1)
+	changed := SL.Delete(1)
+	deleted := SL.DeleteRange(0, 10)
+
+	if len(keys) != 0 || len(vals) != 0 || h != 1 || val != nil || changed != false || deleted != 0 || SL.Verify() != nil {
+		t.Fatal("A skip list operation failed when the list was empty ")
+	}
+}
+
+func TestSkipListInsert(t *testing.T) {
+	SL := NewSkipList(0.5, 16, 1)
+	keys := rand.Perm(nRAND)
+
+	for i, key := range keys {
+		SL.Insert(key, key*2)
+		if err := SL.Verify(); err != nil {
+			t.Fatal(err)
+		}
+		if SL.Size() != uint64(i+1) {
+			t.Fatal("Skip list size incorrect, expected " + sc.Itoa(i+1) + " but got " + sc.Itoa(int(SL.Size())) + ". ")
+		}
+	}
+	for i, key := range SL.Keys() {
+		if key != i {
+			t.Fatal("Skip list keys were incorrect, expected " + sc.Itoa(i) + " at index " + sc.Itoa(i) + " but got " + sc.Itoa(key) + ". ")
+		}
+	}
+	for _, key := range keys {
+		if val := SL.Find(key); val == nil || (*val).(int) != key*2 {
+			t.Fatal("Could not find key " + sc.Itoa(key) + " in the skip list. ")
+		}
+	}
+	if SL.Contains(-1) || SL.Contains(nRAND) {
+		t.Fatal("Found a key that was not in the skip list. ")
+	}
+}
+
+func TestSkipListParameters(t *testing.T) {
+	n := 1 << 12
+	for _, maxLevel := range []int{1, 4, 64} {
+		SL := NewSkipList(0.25, maxLevel, 1)
+		for i := 0; i < n; i++ {
+			SL.Insert(i, nil)
+		}
+		if err := SL.Verify(); err != nil {
+			t.Fatal(err)
+		}
+		if SL.Height() > uint64(maxLevel) {
+			t.Fatal("Skip list has " + sc.Itoa(int(SL.Height())) + " levels but the maximum is " + sc.Itoa(maxLevel))
+		}
+	}
+
+	// with p = 1/4 about a quarter of the nodes reach the second level
+	SL := NewSkipList(0.25, 16, 1)
+	for i := 0; i < n; i++ {
+		SL.Insert(i, nil)
+	}
+	second := 0
+	for node := SL.head.next[1]; node != nil; node = node.next[1] {
+		second++
+	}
+	if second < n/8 || second > n*3/8 {
+		t.Fatal("Expected about " + sc.Itoa(n/4) + " nodes on the second level but found " + sc.Itoa(second))
+	}
+
+	// out of range parameters are clamped
+	SL = NewSkipList(2, 0, 1)
+	if SL.p != 0.5 || SL.maxLevel != 1 {
+		t.Fatal("Skip list parameters were not clamped. ")
+	}
+}
+
+func TestSkipListSeed(t *testing.T) {
+	a, b := NewSkipList(0.5, 32, 7), NewSkipList(0.5, 32, 7)
+	for i := 0; i < nRAND; i++ {
+		a.Insert(i, nil)
+		b.Insert(i, nil)
+	}
+	for x, y := a.head.next[0], b.head.next[0]; x != nil; x, y = x.next[0], y.next[0] {
+		if len(x.next) != len(y.next) {
+			t.Fatal("Skip lists with the same seed gave key " + sc.Itoa(x.key) + " different levels. ")
+		}
+	}
+}
+
+func TestSkipListDelete(t *testing.T) {
+	SL := NewSkipList(0.5, 16, 1)
+	keys := rand.Perm(nRAND)
+
+	for _, key := range keys {
+		SL.Insert(key, nil)
+	}
+	rand.Shuffle(len(keys), func(i, j int) { keys[i], keys[j] = keys[j], keys[i] })
+	for i, k := range keys {
+		if !SL.Delete(k) {
+			t.Fatal("Skip list returned false when list should have been modified. ")
+		}
+		if SL.Delete(k) || SL.Contains(k) {
+			t.Fatal("Found deleted node after deletion of:" + sc.Itoa(k) + ". ")
+		}
+		if err := SL.Verify(); err != nil {
+			t.Fatal(err)
+		}
+		if SL.Size() != uint64(nRAND-(i+1)) {
+			t.Fatal("Skip list size incorrect, expected " + sc.Itoa(nRAND-(i+1)) + " but got " + sc.Itoa(int(SL.Size())) + ". ")
+		}
+	}
+	if SL.Height() != 1 {
+		t.Fatal("Empty skip list still has " + sc.Itoa(int(SL.Height())) + " levels. ")
+	}
+}
+
+func TestSkipListDuplicates(t *testing.T) {
+	SL := NewSkipList(0.5, 16, 1)
+	BT := NewBTree(T, nAlloc)
+	counts := make([]int, nRAND/10)
+
+	for i := 0; i < nRAND*2; i++ {
+		key := rand.Intn(nRAND / 10)
+		counts[key]++
+		SL.Insert(key, i)
+		BT.Insert(key, i)
+	}
+	checkKeys(t, "Skip list values", intValues(SL.Values()), intValues(BT.Values()))
+	for key, count := range counts {
+		vals := SL.FindAll(key)
+		if len(vals) != count || SL.Count(key) != count {
+			t.Fatal("Expected " + sc.Itoa(count) + " occurrences of " + sc.Itoa(key) + " but found " + sc.Itoa(len(vals)))
+		}
+		if count > 0 && *SL.Find(key) != vals[0] {
+			t.Fatal("Find did not return the first occurrence of " + sc.Itoa(key) + ". ")
+		}
+	}
+	// Delete removes the first occurrence, so the rest stay in insertion order
+	for key, count := range counts {
+		if count > 1 {
+			rest := SL.FindAll(key)[1:]
+			SL.Delete(key)
+			checkKeys(t, "Skip list values after delete", intValues(SL.FindAll(key)), intValues(rest))
+		}
+	}
+	if err := SL.Verify(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// intValues converts values that are all ints so their order can be checked with checkKeys
+func intValues(vals []interface{}) []int {
+	ints := make([]int, len(vals))
+	for i, v := range vals {
+		ints[i] = v.(int)
+	}
+	return ints
+}
+
+func TestSkipListPut(t *testing.T) {
+	SL := NewSkipList(0.5, 16, 1)
+
+	for i := 0; i < nRAND*2; i++ {
+		key := rand.Intn(nRAND)
+		SL.Put(key, i)
+		if val := SL.Find(key); val == nil || (*val).(int) != i {
+			t.Fatal("Put did not set the value of " + sc.Itoa(key) + ". ")
+		}
+	}
+	for key := 0; key < nRAND; key++ {
+		if SL.Count(key) > 1 {
+			t.Fatal("Put inserted a duplicate of " + sc.Itoa(key) + ". ")
+		}
+	}
+	if err := SL.Verify(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSkipListRange(t *testing.T) {
+	SL := NewSkipList(0.5, 16, 1)
+	for _, key := range rand.Perm(nRAND) {
+		SL.Insert(key, key)
+	}
+	for i := 0; i < nRAND; i++ {
+		lo, hi := rand.Intn(nRAND), rand.Intn(nRAND)
+		expected := []int{}
+		for k := lo; k <= hi; k++ {
+			expected = append(expected, k)
+		}
+		actual := []int{}
+		SL.Range(lo, hi, func(key int, value interface{}) bool {
+			actual = append(actual, key)
+			return true
+		})
+		checkKeys(t, "Skip list range", actual, expected)
+	}
+}
+
+func TestSkipListDeleteRange(t *testing.T) {
+	for i := 0; i < nRAND; i++ {
+		SL := NewSkipList(0.5, 16, int64(i))
+		BT := NewBTree(T, nAlloc)
+		for j := 0; j < nRAND; j++ {
+			key := rand.Intn(nRAND)
+			SL.Insert(key, j)
+			BT.Insert(key, j)
+		}
+		lo, hi := rand.Intn(nRAND+2)-1, rand.Intn(nRAND+2)-1
+		expected := BT.DeleteRange(lo, hi)
+		if deleted := SL.DeleteRange(lo, hi); deleted != expected {
+			t.Fatal("DeleteRange(" + sc.Itoa(lo) + ", " + sc.Itoa(hi) + ") deleted " + sc.Itoa(deleted) + " key-values but expected " + sc.Itoa(expected))
+		}
+		if err := SL.Verify(); err != nil {
+			t.Fatal(err)
+		}
+		checkKeys(t, "Skip list after DeleteRange", SL.Keys(), BT.Keys())
+	}
+}
+
+func TestSkipListVerify(t *testing.T) {
+	SL := NewSkipList(0.5, 16, 1)
+	for i := 0; i < nRAND; i++ {
+		SL.Insert(i, nil)
+	}
+	SL.head.next[0].key = nRAND
+	if SL.Verify() == nil {
+		t.Fatal("Verify did not find a key out of order. ")
+	}
+	SL.head.next[0].key = 0
+	SL.size++
+	if SL.Verify() == nil {
+		t.Fatal("Verify did not find the wrong size. ")
+	}
+}
+
+func BenchmarkSkipListInsert(b *testing.B) {
+	keys := rand.Perm(1 << 16)
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		SL := NewSkipList(0.25, 32, 1)
+		for _, k := range keys {
+			SL.Insert(k, nil)
+		}
+	}
+}
+
+func BenchmarkSkipListBTreeInsert(b *testing.B) {
+	keys := rand.Perm(1 << 16)
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		BT := NewBTree(4, nAlloc)
+		for _, k := range keys {
+			BT.Insert(k, nil)
+		}
+	}
+}
+
+func BenchmarkSkipListBSTreeInsert(b *testing.B) {
+	keys := rand.Perm(1 << 16)
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		BST := NewBSTree()
+		for _, k := range keys {
+			BST.Insert(k, nil)
+		}
+	}
+}
+
+func BenchmarkSkipListFind(b *testing.B) {
+	keys := rand.Perm(1 << 16)
+	SL := NewSkipList(0.25, 32, 1)
+	for _, k := range keys {
+		SL.Insert(k, nil)
+	}
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		for _, k := range keys {
+			SL.Find(k)
+		}
+	}
+}
+
+func BenchmarkSkipListBTreeFind(b *testing.B) {
+	keys := rand.Perm(1 << 16)
+	BT := NewBTree(4, nAlloc)
+	for _, k := range keys {
+		BT.Insert(k, nil)
+	}
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		for _, k := range keys {
+			BT.Find(k)
+		}
+	}
+}
+
+func BenchmarkSkipListBSTreeFind(b *testing.B) {
+	keys := rand.Perm(1 << 16)
+	BST := NewBSTree()
+	for _, k := range keys {
+		BST.Insert(k, nil)
+	}
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		for _, k := range keys {
+			BST.Find(k)
+		}
+	}
+}
+
+func BenchmarkSkipListRange(b *testing.B) {
+	SL := NewSkipList(0.25, 32, 1)
+	for _, k := range rand.Perm(1 << 16) {
+		SL.Insert(k, nil)
+	}
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		SL.Range(1<<12, 1<<13, func(key int, value interface{}) bool {
+			return true
+		})
+	}
+}
+
+func BenchmarkSkipListBTreeRange(b *testing.B) {
+	BT := NewBTree(4, nAlloc)
+	for _, k := range rand.Perm(1 << 16) {
+		BT.Insert(k, nil)
+	}
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		BT.ascendRange(1<<12, 1<<13, func(kv *keyValue) bool {
+			return true
+		})
+	}
+}
+
+func BenchmarkSkipListBSTreeRange(b *testing.B) {
+	BST := NewBSTree()
+	for _, k := range rand.Perm(1 << 16) {
+		BST.Insert(k, nil)
+	}
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		BST.ascendRange(1<<12, 1<<13, func(n *node) bool {
+			return true
+		})
+	}
+}
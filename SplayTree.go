@@ -0,0 +1,412 @@
+package GoTrees
+
+import (
+	"errors"
+	"math"
+	"strconv"
+)
+
+// SplayTree is a binary search tree that moves every node it finds, inserts or deletes to the root, so keys that are used often stay near the top.
+// Every operation takes amortised O(log n) time, and a skewed access pattern is faster than on a BSTree since the hot keys are only a few levels deep.
+// Rotations can move duplicate keys to either side of each other, but they always stay in insertion order.
+type SplayTree struct {
+	root *node
+	size uint64
+}
+
+// NewSplayTree returns an empty splay tree. The values will be initialized the same way when doing SplayTree{}
+func NewSplayTree() SplayTree {
+	return SplayTree{root: nil, size: 0}
+}
+
+// splay moves the last node on the search path of key to the root top down and returns it. The search stops at the first occurrence of key if stop is set, otherwise equal keys are passed on the right.
+func splay(n *node, key int, stop bool) *node {
+	if n == nil {
+		return nil
+	}
+	// header.Right holds the nodes left of the search path and header.Left the nodes right of it, l and r are the last nodes added to each
+	var header node
+	l, r := &header, &header
+	for {
+		if key < n.Key {
+			if n.Left == nil {
+				break
+			}
+			if key < n.Left.Key {
+				// zig-zig, rotate right
+				child := n.Left
+				n.Left = child.Right
+				child.Right = n
+				n = child
+				if n.Left == nil {
+					break
+				}
+			}
+			r.Left = n
+			r = n
+			n = n.Left
+		} else if key > n.Key || (!stop && key == n.Key) {
+			if n.Right == nil {
+				break
+			}
+			if key > n.Right.Key || (!stop && key == n.Right.Key) {
+				// zig-zig, rotate left
+				child := n.Right
+				n.Right = child.Left
+				child.Left = n
+				n = child
+				if n.Right == nil {
+					break
+				}
+			}
+			l.Right = n
+			l = n
+			n = n.Right
+		} else {
+			break
+		}
+	}
+	l.Right = n.Left
+	r.Left = n.Right
+	n.Left = header.Right
+	n.Right = header.Left
+	return n
+}
+
+// splayKey splays the occurrence of key closest to the root, or the last node on its search path if key is not in the tree
+func (st *SplayTree) splayKey(key int) {
+	st.root = splay(st.root, key, true)
+}
+
+// Insert will insert node into the splay tree as the new root. If the node has a duplicate key, it will be placed after every other occurrence.
+func (st *SplayTree) Insert(key int, value interface{}) {
+	// splay the node just before or after the new one, going right on equal keys so the new node comes after them
+	st.root = splay(st.root, key, false)
+	st.link(newNode(key, value))
+}
+
+// link makes n the root, splitting the old root which must be the node just before or after n in order
+func (st *SplayTree) link(n *node) {
+	st.size++
+	if st.root != nil {
+		if st.root.Key <= n.Key {
+			n.Left = st.root
+			n.Right = st.root.Right
+			st.root.Right = nil
+		} else {
+			n.Right = st.root
+			n.Left = st.root.Left
+			st.root.Left = nil
+		}
+	}
+	st.root = n
+}
+
+// InsertBatch will insert every key-value of kvs, the same as calling Insert for each in order. The batch is sorted first (already sorted batches are left alone, kvs itself is never changed).
+// Each insert leaves its key at the root, which acts as the finger for the next one: the next key is found by going right from the root,
+// and by the dynamic finger property of splay trees an insert takes amortised O(log d), where d is the number of keys between it and the previous one.
+func (st *SplayTree) InsertBatch(kvs []Entry) {
+	for _, kv := range sortedEntries(kvs) {
+		st.Insert(kv.Key, kv.Value)
+	}
+}
+
+// Put will set the value of key, inserting it if it is not in the splay tree. If key has duplicates only the occurrence closest to the root is changed.
+func (st *SplayTree) Put(key int, value interface{}) {
+	st.upsert(key, func(old interface{}, ok bool) (interface{}, bool) {
+		return value, true
+	})
+}
+
+// PutIfAbsent will insert key only if it is not already in the splay tree. It will return whether or not the tree was changed.
+func (st *SplayTree) PutIfAbsent(key int, value interface{}) bool {
+	inserted := false
+	st.upsert(key, func(old interface{}, ok bool) (interface{}, bool) {
+		inserted = !ok
+		return value, !ok
+	})
+	return inserted
+}
+
+// Update calls fn with the current value of key and whether it was found, then stores the value fn returns. If fn returns false the tree is left unchanged.
+func (st *SplayTree) Update(key int, fn func(old interface{}, ok bool) (interface{}, bool)) {
+	st.upsert(key, fn)
+}
+
+// GetOrInsert returns the value of key if it is in the splay tree, otherwise it inserts value and returns it. loaded reports whether the value was already there.
+func (st *SplayTree) GetOrInsert(key int, value interface{}) (actual interface{}, loaded bool) {
+	st.upsert(key, func(old interface{}, ok bool) (interface{}, bool) {
+		actual, loaded = old, ok
+		if !ok {
+			actual = value
+		}
+		return actual, !ok
+	})
+	return actual, loaded
+}
+
+// Swap sets the value of key, inserting it if it is not in the splay tree, and returns the previous value. loaded reports whether there was a previous value.
+func (st *SplayTree) Swap(key int, value interface{}) (previous interface{}, loaded bool) {
+	st.upsert(key, func(old interface{}, ok bool) (interface{}, bool) {
+		previous, loaded = old, ok
+		return value, true
+	})
+	return previous, loaded
+}
+
+// upsert splays key to the root with a single descent. fn is given the value of the occurrence of key closest to the root and decides the value to store.
+func (st *SplayTree) upsert(key int, fn func(old interface{}, ok bool) (interface{}, bool)) {
+	st.splayKey(key)
+	if st.root != nil && st.root.Key == key {
+		if value, store := fn(st.root.Val, true); store {
+			st.root.Val = value
+		}
+		return
+	}
+	// the search ended at the node just before or after key, since no occurrence of key was on its path
+	if value, store := fn(nil, false); store {
+		st.link(newNode(key, value))
+	}
+}
+
+// Delete will delete the closest occurance of the key to the root in the splay tree. It will return whether or not the tree was changed.
+// The node is splayed to the root and replaced by joining its subtrees, if key is not found the last node on its path is splayed instead.
+func (st *SplayTree) Delete(key int) bool {
+	st.splayKey(key)
+	if st.root == nil || st.root.Key != key {
+		return false
+	}
+	st.size--
+	if st.root.Left == nil {
+		st.root = st.root.Right
+		return true
+	}
+	// the largest node of the left subtree has no right child once splayed, so the right subtree hangs there
+	right := st.root.Right
+	st.root = splay(st.root.Left, math.MaxInt, false)
+	st.root.Right = right
+	return true
+}
+
+// Find will find key in the splay tree and return the node, which is splayed to the root. Find will return the closest occurance of key to the root.
+func (st *SplayTree) Find(key int) *interface{} {
+	st.splayKey(key)
+	if st.root == nil || st.root.Key != key {
+		return nil
+	}
+	return &st.root.Val
+}
+
+// Contains determines if key exists in the splay tree and returns the result. Like Find it splays the tree.
+func (st *SplayTree) Contains(key int) bool {
+	return st.Find(key) != nil
+}
+
+// FindAll returns the values of every occurrence of key in insertion order. It returns an empty slice if key is not in the splay tree. It does not splay the tree.
+func (st *SplayTree) FindAll(key int) []interface{} {
+	return st.view().FindAll(key)
+}
+
+// Count returns the number of occurrences of key in the splay tree. It does not splay the tree.
+func (st *SplayTree) Count(key int) int {
+	return st.view().Count(key)
+}
+
+// DeleteAll will delete every occurrence of key in the splay tree. It will return the number of nodes deleted.
+func (st *SplayTree) DeleteAll(key int) int {
+	count := 0
+	for st.Delete(key) {
+		count++
+	}
+	return count
+}
+
+// SplitAt cuts the splay tree in two by key. left will hold every key smaller than key and right will hold the rest. st is left empty since its nodes are reused.
+// The boundary is splayed to the root and cut off on one side, which takes amortised O(log n). The nodes do not count their subtrees,
+// so the halves are counted a node at a time from both ends together, which stops once the smaller half has been walked.
+func (st *SplayTree) SplitAt(key int) (left SplayTree, right SplayTree) {
+	if st.root == nil {
+		return NewSplayTree(), NewSplayTree()
+	}
+	var l, r *node
+	if key == math.MinInt {
+		r = st.root
+	} else {
+		// equal keys are passed on the right, so the root becomes the last key smaller than key or the first key after it
+		st.root = splay(st.root, key-1, false)
+		if st.root.Key < key {
+			l, r = st.root, st.root.Right
+			l.Right = nil
+		} else {
+			l, r = st.root.Left, st.root
+			r.Left = nil
+		}
+	}
+	leftSize := countLeft(l, r, st.size)
+	left = SplayTree{root: l, size: leftSize}
+	right = SplayTree{root: r, size: st.size - leftSize}
+	st.Clear()
+	return left, right
+}
+
+// countLeft returns the number of nodes in l, where l and r hold total nodes together. Both are walked in order at the same time, so only the smaller one is walked to its end.
+func countLeft(l, r *node, total uint64) uint64 {
+	nextL, nextR := (&BSTree{root: l}).iterator(), (&BSTree{root: r}).iterator()
+	countL, countR := uint64(0), uint64(0)
+	for {
+		if _, _, ok := nextL(); !ok {
+			return countL
+		}
+		countL++
+		if _, _, ok := nextR(); !ok {
+			return total - countR
+		}
+		countR++
+	}
+}
+
+// Join appends every key-value in right to st in amortised O(log n). Every key in st must be smaller than or equal to every key in right, otherwise Join returns false and neither tree is changed apart from splaying.
+// The largest key of st is splayed to the root, which leaves it without a right child, and right is attached there. right is left empty since its nodes are reused.
+func (st *SplayTree) Join(right *SplayTree) bool {
+	if right.root == nil {
+		return true
+	}
+	if st.root == nil {
+		*st, *right = *right, NewSplayTree()
+		return true
+	}
+	st.root = splay(st.root, math.MaxInt, false)
+	right.root = splay(right.root, math.MinInt, true)
+	if st.root.Key > right.root.Key {
+		return false
+	}
+	st.root.Right = right.root
+	st.size += right.size
+	right.Clear()
+	return true
+}
+
+// view returns a BSTree sharing the nodes of st, for the methods that only read the tree in order or by level
+func (st *SplayTree) view() *BSTree {
+	return &BSTree{root: st.root, size: st.size}
+}
+
+func (st *SplayTree) Keys() []int {
+	return st.view().Keys()
+}
+
+func (st *SplayTree) Values() []interface{} {
+	return st.view().Values()
+}
+
+// Clear clears the splay tree of all nodes.
+func (st *SplayTree) Clear() {
+	st.root = nil
+	st.size = 0
+}
+
+// Height returns the number of levels in the splay tree, which changes as nodes are splayed.
+func (st *SplayTree) Height() uint64 {
+	return st.view().Height()
+}
+
+// String will return the splay tree in level order, with X for missing children.
+func (st *SplayTree) String() string {
+	return st.view().String()
+}
+
+// Stats walks the splay tree once and returns its shape statistics.
+func (st *SplayTree) Stats() BSTreeStats {
+	return st.view().Stats()
+}
+
+func (st *SplayTree) Size() uint64 {
+	return st.size
+}
+
+// Dot will return the splay tree in the graphviz DOT language. Each node is labelled with its key.
+func (st *SplayTree) Dot() string {
+	return st.view().Dot()
+}
+
+// Union returns a new splay tree with every key in st or other. resolve picks the value of a key that is in both trees, if resolve is nil the value from st is kept.
+// Both trees are treated as sets, so only the first occurrence of a duplicate key is used. The result starts out balanced.
+func (st *SplayTree) Union(other *SplayTree, resolve func(key int, a, b interface{}) interface{}) SplayTree {
+	return newSplayTreeFromSorted(mergeSets(st.view().keyValues(), other.view().keyValues(), setUnion, resolve))
+}
+
+// Intersect returns a new splay tree with every key that is in both st and other, using the values from st.
+func (st *SplayTree) Intersect(other *SplayTree) SplayTree {
+	return newSplayTreeFromSorted(mergeSets(st.view().keyValues(), other.view().keyValues(), setIntersect, nil))
+}
+
+// Difference returns a new splay tree with every key in st that is not in other.
+func (st *SplayTree) Difference(other *SplayTree) SplayTree {
+	return newSplayTreeFromSorted(mergeSets(st.view().keyValues(), other.view().keyValues(), setDifference, nil))
+}
+
+// SymmetricDifference returns a new splay tree with every key that is in exactly one of st and other.
+func (st *SplayTree) SymmetricDifference(other *SplayTree) SplayTree {
+	return newSplayTreeFromSorted(mergeSets(st.view().keyValues(), other.view().keyValues(), setSymmetricDifference, nil))
+}
+
+// UnionWith is Union, but st is replaced with the result instead of returning a new splay tree.
+func (st *SplayTree) UnionWith(other *SplayTree, resolve func(key int, a, b interface{}) interface{}) {
+	*st = st.Union(other, resolve)
+}
+
+// IntersectWith is Intersect, but st is replaced with the result instead of returning a new splay tree.
+func (st *SplayTree) IntersectWith(other *SplayTree) {
+	*st = st.Intersect(other)
+}
+
+// DifferenceWith is Difference, but st is replaced with the result instead of returning a new splay tree.
+func (st *SplayTree) DifferenceWith(other *SplayTree) {
+	*st = st.Difference(other)
+}
+
+// SymmetricDifferenceWith is SymmetricDifference, but st is replaced with the result instead of returning a new splay tree.
+func (st *SplayTree) SymmetricDifferenceWith(other *SplayTree) {
+	*st = st.SymmetricDifference(other)
+}
+
+func newSplayTreeFromSorted(kvs []*keyValue) SplayTree {
+	return SplayTree{root: buildBSTNode(kvs), size: uint64(len(kvs))}
+}
+
+// Apply replays patch onto st: added keys are inserted, removed keys are deleted and changed keys are set with Put.
+// Duplicate keys are changed at the position in Index, see applyKeyChanges. It stops at the first change that does not fit the tree and returns an error for it, the keys before it stay changed.
+func (st *SplayTree) Apply(patch Patch) error {
+	return applyPatch(st, patch)
+}
+
+func (st *SplayTree) iterator() func() (int, interface{}, bool) {
+	return st.view().iterator()
+}
+
+// Verify walks the whole splay tree and checks its invariants. It returns nil if the tree is valid, otherwise an error describing the first problem found.
+func (st *SplayTree) Verify() error {
+	count := uint64(0)
+	if err := verifySplayNode(st.root, nil, nil, &count); err != nil {
+		return err
+	}
+	if count != st.size {
+		return errors.New("tree holds " + strconv.FormatUint(count, 10) + " nodes but size is " + strconv.FormatUint(st.size, 10))
+	}
+	return nil
+}
+
+// verifySplayNode checks n and its subtree. Keys must be within [lo, hi] since rotations can leave duplicates on either side (nil is unbounded)
+func verifySplayNode(n *node, lo, hi *int, count *uint64) error {
+	if n == nil {
+		return nil
+	}
+	if (lo != nil && n.Key < *lo) || (hi != nil && n.Key > *hi) {
+		return errors.New("key " + strconv.Itoa(n.Key) + " is on the wrong side of one of its ancestors")
+	}
+	*count++
+	if err := verifySplayNode(n.Left, lo, &n.Key, count); err != nil {
+		return err
+	}
+	return verifySplayNode(n.Right, &n.Key, hi, count)
+}
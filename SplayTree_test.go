@@ -0,0 +1,378 @@
+package GoTrees
+
+import (
+	"math"
+	"math/rand"
+	sc "strconv"
+	"testing"
+)
+
+func TestSplayTreeEmptyAllOps(t *testing.T) {
+	ST := NewSplayTree()
+
+	keys := ST.Keys()
+	vals := ST.Values()
+	h := ST.Height()
+	val := ST.Find(1)
+	changed := ST.Delete(1)
+	actual := ST.String()
+
+	if len(keys) != 0 || len(vals) != 0 || h != 0 || val != nil || changed != false || actual != "X \n" || ST.Verify() != nil {
+		t.Fatal("A splay tree operation failed when the tree was empty ")
+	}
+}
+
+func TestSplayTreeInsert(t *testing.T) {
+	ST := NewSplayTree()
+	keys := rand.Perm(nRAND)
+
+	for i, key := range keys {
+		ST.Insert(key, key*2)
+		if ST.root.Key != key {
+			t.Fatal("Inserted key " + sc.Itoa(key) + " was not splayed to the root. ")
+		}
+		if err := ST.Verify(); err != nil {
+			t.Fatal(err)
+		}
+		if ST.Size() != uint64(i+1) {
+			t.Fatal("Splay tree size incorrect, expected " + sc.Itoa(i+1) + " but got " + sc.Itoa(int(ST.Size())) + ". ")
+		}
+	}
+	expected := make([]int, nRAND)
+	for i := range expected {
+		expected[i] = i
+	}
+	checkKeys(t, "Splay tree", ST.Keys(), expected)
+	for i, v := range ST.Values() {
+		if v.(int) != i*2 {
+			t.Fatal("Splay tree value of " + sc.Itoa(i) + " was incorrect. ")
+		}
+	}
+}
+
+func TestSplayTreeFind(t *testing.T) {
+	ST := NewSplayTree()
+	keys := rand.Perm(nRAND)
+
+	for _, key := range keys {
+		ST.Insert(key*2, key)
+	}
+	for i := 0; i < nRAND*2; i++ {
+		key := rand.Intn(nRAND * 2)
+		val := ST.Find(key)
+		if key%2 == 0 && (val == nil || (*val).(int) != key/2) {
+			t.Fatal("Could not find key " + sc.Itoa(key) + " in the splay tree. ")
+		} else if key%2 == 1 && val != nil {
+			t.Fatal("Found key " + sc.Itoa(key) + " that was not in the splay tree. ")
+		}
+		if key%2 == 0 && ST.root.Key != key {
+			t.Fatal("Found key " + sc.Itoa(key) + " was not splayed to the root. ")
+		}
+		if err := ST.Verify(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if ST.Contains(-1) || ST.Contains(nRAND*2) || !ST.Contains(0) {
+		t.Fatal("Contains was incorrect at the ends of the splay tree. ")
+	}
+}
+
+func TestSplayTreeDelete(t *testing.T) {
+	ST := NewSplayTree()
+	keys := rand.Perm(nRAND)
+
+	for _, key := range keys {
+		ST.Insert(key, nil)
+	}
+	rand.Shuffle(len(keys), func(i, j int) { keys[i], keys[j] = keys[j], keys[i] })
+	for i, k := range keys {
+		if !ST.Delete(k) {
+			t.Fatal("Splay tree returned false when tree should have been modified. ")
+		}
+		if ST.Delete(k) || ST.Find(k) != nil {
+			t.Fatal("Found deleted node after deletion of:" + sc.Itoa(k) + ". ")
+		}
+		if err := ST.Verify(); err != nil {
+			t.Fatal(err)
+		}
+		if ST.Size() != uint64(nRAND-(i+1)) {
+			t.Fatal("Splay tree size incorrect, expected " + sc.Itoa(nRAND-(i+1)) + " but got " + sc.Itoa(int(ST.Size())) + ". ")
+		}
+	}
+	if ST.root != nil {
+		t.Fatal("Splay tree was not empty after deleting every key. ")
+	}
+}
+
+func TestSplayTreeDuplicates(t *testing.T) {
+	ST := NewSplayTree()
+	counts := make([]int, nRAND/10)
+
+	for i := 0; i < nRAND*2; i++ {
+		key := rand.Intn(nRAND / 10)
+		counts[key]++
+		ST.Insert(key, i)
+		// splaying other keys rotates the duplicates around, which must keep them in insertion order
+		ST.Find(rand.Intn(nRAND / 10))
+	}
+	if err := ST.Verify(); err != nil {
+		t.Fatal(err)
+	}
+	for key, count := range counts {
+		vals := ST.FindAll(key)
+		if len(vals) != count || ST.Count(key) != count {
+			t.Fatal("Expected " + sc.Itoa(count) + " occurrences of " + sc.Itoa(key) + " but found " + sc.Itoa(len(vals)))
+		}
+		for i := 1; i < len(vals); i++ {
+			if vals[i].(int) < vals[i-1].(int) {
+				t.Fatal("FindAll did not return the values of " + sc.Itoa(key) + " in insertion order. ")
+			}
+		}
+	}
+	remaining := nRAND * 2
+	for key, count := range counts {
+		if deleted := ST.DeleteAll(key); deleted != count {
+			t.Fatal("Expected to delete " + sc.Itoa(count) + " occurrences of " + sc.Itoa(key) + " but deleted " + sc.Itoa(deleted))
+		}
+		remaining -= count
+		if ST.Contains(key) || ST.Size() != uint64(remaining) {
+			t.Fatal("Splay tree still holds " + sc.Itoa(key) + " or has the wrong size after DeleteAll. ")
+		}
+		if err := ST.Verify(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestSplayTreePut(t *testing.T) {
+	ST := NewSplayTree()
+
+	for i := 0; i < nRAND*2; i++ {
+		key := rand.Intn(nRAND)
+		ST.Put(key, i)
+		if val := ST.Find(key); val == nil || (*val).(int) != i {
+			t.Fatal("Put did not set the value of " + sc.Itoa(key) + ". ")
+		}
+	}
+	if err := ST.Verify(); err != nil {
+		t.Fatal(err)
+	}
+	for key := 0; key < nRAND; key++ {
+		if ST.Count(key) > 1 {
+			t.Fatal("Put inserted a duplicate of " + sc.Itoa(key) + ". ")
+		}
+	}
+
+	ST.Clear()
+	if !ST.PutIfAbsent(1, "a") || ST.PutIfAbsent(1, "b") || *ST.Find(1) != "a" {
+		t.Fatal("PutIfAbsent replaced a value that was already in the splay tree. ")
+	}
+	if actual, loaded := ST.GetOrInsert(2, "c"); loaded || actual != "c" {
+		t.Fatal("GetOrInsert did not insert a missing key. ")
+	}
+	if actual, loaded := ST.GetOrInsert(2, "d"); !loaded || actual != "c" {
+		t.Fatal("GetOrInsert did not return the value already in the splay tree. ")
+	}
+	if previous, loaded := ST.Swap(1, "e"); !loaded || previous != "a" || *ST.Find(1) != "e" {
+		t.Fatal("Swap did not return the previous value. ")
+	}
+	ST.Update(3, func(old interface{}, ok bool) (interface{}, bool) {
+		return "f", false
+	})
+	if ST.Contains(3) || ST.Size() != 2 {
+		t.Fatal("Update changed the splay tree when fn returned false. ")
+	}
+	if err := ST.Verify(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSplayTreeSetOps(t *testing.T) {
+	a, b := NewSplayTree(), NewSplayTree()
+	for _, key := range []int{1, 2, 3, 5, 8} {
+		a.Insert(key, nil)
+	}
+	for _, key := range []int{2, 3, 4, 8, 9} {
+		b.Insert(key, nil)
+	}
+
+	union := a.Union(&b, nil)
+	checkKeys(t, "Splay tree union", union.Keys(), []int{1, 2, 3, 4, 5, 8, 9})
+	intersect := a.Intersect(&b)
+	checkKeys(t, "Splay tree intersect", intersect.Keys(), []int{2, 3, 8})
+	difference := a.Difference(&b)
+	checkKeys(t, "Splay tree difference", difference.Keys(), []int{1, 5})
+	symmetric := a.SymmetricDifference(&b)
+	checkKeys(t, "Splay tree symmetric difference", symmetric.Keys(), []int{1, 4, 5, 9})
+	for _, tree := range []*SplayTree{&union, &intersect, &difference, &symmetric} {
+		if err := tree.Verify(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	a.UnionWith(&b, nil)
+	checkKeys(t, "Splay tree union with", a.Keys(), []int{1, 2, 3, 4, 5, 8, 9})
+}
+
+func TestSplayTreeApply(t *testing.T) {
+	a, b := NewSplayTree(), NewBSTree()
+	for i := 0; i < nRAND; i++ {
+		a.Put(rand.Intn(nRAND), i)
+		b.Put(rand.Intn(nRAND), i)
+	}
+	if err := a.Apply(NewPatch(&a, &b)); err != nil {
+		t.Fatal(err)
+	}
+	if patch := NewPatch(&a, &b); len(patch) != 0 {
+		t.Fatal("Splay tree differs from the BST after applying the patch:\n" + patch.String())
+	}
+	if err := a.Verify(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSplayTreeVerify(t *testing.T) {
+	ST := NewSplayTree()
+	for _, key := range []int{5, 3, 8} {
+		ST.Insert(key, nil)
+	}
+	ST.root.Left.Key = 100
+	if ST.Verify() == nil {
+		t.Fatal("Verify did not find a key on the wrong side. ")
+	}
+	ST.root.Left.Key = ST.root.Key
+	ST.size++
+	if ST.Verify() == nil {
+		t.Fatal("Verify did not find the wrong size. ")
+	}
+}
+
+// skewedKeys returns the keys of a tree in random order, and lookups where a few keys are used far more than the rest
+func skewedKeys() ([]int, []int) {
+	r := rand.New(rand.NewSource(1))
+	keys := r.Perm(1 << 20)
+	zipf := rand.NewZipf(r, 1.2, 1, uint64(len(keys)-1))
+	// rank the keys in a different order than they are inserted, otherwise the hot keys would be near the root of the BST
+	ranks := r.Perm(len(keys))
+	lookups := make([]int, 1<<16)
+	for i := range lookups {
+		lookups[i] = ranks[zipf.Uint64()]
+	}
+	return keys, lookups
+}
+
+func BenchmarkSplayTreeSkewedFind(b *testing.B) {
+	keys, lookups := skewedKeys()
+	ST := NewSplayTree()
+	for _, k := range keys {
+		ST.Insert(k, nil)
+	}
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		for _, k := range lookups {
+			ST.Find(k)
+		}
+	}
+}
+
+func BenchmarkSplayTreeBSTreeSkewedFind(b *testing.B) {
+	keys, lookups := skewedKeys()
+	BST := NewBSTree()
+	for _, k := range keys {
+		BST.Insert(k, nil)
+	}
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		for _, k := range lookups {
+			BST.Find(k)
+		}
+	}
+}
+
+func BenchmarkSplayTreeSequentialInsert(b *testing.B) {
+	for n := 0; n < b.N; n++ {
+		ST := NewSplayTree()
+		for k := 0; k < 1<<12; k++ {
+			ST.Insert(k, nil)
+		}
+	}
+}
+
+func BenchmarkSplayTreeBSTreeSequentialInsert(b *testing.B) {
+	for n := 0; n < b.N; n++ {
+		BST := NewBSTree()
+		for k := 0; k < 1<<12; k++ {
+			BST.Insert(k, nil)
+		}
+	}
+}
+
+func TestSplayTreeSplitAtJoin(t *testing.T) {
+	for i := 0; i < nRAND; i++ {
+		ST := NewSplayTree()
+		for j := 0; j < nRAND; j++ {
+			ST.Insert(rand.Intn(nRAND), j)
+		}
+		all := ST.Keys()
+		values := ST.Values()
+		key := rand.Intn(nRAND+2) - 1
+
+		left, right := ST.SplitAt(key)
+		if ST.Size() != 0 || left.Size()+right.Size() != uint64(nRAND) {
+			t.Fatal("SplitAt " + sc.Itoa(key) + " lost key-values or did not empty the splay tree. ")
+		}
+		for _, tree := range []*SplayTree{&left, &right} {
+			if err := tree.Verify(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for _, k := range left.Keys() {
+			if k >= key {
+				t.Fatal("Left splay tree of split at " + sc.Itoa(key) + " holds " + sc.Itoa(k))
+			}
+		}
+		for _, k := range right.Keys() {
+			if k < key {
+				t.Fatal("Right splay tree of split at " + sc.Itoa(key) + " holds " + sc.Itoa(k))
+			}
+		}
+
+		if left.Size() > 0 && right.Size() > 0 && right.Join(&left) {
+			t.Fatal("Join accepted a splay tree with smaller keys on the right. ")
+		}
+		if !left.Join(&right) || right.Size() != 0 {
+			t.Fatal("Join after split at " + sc.Itoa(key) + " failed. ")
+		}
+		if err := left.Verify(); err != nil {
+			t.Fatal(err)
+		}
+		checkKeys(t, "Joined splay tree", left.Keys(), all)
+		// duplicates keep their insertion order through the split and join
+		checkKeys(t, "Joined splay tree values", intValues(left.Values()), intValues(values))
+	}
+
+	ST := NewSplayTree()
+	ST.Insert(math.MinInt, 0)
+	left, right := ST.SplitAt(math.MinInt)
+	if left.Size() != 0 || right.Size() != 1 || !left.Join(&right) || left.Size() != 1 {
+		t.Fatal("SplitAt the smallest int should put every key on the right. ")
+	}
+}
+
+func TestSplayTreeInsertBatch(t *testing.T) {
+	ST := NewSplayTree()
+	expected := NewBTree(T, nAlloc)
+	for batch := 0; batch < 5; batch++ {
+		kvs := randomBatch(batch)
+		for _, kv := range kvs {
+			expected.Insert(kv.Key, kv.Value)
+		}
+		ST.InsertBatch(kvs)
+		if err := ST.Verify(); err != nil {
+			t.Fatal(err)
+		}
+		if ST.Size() != expected.Size() {
+			t.Fatal("Splay tree size incorrect, expected " + sc.Itoa(int(expected.Size())) + " but got " + sc.Itoa(int(ST.Size())) + ". ")
+		}
+		checkKeys(t, "Splay tree values after InsertBatch", intValues(ST.Values()), intValues(expected.Values()))
+	}
+}
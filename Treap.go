@@ -0,0 +1,450 @@
+package GoTrees
+
+import (
+	"errors"
+	"math/rand"
+	"strconv"
+)
+
+// Treap is a binary search tree where every node also has a random priority, and nodes are kept in heap order of their priorities.
+// The random priorities make the shape of the tree that of a random insertion order, so it is balanced on average whatever order the keys come in.
+// Split and Merge cut and join treaps in O(log n), and Insert and Delete are built from them. Duplicate keys are kept in insertion order.
+type Treap struct {
+	root *treapNode
+	rng  *rand.Rand
+	size uint64
+}
+
+// treapNode is a key-value with a priority no larger than its parent's, and the number of nodes in its subtree
+type treapNode struct {
+	Key         int
+	Val         interface{}
+	priority    uint64
+	count       uint64
+	Left, Right *treapNode
+}
+
+// NewTreap returns an empty treap whose priorities come from a random source seeded with seed, so the same inserts give the same tree.
+// A Treap{} uses the seed 1.
+func NewTreap(seed int64) Treap {
+	return Treap{root: nil, rng: rand.New(rand.NewSource(seed)), size: 0}
+}
+
+func (tr *Treap) newNode(key int, value interface{}) *treapNode {
+	if tr.rng == nil {
+		tr.rng = rand.New(rand.NewSource(1))
+	}
+	return &treapNode{Key: key, Val: value, priority: tr.rng.Uint64(), count: 1}
+}
+
+func treapCount(n *treapNode) uint64 {
+	if n == nil {
+		return 0
+	}
+	return n.count
+}
+
+// update recounts the subtree of n after one of its children changed
+func (n *treapNode) update() {
+	n.count = 1 + treapCount(n.Left) + treapCount(n.Right)
+}
+
+// treapLinked is a treap node as splitTreapBy and mergeTreaps see it. Treap orders its nodes by key and Rope by position, but both cut and join them the same way.
+type treapLinked[N any] interface {
+	comparable
+	treapPriority() uint64
+	children() (left, right N)
+	setChildren(left, right N)
+	// update recounts the subtree after a child changed
+	update()
+}
+
+func (n *treapNode) treapPriority() uint64 {
+	return n.priority
+}
+
+func (n *treapNode) children() (*treapNode, *treapNode) {
+	return n.Left, n.Right
+}
+
+func (n *treapNode) setChildren(left, right *treapNode) {
+	n.Left, n.Right = left, right
+}
+
+// splitTreap cuts n into the nodes with keys smaller than key and the rest. If orEqual is set, keys equal to key go to the left instead.
+func splitTreap(n *treapNode, key int, orEqual bool) (*treapNode, *treapNode) {
+	return splitTreapBy(n, func(n *treapNode) bool {
+		return n.Key < key || (orEqual && n.Key == key)
+	})
+}
+
+// splitTreapBy cuts n into the nodes for which before returns true and the rest. before must hold for a prefix of the nodes in order, and is called once on each node on the way down from the root.
+func splitTreapBy[N treapLinked[N]](n N, before func(n N) bool) (N, N) {
+	var none N
+	if n == none {
+		return none, none
+	}
+	l, r := n.children()
+	if before(n) {
+		left, right := splitTreapBy(r, before)
+		n.setChildren(l, left)
+		n.update()
+		return n, right
+	}
+	left, right := splitTreapBy(l, before)
+	n.setChildren(right, r)
+	n.update()
+	return left, n
+}
+
+// mergeTreaps joins a and b, where every node of a comes before every node of b, by keeping the higher priority root at each step
+func mergeTreaps[N treapLinked[N]](a, b N) N {
+	var none N
+	if a == none {
+		return b
+	}
+	if b == none {
+		return a
+	}
+	if a.treapPriority() > b.treapPriority() {
+		l, r := a.children()
+		a.setChildren(l, mergeTreaps(r, b))
+		a.update()
+		return a
+	}
+	l, r := b.children()
+	b.setChildren(mergeTreaps(a, l), r)
+	b.update()
+	return b
+}
+
+// Insert will insert node into the treap. If the node has a duplicate key, it will be placed after every other occurrence.
+// The node goes down until its priority is higher than the node in its place, which is then split by key to become its children.
+func (tr *Treap) Insert(key int, value interface{}) {
+	tr.root = insertTreap(tr.root, tr.newNode(key, value))
+	tr.size++
+}
+
+func insertTreap(n, x *treapNode) *treapNode {
+	if n == nil || x.priority > n.priority {
+		x.Left, x.Right = splitTreap(n, x.Key, true)
+		x.update()
+		return x
+	}
+	if x.Key < n.Key {
+		n.Left = insertTreap(n.Left, x)
+	} else {
+		n.Right = insertTreap(n.Right, x)
+	}
+	n.update()
+	return n
+}
+
+// InsertBatch will insert every key-value of kvs, the same as calling Insert for each in order. The batch is sorted first (already sorted batches are left alone, kvs itself is never changed).
+// The sorted keys are built into a treap in linear time by keeping a finger on its right spine, so each key only climbs as far as its priority takes it instead of descending from the root.
+// That treap is then united with tr by splitting on whichever root has the higher priority, which takes O(m log(n/m + 1)) for m keys.
+func (tr *Treap) InsertBatch(kvs []Entry) {
+	// spine is the right spine of the batch treap, the top of the stack is its last node and has no right child yet
+	spine := []*treapNode{}
+	for _, kv := range sortedEntries(kvs) {
+		x := tr.newNode(kv.Key, kv.Value)
+		// the spine nodes with lower priorities are finished and become the left subtree of x
+		var last *treapNode
+		for len(spine) > 0 && spine[len(spine)-1].priority < x.priority {
+			last = spine[len(spine)-1]
+			spine = spine[:len(spine)-1]
+			last.update()
+		}
+		x.Left = last
+		if len(spine) > 0 {
+			spine[len(spine)-1].Right = x
+		}
+		spine = append(spine, x)
+	}
+	for i := len(spine) - 1; i >= 0; i-- {
+		spine[i].update()
+	}
+	if len(spine) > 0 {
+		tr.root = uniteTreaps(tr.root, spine[0])
+		tr.size += uint64(len(kvs))
+	}
+}
+
+// uniteTreaps joins the nodes of a and b into one treap. The root with the higher priority stays on top and the other treap is split around its key, so keys equal in both keep every occurrence from a before those from b.
+func uniteTreaps(a, b *treapNode) *treapNode {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	if a.priority >= b.priority {
+		left, right := splitTreap(b, a.Key, false)
+		a.Left = uniteTreaps(a.Left, left)
+		a.Right = uniteTreaps(a.Right, right)
+		a.update()
+		return a
+	}
+	left, right := splitTreap(a, b.Key, true)
+	b.Left = uniteTreaps(left, b.Left)
+	b.Right = uniteTreaps(right, b.Right)
+	b.update()
+	return b
+}
+
+// Put will set the value of key, inserting it if it is not in the treap. If key has duplicates only the occurrence closest to the root is changed.
+func (tr *Treap) Put(key int, value interface{}) {
+	if n := tr.find(key); n != nil {
+		n.Val = value
+		return
+	}
+	tr.Insert(key, value)
+}
+
+// Delete will delete the closest occurance of the key to the root in the treap by merging its children in its place. It will return whether or not the tree was changed.
+func (tr *Treap) Delete(key int) bool {
+	var deleted bool
+	tr.root, deleted = deleteTreap(tr.root, key)
+	if deleted {
+		tr.size--
+	}
+	return deleted
+}
+
+func deleteTreap(n *treapNode, key int) (*treapNode, bool) {
+	if n == nil {
+		return nil, false
+	}
+	var deleted bool
+	if key < n.Key {
+		n.Left, deleted = deleteTreap(n.Left, key)
+	} else if key > n.Key {
+		n.Right, deleted = deleteTreap(n.Right, key)
+	} else {
+		return mergeTreaps(n.Left, n.Right), true
+	}
+	if deleted {
+		n.update()
+	}
+	return n, deleted
+}
+
+// DeleteAll will delete every occurrence of key in the treap. It will return the number of nodes deleted.
+func (tr *Treap) DeleteAll(key int) int {
+	count := 0
+	for tr.Delete(key) {
+		count++
+	}
+	return count
+}
+
+// find returns the occurrence of key closest to the root, or nil
+func (tr *Treap) find(key int) *treapNode {
+	n := tr.root
+	for n != nil && n.Key != key {
+		if key < n.Key {
+			n = n.Left
+		} else {
+			n = n.Right
+		}
+	}
+	return n
+}
+
+// Find will find key in the treap and return the node. Find will return the closest occurance of key to the root.
+func (tr *Treap) Find(key int) *interface{} {
+	if n := tr.find(key); n != nil {
+		return &n.Val
+	}
+	return nil
+}
+
+// Contains determines if key exists in the treap and returns the result.
+func (tr *Treap) Contains(key int) bool {
+	return tr.find(key) != nil
+}
+
+// FindAll returns the values of every occurrence of key in insertion order. It returns an empty slice if key is not in the treap.
+func (tr *Treap) FindAll(key int) []interface{} {
+	vals := []interface{}{}
+	tr.Range(key, key, func(key int, value interface{}) bool {
+		vals = append(vals, value)
+		return true
+	})
+	return vals
+}
+
+// Count returns the number of occurrences of key in the treap.
+func (tr *Treap) Count(key int) int {
+	count := 0
+	tr.Range(key, key, func(key int, value interface{}) bool {
+		count++
+		return true
+	})
+	return count
+}
+
+// Split cuts the treap in two by key in O(log n). left will hold every key smaller than key and right will hold the rest.
+// Both treaps share the random source of tr, and tr is left empty since its nodes are reused.
+func (tr *Treap) Split(key int) (left Treap, right Treap) {
+	l, r := splitTreap(tr.root, key, false)
+	left = Treap{root: l, rng: tr.rng, size: treapCount(l)}
+	right = Treap{root: r, rng: tr.rng, size: treapCount(r)}
+	tr.Clear()
+	return left, right
+}
+
+// Merge appends every key-value in right to tr in O(log n). Every key in tr must be smaller than or equal to every key in right, otherwise Merge returns false and neither treap is changed.
+// right is left empty since its nodes are reused.
+func (tr *Treap) Merge(right *Treap) bool {
+	if tr.root != nil && right.root != nil {
+		last, first := tr.root, right.root
+		for last.Right != nil {
+			last = last.Right
+		}
+		for first.Left != nil {
+			first = first.Left
+		}
+		if last.Key > first.Key {
+			return false
+		}
+	}
+	tr.root = mergeTreaps(tr.root, right.root)
+	tr.size += right.size
+	right.Clear()
+	return true
+}
+
+// SplitAt is Split under the name the BTree, IntervalTree and SplayTree use for it.
+func (tr *Treap) SplitAt(key int) (left Treap, right Treap) {
+	return tr.Split(key)
+}
+
+// Join is Merge under the name the BTree, IntervalTree and SplayTree use for it.
+func (tr *Treap) Join(right *Treap) bool {
+	return tr.Merge(right)
+}
+
+// Walk calls fn on every key-value in order, until fn returns false.
+func (tr *Treap) Walk(fn func(key int, value interface{}) bool) {
+	nodeStack := []*treapNode{}
+	n := tr.root
+	for n != nil || len(nodeStack) != 0 {
+		if n != nil {
+			nodeStack = append(nodeStack, n)
+			n = n.Left
+		} else {
+			n = nodeStack[len(nodeStack)-1]
+			nodeStack = nodeStack[:len(nodeStack)-1]
+			if !fn(n.Key, n.Val) {
+				return
+			}
+			n = n.Right
+		}
+	}
+}
+
+// Range calls fn on every key-value with lo <= key <= hi in order, until fn returns false.
+func (tr *Treap) Range(lo, hi int, fn func(key int, value interface{}) bool) {
+	nodeStack := []*treapNode{}
+	n := tr.root
+	for n != nil || len(nodeStack) != 0 {
+		if n != nil {
+			if n.Key < lo {
+				// this node and its left subtree are below the range
+				n = n.Right
+			} else {
+				nodeStack = append(nodeStack, n)
+				n = n.Left
+			}
+		} else {
+			n = nodeStack[len(nodeStack)-1]
+			nodeStack = nodeStack[:len(nodeStack)-1]
+			if n.Key > hi || !fn(n.Key, n.Val) {
+				return
+			}
+			n = n.Right
+		}
+	}
+}
+
+func (tr *Treap) Keys() []int {
+	keys := make([]int, 0, tr.size)
+	tr.Walk(func(key int, value interface{}) bool {
+		keys = append(keys, key)
+		return true
+	})
+	return keys
+}
+
+func (tr *Treap) Values() []interface{} {
+	vals := make([]interface{}, 0, tr.size)
+	tr.Walk(func(key int, value interface{}) bool {
+		vals = append(vals, value)
+		return true
+	})
+	return vals
+}
+
+// Clear clears the treap of all nodes. The random source is kept.
+func (tr *Treap) Clear() {
+	tr.root = nil
+	tr.size = 0
+}
+
+// Height returns the number of levels in the treap.
+func (tr *Treap) Height() uint64 {
+	var height func(n *treapNode) uint64
+	height = func(n *treapNode) uint64 {
+		if n == nil {
+			return 0
+		}
+		l, r := height(n.Left), height(n.Right)
+		if l > r {
+			return l + 1
+		}
+		return r + 1
+	}
+	return height(tr.root)
+}
+
+func (tr *Treap) Size() uint64 {
+	return tr.size
+}
+
+// Verify walks the whole treap and checks that keys are in order, priorities are in heap order and every subtree count is correct.
+// It returns nil if the tree is valid, otherwise an error describing the first problem found.
+func (tr *Treap) Verify() error {
+	if err := verifyTreapNode(tr.root, nil, nil); err != nil {
+		return err
+	}
+	if count := treapCount(tr.root); count != tr.size {
+		return errors.New("tree holds " + strconv.FormatUint(count, 10) + " nodes but size is " + strconv.FormatUint(tr.size, 10))
+	}
+	return nil
+}
+
+// verifyTreapNode checks n and its subtree. Keys must be within [lo, hi] since merges can leave duplicates on either side (nil is unbounded)
+func verifyTreapNode(n *treapNode, lo, hi *int) error {
+	if n == nil {
+		return nil
+	}
+	if (lo != nil && n.Key < *lo) || (hi != nil && n.Key > *hi) {
+		return errors.New("key " + strconv.Itoa(n.Key) + " is on the wrong side of one of its ancestors")
+	}
+	for _, child := range []*treapNode{n.Left, n.Right} {
+		if child != nil && child.priority > n.priority {
+			return errors.New("key " + strconv.Itoa(child.Key) + " has a higher priority than its parent " + strconv.Itoa(n.Key))
+		}
+	}
+	if err := verifyTreapNode(n.Left, lo, &n.Key); err != nil {
+		return err
+	}
+	if err := verifyTreapNode(n.Right, &n.Key, hi); err != nil {
+		return err
+	}
+	if count := 1 + treapCount(n.Left) + treapCount(n.Right); n.count != count {
+		return errors.New("key " + strconv.Itoa(n.Key) + " counts " + strconv.FormatUint(n.count, 10) + " nodes in its subtree but has " + strconv.FormatUint(count, 10))
+	}
+	return nil
+}
@@ -0,0 +1,268 @@
+package GoTrees
+
+import (
+	"math/rand"
+	sc "strconv"
+	"testing"
+)
+
+func TestTreapEmptyAllOps(t *testing.T) {
+	TR := NewTreap(1)
+
+	keys := TR.Keys()
+	vals := TR.Values()
+	h := TR.Height()
+	val := TR.Find(1)
+	changed := TR.Delete(1)
+	left, right := TR.Split(1)
+
+	if len(keys) != 0 || len(vals) != 0 || h != 0 || val != nil || changed != false || left.Size() != 0 || right.Size() != 0 || TR.Verify() != nil {
+		t.Fatal("A treap operation failed when the tree was empty ")
+	}
+	if !left.Merge(&right) || left.Size() != 0 {
+		t.Fatal("Merging two empty treaps failed. ")
+	}
+}
+
+func TestTreapInsert(t *testing.T) {
+	TR := NewTreap(int64(T))
+	keys := rand.Perm(nRAND)
+
+	for i, key := range keys {
+		TR.Insert(key, key*2)
+		if err := TR.Verify(); err != nil {
+			t.Fatal(err)
+		}
+		if TR.Size() != uint64(i+1) {
+			t.Fatal("Treap size incorrect, expected " + sc.Itoa(i+1) + " but got " + sc.Itoa(int(TR.Size())) + ". ")
+		}
+	}
+	for i, key := range TR.Keys() {
+		if key != i {
+			t.Fatal("Treap keys were incorrect, expected " + sc.Itoa(i) + " at index " + sc.Itoa(i) + " but got " + sc.Itoa(key) + ". ")
+		}
+	}
+	for _, key := range keys {
+		if val := TR.Find(key); val == nil || (*val).(int) != key*2 {
+			t.Fatal("Could not find key " + sc.Itoa(key) + " in the treap. ")
+		}
+	}
+	if TR.Contains(-1) || TR.Contains(nRAND) {
+		t.Fatal("Found a key that was not in the treap. ")
+	}
+}
+
+func TestTreapHeight(t *testing.T) {
+	TR := NewTreap(1)
+	n := 1 << 12
+
+	// sorted keys make a BSTree a list, the priorities keep a treap balanced
+	for i := 0; i < n; i++ {
+		TR.Insert(i, nil)
+	}
+	if h := TR.Height(); h > 48 {
+		t.Fatal("Treap of " + sc.Itoa(n) + " sorted keys has height " + sc.Itoa(int(h)) + ". ")
+	}
+}
+
+func TestTreapSeed(t *testing.T) {
+	a, b, c := NewTreap(7), NewTreap(7), NewTreap(8)
+	for i := 0; i < nRAND; i++ {
+		a.Insert(i, nil)
+		b.Insert(i, nil)
+		c.Insert(i, nil)
+	}
+	if a.root.Key != b.root.Key || a.Height() != b.Height() {
+		t.Fatal("Treaps with the same seed had different shapes. ")
+	}
+	if a.root.priority == c.root.priority {
+		t.Fatal("Treaps with different seeds had the same priorities. ")
+	}
+}
+
+func TestTreapDelete(t *testing.T) {
+	TR := NewTreap(int64(T))
+	keys := rand.Perm(nRAND)
+
+	for _, key := range keys {
+		TR.Insert(key, nil)
+	}
+	rand.Shuffle(len(keys), func(i, j int) { keys[i], keys[j] = keys[j], keys[i] })
+	for i, k := range keys {
+		if !TR.Delete(k) {
+			t.Fatal("Treap returned false when tree should have been modified. ")
+		}
+		if TR.Delete(k) || TR.Contains(k) {
+			t.Fatal("Found deleted node after deletion of:" + sc.Itoa(k) + ". ")
+		}
+		if err := TR.Verify(); err != nil {
+			t.Fatal(err)
+		}
+		if TR.Size() != uint64(nRAND-(i+1)) {
+			t.Fatal("Treap size incorrect, expected " + sc.Itoa(nRAND-(i+1)) + " but got " + sc.Itoa(int(TR.Size())) + ". ")
+		}
+	}
+}
+
+func TestTreapDuplicates(t *testing.T) {
+	TR := NewTreap(int64(T))
+	counts := make([]int, nRAND/10)
+
+	for i := 0; i < nRAND*2; i++ {
+		key := rand.Intn(nRAND / 10)
+		counts[key]++
+		TR.Insert(key, i)
+	}
+	if err := TR.Verify(); err != nil {
+		t.Fatal(err)
+	}
+	for key, count := range counts {
+		vals := TR.FindAll(key)
+		if len(vals) != count || TR.Count(key) != count {
+			t.Fatal("Expected " + sc.Itoa(count) + " occurrences of " + sc.Itoa(key) + " but found " + sc.Itoa(len(vals)))
+		}
+		for i := 1; i < len(vals); i++ {
+			if vals[i].(int) < vals[i-1].(int) {
+				t.Fatal("FindAll did not return the values of " + sc.Itoa(key) + " in insertion order. ")
+			}
+		}
+	}
+	remaining := nRAND * 2
+	for key, count := range counts {
+		if deleted := TR.DeleteAll(key); deleted != count {
+			t.Fatal("Expected to delete " + sc.Itoa(count) + " occurrences of " + sc.Itoa(key) + " but deleted " + sc.Itoa(deleted))
+		}
+		remaining -= count
+		if TR.Contains(key) || TR.Size() != uint64(remaining) {
+			t.Fatal("Treap still holds " + sc.Itoa(key) + " or has the wrong size after DeleteAll. ")
+		}
+	}
+}
+
+func TestTreapPut(t *testing.T) {
+	TR := NewTreap(int64(T))
+
+	for i := 0; i < nRAND*2; i++ {
+		key := rand.Intn(nRAND)
+		TR.Put(key, i)
+		if val := TR.Find(key); val == nil || (*val).(int) != i {
+			t.Fatal("Put did not set the value of " + sc.Itoa(key) + ". ")
+		}
+	}
+	for key := 0; key < nRAND; key++ {
+		if TR.Count(key) > 1 {
+			t.Fatal("Put inserted a duplicate of " + sc.Itoa(key) + ". ")
+		}
+	}
+	if err := TR.Verify(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTreapSplitMerge(t *testing.T) {
+	for i := 0; i < nRAND; i++ {
+		TR := NewTreap(int64(i))
+		for j := 0; j < nRAND; j++ {
+			TR.Insert(rand.Intn(nRAND), j)
+		}
+		all := TR.Keys()
+		key := rand.Intn(nRAND+2) - 1
+
+		left, right := TR.Split(key)
+		if TR.Size() != 0 || left.Size()+right.Size() != uint64(nRAND) {
+			t.Fatal("Split at " + sc.Itoa(key) + " lost key-values or did not empty the treap. ")
+		}
+		for _, tree := range []*Treap{&left, &right} {
+			if err := tree.Verify(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for _, k := range left.Keys() {
+			if k >= key {
+				t.Fatal("Left treap of split at " + sc.Itoa(key) + " holds " + sc.Itoa(k))
+			}
+		}
+		for _, k := range right.Keys() {
+			if k < key {
+				t.Fatal("Right treap of split at " + sc.Itoa(key) + " holds " + sc.Itoa(k))
+			}
+		}
+
+		if left.Size() > 0 && right.Size() > 0 && right.Merge(&left) {
+			t.Fatal("Merge accepted a treap with smaller keys on the right. ")
+		}
+		if !left.Merge(&right) || right.Size() != 0 {
+			t.Fatal("Merge after split at " + sc.Itoa(key) + " failed. ")
+		}
+		if err := left.Verify(); err != nil {
+			t.Fatal(err)
+		}
+		checkKeys(t, "Merged treap", left.Keys(), all)
+
+		// SplitAt and Join are the same operations under the names the other trees use
+		left, right = left.SplitAt(key)
+		if !left.Join(&right) || right.Size() != 0 || left.Verify() != nil {
+			t.Fatal("Join after SplitAt " + sc.Itoa(key) + " failed. ")
+		}
+		checkKeys(t, "Joined treap", left.Keys(), all)
+	}
+}
+
+func TestTreapRange(t *testing.T) {
+	TR := NewTreap(int64(T))
+	for _, key := range rand.Perm(nRAND) {
+		TR.Insert(key, key)
+	}
+	for i := 0; i < nRAND; i++ {
+		lo, hi := rand.Intn(nRAND), rand.Intn(nRAND)
+		expected := []int{}
+		for k := lo; k <= hi; k++ {
+			expected = append(expected, k)
+		}
+		actual := []int{}
+		TR.Range(lo, hi, func(key int, value interface{}) bool {
+			actual = append(actual, key)
+			return true
+		})
+		checkKeys(t, "Treap range", actual, expected)
+	}
+	count := 0
+	TR.Walk(func(key int, value interface{}) bool {
+		count++
+		return count < 10
+	})
+	if count != 10 {
+		t.Fatal("Walk did not stop when fn returned false. ")
+	}
+}
+
+func TestTreapVerify(t *testing.T) {
+	TR := NewTreap(1)
+	for i := 0; i < 10; i++ {
+		TR.Insert(i, nil)
+	}
+	TR.root.priority = 0
+	if TR.Verify() == nil {
+		t.Fatal("Verify did not find a priority out of heap order. ")
+	}
+}
+
+func TestTreapInsertBatch(t *testing.T) {
+	TR := NewTreap(int64(T))
+	expected := NewBTree(T, nAlloc)
+	// several batches so later batches are united with an existing treap
+	for batch := 0; batch < 5; batch++ {
+		kvs := randomBatch(batch)
+		for _, kv := range kvs {
+			expected.Insert(kv.Key, kv.Value)
+		}
+		TR.InsertBatch(kvs)
+		if err := TR.Verify(); err != nil {
+			t.Fatal(err)
+		}
+		if TR.Size() != expected.Size() {
+			t.Fatal("Treap size incorrect, expected " + sc.Itoa(int(expected.Size())) + " but got " + sc.Itoa(int(TR.Size())) + ". ")
+		}
+		checkKeys(t, "Treap values after InsertBatch", intValues(TR.Values()), intValues(expected.Values()))
+	}
+}
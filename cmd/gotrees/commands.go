@@ -0,0 +1,215 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	gt "github.com/Midnight-Sink/GoTrees"
+)
+
+// tree is the method set shared by the trees gotrees can build
+type tree interface {
+	Insert(key int, value interface{})
+	Put(key int, value interface{})
+	Delete(key int) bool
+	Find(key int) *interface{}
+	Contains(key int) bool
+	FindAll(key int) []interface{}
+	Count(key int) int
+	DeleteAll(key int) int
+	Keys() []int
+	Values() []interface{}
+	Height() uint64
+	Size() uint64
+	String() string
+	Dot() string
+	Verify() error
+}
+
+const helpText = `commands:
+  insert KEY [VALUE]   insert a key-value pair (the rest of the line is the value)
+  put KEY [VALUE]      set the value of KEY, inserting it if it is missing
+  delete KEY           delete the occurrence of KEY closest to the root
+  deleteall KEY        delete every occurrence of KEY
+  find KEY             print the value stored for KEY
+  findall KEY          print the values of every occurrence of KEY in insertion order
+  count KEY            print the number of occurrences of KEY
+  contains KEY         print whether KEY is in the tree
+  range LO HI          print every key-value pair with LO <= key <= HI in order
+  keys                 print every key in order
+  values               print every value in key order
+  size                 print the number of key-values
+  height               print the height of the tree
+  stats                print statistics about the tree
+  verify               check the tree invariants
+  print                print the tree one level per line
+  dot                  print the tree in the graphviz DOT language
+  help                 print this message
+  quit                 exit the REPL
+`
+
+// errQuit is returned by run when the quit command is given
+var errQuit = errors.New("quit")
+
+// repl reads one command per line from sc until EOF or quit. Errors are printed and do not stop the REPL.
+func repl(tr tree, sc *bufio.Scanner, w io.Writer) {
+	fmt.Fprint(w, "> ")
+	for sc.Scan() {
+		args := strings.Fields(sc.Text())
+		if len(args) > 0 {
+			if err := run(tr, args, w); err == errQuit {
+				return
+			} else if err != nil {
+				fmt.Fprintln(w, "error:", err)
+			}
+		}
+		fmt.Fprint(w, "> ")
+	}
+	fmt.Fprintln(w)
+}
+
+// run executes a single command against tr and writes the result to w
+func run(tr tree, args []string, w io.Writer) error {
+	cmd, args := strings.ToLower(args[0]), args[1:]
+	switch cmd {
+	case "insert":
+		if len(args) < 1 {
+			return errors.New("usage: insert KEY [VALUE]")
+		}
+		key, err := parseKey(args[0])
+		if err != nil {
+			return err
+		}
+		var value interface{}
+		if len(args) > 1 {
+			value = strings.Join(args[1:], " ")
+		}
+		tr.Insert(key, value)
+	case "put":
+		if len(args) < 1 {
+			return errors.New("usage: put KEY [VALUE]")
+		}
+		key, err := parseKey(args[0])
+		if err != nil {
+			return err
+		}
+		var value interface{}
+		if len(args) > 1 {
+			value = strings.Join(args[1:], " ")
+		}
+		tr.Put(key, value)
+	case "delete":
+		key, err := oneKey(cmd, args)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, tr.Delete(key))
+	case "deleteall":
+		key, err := oneKey(cmd, args)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, tr.DeleteAll(key))
+	case "find":
+		key, err := oneKey(cmd, args)
+		if err != nil {
+			return err
+		}
+		if v := tr.Find(key); v != nil {
+			fmt.Fprintln(w, *v)
+		} else {
+			fmt.Fprintln(w, "not found")
+		}
+	case "findall":
+		key, err := oneKey(cmd, args)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, tr.FindAll(key))
+	case "count":
+		key, err := oneKey(cmd, args)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, tr.Count(key))
+	case "contains":
+		key, err := oneKey(cmd, args)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, tr.Contains(key))
+	case "range":
+		if len(args) != 2 {
+			return errors.New("usage: range LO HI")
+		}
+		lo, err := parseKey(args[0])
+		if err != nil {
+			return err
+		}
+		hi, err := parseKey(args[1])
+		if err != nil {
+			return err
+		}
+		vals := tr.Values()
+		for i, k := range tr.Keys() {
+			if k > hi {
+				break
+			}
+			if k >= lo {
+				fmt.Fprintln(w, k, vals[i])
+			}
+		}
+	case "keys":
+		fmt.Fprintln(w, tr.Keys())
+	case "values":
+		fmt.Fprintln(w, tr.Values())
+	case "size":
+		fmt.Fprintln(w, tr.Size())
+	case "height":
+		fmt.Fprintln(w, tr.Height())
+	case "stats":
+		switch t := tr.(type) {
+		case *gt.BTree:
+			fmt.Fprint(w, t.Stats())
+		case *gt.BSTree:
+			fmt.Fprint(w, t.Stats())
+		}
+	case "verify":
+		if err := tr.Verify(); err != nil {
+			fmt.Fprintln(w, "invalid:", err)
+		} else {
+			fmt.Fprintln(w, "ok")
+		}
+	case "print":
+		fmt.Fprint(w, tr.String())
+	case "dot":
+		fmt.Fprint(w, tr.Dot())
+	case "help":
+		fmt.Fprint(w, helpText)
+	case "quit", "exit":
+		return errQuit
+	default:
+		return fmt.Errorf("unknown command %q, try help", cmd)
+	}
+	return nil
+}
+
+// oneKey parses the arguments of a command that takes exactly one key
+func oneKey(cmd string, args []string) (int, error) {
+	if len(args) != 1 {
+		return 0, fmt.Errorf("usage: %s KEY", cmd)
+	}
+	return parseKey(args[0])
+}
+
+func parseKey(s string) (int, error) {
+	key, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("key %q is not an integer", s)
+	}
+	return key, nil
+}
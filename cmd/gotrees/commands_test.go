@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRunCommands(t *testing.T) {
+	for _, treeType := range []string{"btree", "bst"} {
+		tr, err := newTree(treeType, 0, 0.5)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out bytes.Buffer
+		cmds := []string{"insert 10 ten", "insert 5 five", "insert 20 twenty", "insert 15", "put 15 fifteen", "put 15", "find 5", "find 7", "delete 20", "contains 20", "range 5 15", "size", "verify", "insert 5 again", "count 5", "findall 5", "deleteall 5"}
+		for _, cmd := range cmds {
+			if err := run(tr, strings.Fields(cmd), &out); err != nil {
+				t.Fatal(treeType + ": command " + cmd + " failed: " + err.Error())
+			}
+		}
+		expected := "five\nnot found\ntrue\nfalse\n5 five\n10 ten\n15 <nil>\n3\nok\n2\n[five again]\n2\n"
+		if out.String() != expected {
+			t.Fatal(treeType + ": expected output: \n" + expected + "\n but got \n" + out.String())
+		}
+	}
+}
+
+func TestRunBadCommands(t *testing.T) {
+	tr, _ := newTree("btree", 0, 0.5)
+	var out bytes.Buffer
+	for _, cmd := range []string{"insert", "insert x", "find 1 2", "range 1", "bogus"} {
+		if run(tr, strings.Fields(cmd), &out) == nil {
+			t.Fatal("Command " + cmd + " should have failed. ")
+		}
+	}
+	if _, err := newTree("heap", 0, 0.5); err == nil {
+		t.Fatal("Unknown tree type should have failed. ")
+	}
+}
+
+func TestREPL(t *testing.T) {
+	tr, _ := newTree("bst", 0, 0)
+	var out bytes.Buffer
+	in := bufio.NewScanner(strings.NewReader("insert 1 one\nbogus\n\nkeys\nquit\nkeys\n"))
+
+	repl(tr, in, &out)
+	expected := "> > error: unknown command \"bogus\", try help\n> > [1]\n> "
+	if out.String() != expected {
+		t.Fatal("Expected output: \n" + expected + "\n but got \n" + out.String())
+	}
+}
+
+func TestLoadCSV(t *testing.T) {
+	tr, _ := newTree("btree", 0, 0.5)
+	n, err := loadCSV(tr, strings.NewReader("key,value\n3,c\n1,a\n2,\"b, with comma\"\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 || tr.Size() != 3 {
+		t.Fatal("Expected 3 key-values to be loaded. ")
+	}
+	if v := tr.Find(2); v == nil || (*v).(string) != "b, with comma" {
+		t.Fatal("Key 2 was not loaded with the quoted value. ")
+	}
+	if _, err := loadCSV(tr, strings.NewReader("1,a\nx,b\n")); err == nil {
+		t.Fatal("A non-integer key after the header should have failed. ")
+	}
+}
+
+func TestLoadJSONLines(t *testing.T) {
+	tr, _ := newTree("bst", 0, 0)
+	n, err := loadJSONLines(tr, strings.NewReader("{\"key\": 2, \"value\": \"b\"}\n\n{\"key\": 1, \"value\": {\"x\": 1}}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 || tr.Size() != 2 {
+		t.Fatal("Expected 2 key-values to be loaded. ")
+	}
+	if v := tr.Find(2); v == nil || (*v).(string) != "b" {
+		t.Fatal("Key 2 was not loaded with its value. ")
+	}
+	if _, err := loadJSONLines(tr, strings.NewReader("{\"value\": 1}\n")); err == nil {
+		t.Fatal("A record without a key should have failed. ")
+	}
+}
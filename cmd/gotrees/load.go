@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// loadFile inserts every key-value pair in the file into tr and returns how many were inserted
+func loadFile(tr tree, path, format string) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	}
+	switch format {
+	case "csv":
+		return loadCSV(tr, f)
+	case "jsonl", "json", "ndjson":
+		return loadJSONLines(tr, f)
+	}
+	return 0, fmt.Errorf("unknown load format %q, expected csv or jsonl", format)
+}
+
+// loadCSV reads "key,value" records. A first record whose key is not an integer is treated as a header and skipped.
+func loadCSV(tr tree, r io.Reader) (int, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	n := 0
+	for line := 1; ; line++ {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			return n, nil
+		} else if err != nil {
+			return n, err
+		}
+		key, err := strconv.Atoi(strings.TrimSpace(rec[0]))
+		if err != nil {
+			if line == 1 {
+				continue
+			}
+			return n, fmt.Errorf("line %d: key %q is not an integer", line, rec[0])
+		}
+		var value interface{}
+		if len(rec) > 1 {
+			value = rec[1]
+		}
+		tr.Insert(key, value)
+		n++
+	}
+}
+
+// jsonRecord is a single line of a JSON lines load file
+type jsonRecord struct {
+	Key   *int        `json:"key"`
+	Value interface{} `json:"value"`
+}
+
+// loadJSONLines reads one {"key": 1, "value": ...} object per line. Blank lines are skipped.
+func loadJSONLines(tr tree, r io.Reader) (int, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	n := 0
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" {
+			continue
+		}
+		var rec jsonRecord
+		if err := json.Unmarshal([]byte(text), &rec); err != nil {
+			return n, fmt.Errorf("line %d: %v", line, err)
+		}
+		if rec.Key == nil {
+			return n, fmt.Errorf("line %d: missing \"key\"", line)
+		}
+		tr.Insert(*rec.Key, rec.Value)
+		n++
+	}
+	return n, sc.Err()
+}
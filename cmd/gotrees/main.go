@@ -0,0 +1,64 @@
+// Command gotrees builds one of the GoTrees trees from a data file and runs commands against it.
+//
+// Usage:
+//
+//	gotrees [-tree btree|bst] [-t n] [-alloc f] [-load file] [-format csv|jsonl] [command args...]
+//
+// If a command is given on the command line it is run once and gotrees exits, otherwise an interactive REPL is started on stdin.
+// Type "help" in the REPL for the list of commands.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+
+	gt "github.com/Midnight-Sink/GoTrees"
+)
+
+func main() {
+	treeType := flag.String("tree", "btree", "tree type to build: btree or bst")
+	t := flag.Uint("t", 0, "b-tree t parameter, the max degree will be 2*t+2")
+	alloc := flag.Float64("alloc", 0.5, "b-tree node preallocation as a fraction of t, between 0 and 1")
+	load := flag.String("load", "", "file of key-value pairs to insert before running commands")
+	format := flag.String("format", "", "format of the load file: csv or jsonl (default: from the file extension)")
+	flag.Parse()
+
+	tr, err := newTree(*treeType, *t, float32(*alloc))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	if *load != "" {
+		n, err := loadFile(tr, *load, *format)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "loaded %d key-values from %s\n", n, *load)
+	}
+
+	if flag.NArg() > 0 {
+		if err := run(tr, flag.Args(), os.Stdout); err != nil && err != errQuit {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+	repl(tr, bufio.NewScanner(os.Stdin), os.Stdout)
+}
+
+// newTree creates an empty tree of the given type
+func newTree(treeType string, t uint, alloc float32) (tree, error) {
+	switch treeType {
+	case "btree":
+		bt := gt.NewBTree(t, alloc)
+		return &bt, nil
+	case "bst":
+		bst := gt.NewBSTree()
+		return &bst, nil
+	}
+	return nil, fmt.Errorf("unknown tree type %q, expected btree or bst", treeType)
+}
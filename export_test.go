@@ -0,0 +1,6 @@
+package GoTrees
+
+// setBeforeDelete makes Delete call fn between reading the value of a node and swapping it out, so a test can change the value in that window. nil removes it.
+func (sl *ConcurrentSkipList) setBeforeDelete(fn func(key int)) {
+	sl.beforeDelete = fn
+}